@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sberTestTask/internal/backup"
+	"sberTestTask/internal/config"
+	"sberTestTask/internal/reminder"
+	"sberTestTask/internal/todo/repository/postgres"
+	"sberTestTask/internal/webhook"
+	"strings"
+	"time"
+)
+
+// runBackup implements "server backup [-o file]".
+func runBackup(ctx context.Context, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	out := flags.String("o", "-", "archive file, - for stdout; a .gz suffix compresses it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := openDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	w, commit, err := createArchive(*out)
+	if err != nil {
+		return err
+	}
+	manifest, err := backup.Backup(ctx, postgres.NewPostgresRepository(db), w, time.Now(), backupStores(db)...)
+	if err := commit(err); err != nil {
+		return err
+	}
+
+	slog.Info("backup written",
+		slog.String("file", *out),
+		slog.Any("counts", manifest.Counts),
+		slog.String("sha256", manifest.SHA256),
+	)
+	return nil
+}
+
+// runRestore implements "server restore -i file [-on-conflict strategy] [-verify-only]".
+func runRestore(ctx context.Context, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	in := flags.String("i", "", "archive file, - for stdin; gzip-compressed archives are detected")
+	onConflict := flags.String("on-conflict", string(backup.Skip), "what to do with tasks whose id exists: skip, overwrite or renumber")
+	verifyOnly := flags.Bool("verify-only", false, "only check the archive")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" {
+		return errors.New("restore: -i is required")
+	}
+	strategy, err := backup.ParseStrategy(*onConflict)
+	if err != nil {
+		return err
+	}
+
+	path, cleanup, err := seekableArchive(*in)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// The archive is read twice so that nothing is written from a
+	// corrupted or truncated file.
+	var manifest *backup.Manifest
+	err = readArchive(path, func(r io.Reader) (err error) {
+		manifest, err = backup.Verify(r)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("verify %s: %w", *in, err)
+	}
+	slog.Info("archive verified", slog.String("file", *in), slog.Any("counts", manifest.Counts))
+	if *verifyOnly {
+		return nil
+	}
+
+	db, err := openDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var stats backup.Stats
+	err = readArchive(path, func(r io.Reader) (err error) {
+		stats, err = backup.Restore(ctx, postgres.NewPostgresRepository(db), r, strategy, backupStores(db)...)
+		return err
+	})
+	logAttrs := []any{
+		slog.String("strategy", string(strategy)),
+		slog.Int("created", stats.Created),
+		slog.Int("updated", stats.Updated),
+		slog.Int("skipped", stats.Skipped),
+		slog.Int("webhook_subscriptions", stats.Subscriptions),
+		slog.Int("reminders", stats.Reminders),
+	}
+	if err != nil {
+		slog.Error("restore stopped", logAttrs...)
+		return err
+	}
+	slog.Info("restore finished", logAttrs...)
+	return nil
+}
+
+// backupStores adds the webhook subscriptions and reminders to archives.
+func backupStores(db *sql.DB) []backup.Option {
+	return []backup.Option{
+		backup.WithSubscriptions(webhook.NewStore(db)),
+		backup.WithReminders(reminder.NewStore(db)),
+	}
+}
+
+// createArchive opens the backup destination. The returned commit function
+// finishes the file; for a path the archive only replaces an existing file
+// once it was written completely.
+func createArchive(path string) (io.Writer, func(error) error, error) {
+	if path == "-" {
+		return os.Stdout, func(err error) error { return err }, nil
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".backup-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	var w io.Writer = f
+	var zw *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		zw = gzip.NewWriter(f)
+		w = zw
+	}
+
+	commit := func(err error) error {
+		if err == nil && zw != nil {
+			err = zw.Close()
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(f.Name(), path)
+		}
+		if err != nil {
+			os.Remove(f.Name())
+		}
+		return err
+	}
+	return w, commit, nil
+}
+
+// seekableArchive returns a path the archive can be read from repeatedly,
+// copying stdin to a temporary file.
+func seekableArchive(path string) (string, func(), error) {
+	if path != "-" {
+		return path, func() {}, nil
+	}
+
+	f, err := os.CreateTemp("", "restore-*.ndjson")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.Remove(f.Name()) }
+	_, err = io.Copy(f, os.Stdin)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return f.Name(), cleanup, nil
+}
+
+func readArchive(path string, fn func(io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Compressed archives are recognised by the gzip magic number, which
+	// also covers archives piped through stdin.
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+	return fn(r)
+}
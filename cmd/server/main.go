@@ -1,16 +1,49 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5"
 	_ "github.com/lib/pq"
-	"log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	_ "sberTestTask/docs"
+	"sberTestTask/internal/auth"
+	"sberTestTask/internal/cache"
 	"sberTestTask/internal/config"
+	"sberTestTask/internal/health"
+	"sberTestTask/internal/logger"
+	"sberTestTask/internal/metrics"
+	"sberTestTask/internal/migrations"
+	"sberTestTask/internal/ratelimit"
+	"sberTestTask/internal/reminder"
 	"sberTestTask/internal/todo/delivery/api"
+	"sberTestTask/internal/todo/delivery/graphqlapi"
+	"sberTestTask/internal/todo/delivery/grpcapi"
+	"sberTestTask/internal/todo/delivery/wsapi"
+	"sberTestTask/internal/todo/events"
+	"sberTestTask/internal/todo/repository"
 	"sberTestTask/internal/todo/repository/postgres"
 	"sberTestTask/internal/todo/service"
+	"sberTestTask/internal/tracing"
+	"sberTestTask/internal/webhook"
+	"sberTestTask/internal/worker"
+	todov1 "sberTestTask/pkg/pb/todo/v1"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata"
 )
 
 // @title Swagger Example API
@@ -19,25 +52,467 @@ import (
 // @termsOfService http://swagger.io/terms/
 
 // @host localhost:8080
-// @BasePath /
+// @BasePath /v1
 func main() {
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("Error loading config: %v", err)
+		slog.Error("error loading config", slog.String("error", err.Error()))
+		os.Exit(1)
 	}
 
-	db, err := sql.Open("postgres", cfg.Database.URL)
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	// backup can write the archive to stdout, so other commands log to stderr.
+	logOutput := os.Stderr
+	if command == "serve" {
+		logOutput = os.Stdout
+	}
+	l, err := logger.New(logOutput, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		slog.Error("error configuring logger", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	slog.SetDefault(l)
+
+	switch command {
+	case "serve":
+		err = run(cfg)
+	case "backup", "restore":
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		if command == "backup" {
+			err = runBackup(ctx, cfg, args)
+		} else {
+			err = runRestore(ctx, cfg, args)
+		}
+		stop()
+	default:
+		err = fmt.Errorf("unknown command %q, want serve, backup or restore", command)
+	}
 	if err != nil {
-		log.Fatal(err)
+		msg := command + " failed"
+		if command == "serve" {
+			msg = "server stopped"
+		}
+		slog.Error(msg, slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+}
+
+func run(cfg *config.Config) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Init(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(ctx, cfg)
+	if err != nil {
+		return err
 	}
 	defer db.Close()
 
-	repo := postgres.NewPostgresRepository(db)
-	uc := service.NewTodoUsecase(repo)
-	handler := api.NewHandler(uc)
+	workers := worker.NewGroup()
+
+	schemaVersion, err := migrations.LatestVersion()
+	if err != nil {
+		return err
+	}
+	checks := health.NewRegistry()
+	checks.Register(
+		health.NewDBChecker(db),
+		health.NewMigrationChecker(db, schemaVersion),
+		workers,
+	)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(db, "todo"),
+	)
+
+	var repoOpts []postgres.Option
+	if cfg.Webhooks.Enabled {
+		repoOpts = append(repoOpts, postgres.WithOutbox())
+	}
+	repo := repository.NewMetricsRepository(postgres.NewPostgresRepository(db, repoOpts...), reg)
+	reg.MustRegister(metrics.NewTaskCollector(repo))
+	bus := events.NewBus(cfg.Events.HistorySize)
+	publisher, err := newEventPublisher(cfg, db, repo, bus, workers)
+	if err != nil {
+		return err
+	}
+	eventsHandler := api.NewEventsHandler(bus)
+	hub := wsapi.NewHub(bus)
+	workers.Go("websocket-hub", hub.Run)
+
+	location, err := time.LoadLocation(cfg.Tasks.TimeZone)
+	if err != nil {
+		return fmt.Errorf("tasks.time_zone: %w", err)
+	}
+	timeZones, err := userTimeZones(cfg)
+	if err != nil {
+		return err
+	}
+	uc := service.NewTracingUsecase(service.NewMetricsUsecase(service.NewTodoUsecase(newCachingRepository(cfg, repo, reg),
+		service.WithPublisher(publisher),
+		service.WithLocation(location),
+	), reg))
+	handler := api.NewHandler(uc, api.WithMaxBodySize(cfg.Server.MaxBodySize))
+	graphqlHandler, err := graphqlapi.NewHandler(uc, cfg.GraphQL.ComplexityLimit, cfg.GraphQL.MaxDepth)
+	if err != nil {
+		return err
+	}
 	r := chi.NewRouter()
 
-	api.RegisterRoutes(r, handler)
+	limiter, err := newRateLimiter(cfg, db, workers)
+	if err != nil {
+		return err
+	}
+
+	webhooks := newWebhooks(cfg, db, workers)
+	reminders, err := newReminders(cfg, db, workers)
+	if err != nil {
+		return err
+	}
+	feedTokens, err := newFeedTokens(cfg)
+	if err != nil {
+		return err
+	}
+	authn := newAuthenticator(cfg, feedTokens)
+	deprecations, err := apiDeprecations(cfg)
+	if err != nil {
+		return err
+	}
+
+	r.Use(tracing.Middleware)
+	r.Use(metrics.NewHTTPMetrics(reg).Middleware)
+	api.RegisterRoutes(r, handler, api.RouteOptions{
+		Auth:           authn,
+		RateLimit:      limiter,
+		Events:         eventsHandler,
+		WebSocket:      wsapi.NewHandler(hub, cfg.WebSocket.AllowedOrigins),
+		Webhooks:       webhooks,
+		Reminders:      reminders,
+		GraphQL:        graphqlHandler,
+		FeedTokens:     feedTokens,
+		TimeZones:      timeZones,
+		CacheControl:   newCacheControl(cfg),
+		Deprecations:   deprecations,
+		VersionMetrics: api.NewVersionMetrics(reg),
+	})
+	r.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg}))
+	r.Get("/healthz", checks.Liveness)
+	r.Get("/readyz", checks.Readiness)
+
+	srv := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           r,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	srv.RegisterOnShutdown(eventsHandler.Shutdown)
+
+	grpcSrv := grpc.NewServer(grpcapi.ServerOptions(authn, limiter)...)
+	todov1.RegisterTodoServiceServer(grpcSrv, grpcapi.NewServer(uc, bus, location))
+	grpcLis, err := net.Listen("tcp", ":"+cfg.GRPC.Port)
+	if err != nil {
+		return err
+	}
+
+	serveErr := make(chan error, 2)
+	go func() {
+		slog.Info("listening", slog.String("addr", srv.Addr))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+	}()
+	go func() {
+		slog.Info("grpc listening", slog.String("addr", grpcLis.Addr().String()))
+		if err := grpcSrv.Serve(grpcLis); err != nil {
+			serveErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+	checks.SetShuttingDown()
+	slog.Info("shutting down", slog.Duration("drain_period", cfg.Server.DrainPeriod))
+
+	// Give load balancers time to stop routing new requests here.
+	time.Sleep(cfg.Server.DrainPeriod)
 
-	log.Fatal(http.ListenAndServe(":"+cfg.Server.Port, r))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("http shutdown", slog.String("error", err.Error()))
+	}
+	stopGRPC(shutdownCtx, grpcSrv)
+	if err := workers.Stop(shutdownCtx); err != nil {
+		slog.Error("workers shutdown", slog.String("error", err.Error()))
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("tracing shutdown", slog.String("error", err.Error()))
+	}
+	return nil
+}
+
+// openDB opens the connection pool and pings the database, retrying with
+// exponential backoff while it is not reachable yet.
+func openDB(ctx context.Context, cfg *config.Config) (*sql.DB, error) {
+	db, err := sql.Open("postgres", cfg.Database.URL)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+
+	backoff := cfg.Database.ConnectBackoff
+	for attempt := 0; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err = db.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return db, nil
+		}
+		if attempt >= cfg.Database.ConnectRetries {
+			db.Close()
+			return nil, err
+		}
+
+		slog.Warn("database not ready",
+			slog.Int("attempt", attempt+1),
+			slog.Int("max_attempts", cfg.Database.ConnectRetries),
+			slog.Duration("retry_in", backoff),
+			slog.String("error", err.Error()),
+		)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			db.Close()
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+func newAuthenticator(cfg *config.Config, feedTokens *auth.FeedTokens) *auth.Authenticator {
+	users := make(map[string]string, len(cfg.Auth.APIKeys))
+	for _, k := range cfg.Auth.APIKeys {
+		users[k.Key] = k.User
+	}
+	return auth.NewAuthenticator(users, cfg.Auth.Required, auth.WithFeedTokens(feedTokens, api.FeedPaths()...))
+}
+
+// newCachingRepository puts the configured cache in front of repo. The
+// task gauges keep reading repo directly.
+func newCachingRepository(cfg *config.Config, repo repository.TodoRepository, reg prometheus.Registerer) repository.TodoRepository {
+	if !cfg.Cache.Enabled {
+		return repo
+	}
+	return repository.NewCachingRepository(repo, cache.NewLRU(cfg.Cache.Size), reg,
+		repository.WithCacheTTL(cfg.Cache.TTL),
+		repository.WithNegativeCacheTTL(cfg.Cache.NegativeTTL),
+	)
+}
+
+// userTimeZones returns the preferred time zones of the API key users.
+func userTimeZones(cfg *config.Config) (map[string]*time.Location, error) {
+	zones := make(map[string]*time.Location)
+	for _, k := range cfg.Auth.APIKeys {
+		if k.TimeZone == "" {
+			continue
+		}
+		loc, err := time.LoadLocation(k.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("auth.api_keys: time zone of %s: %w", k.User, err)
+		}
+		zones[k.User] = loc
+	}
+	return zones, nil
+}
+
+func newFeedTokens(cfg *config.Config) (*auth.FeedTokens, error) {
+	secret := cfg.Auth.FeedSecret
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(b)
+		slog.Warn("auth.feed_secret is not set, calendar feed tokens will change on restart")
+	}
+	return auth.NewFeedTokens(secret), nil
+}
+
+func apiDeprecations(cfg *config.Config) (map[string]api.Deprecation, error) {
+	deprecations := make(map[string]api.Deprecation, len(cfg.API.Deprecations))
+	for version, d := range cfg.API.Deprecations {
+		since, err := parseConfigDate(d.Since)
+		if err != nil || since.IsZero() {
+			return nil, fmt.Errorf("api.deprecations.%s.since: a date is required", version)
+		}
+		sunset, err := parseConfigDate(d.Sunset)
+		if err != nil {
+			return nil, fmt.Errorf("api.deprecations.%s.sunset: %w", version, err)
+		}
+		deprecations[version] = api.Deprecation{Since: since, Sunset: sunset, Link: d.Link}
+	}
+	return deprecations, nil
+}
+
+// parseConfigDate accepts YYYY-MM-DD (midnight UTC) or RFC 3339; empty is
+// the zero time.
+func parseConfigDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func newCacheControl(cfg *config.Config) api.CacheControl {
+	routes := make([]api.CacheControlRoute, 0, len(cfg.CacheControl.Routes))
+	for _, route := range cfg.CacheControl.Routes {
+		routes = append(routes, api.CacheControlRoute{Method: route.Method, Pattern: route.Pattern, Value: route.Value})
+	}
+	return api.CacheControl{Default: cfg.CacheControl.Default, Routes: routes}
+}
+
+func newRateLimiter(cfg *config.Config, db *sql.DB, workers *worker.Group) (*ratelimit.Limiter, error) {
+	if !cfg.RateLimit.Enabled {
+		return nil, nil
+	}
+
+	var store ratelimit.Store
+	switch cfg.RateLimit.Store {
+	case "memory":
+		memory := ratelimit.NewMemoryStore()
+		workers.Go("ratelimit-cleanup", memory.Run)
+		store = memory
+	case "postgres":
+		postgres := ratelimit.NewPostgresStore(db)
+		workers.Go("ratelimit-cleanup", postgres.Run)
+		store = postgres
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", cfg.RateLimit.Store)
+	}
+
+	key, err := ratelimit.NewKeyFunc(cfg.RateLimit.KeyBy)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]ratelimit.Route, 0, len(cfg.RateLimit.Routes))
+	for _, route := range cfg.RateLimit.Routes {
+		routes = append(routes, ratelimit.Route{
+			Method:  route.Method,
+			Pattern: route.Pattern,
+			Limit:   ratelimit.Limit{Rate: route.Rate, Burst: route.Burst},
+		})
+	}
+	defaultLimit := ratelimit.Limit{Rate: cfg.RateLimit.Default.Rate, Burst: cfg.RateLimit.Default.Burst}
+	return ratelimit.NewLimiter(store, defaultLimit, routes, key), nil
+}
+
+// stopGRPC waits for in-flight RPCs to finish and closes the remaining
+// streams when ctx expires.
+func stopGRPC(ctx context.Context, srv *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Error("grpc shutdown", slog.String("error", ctx.Err().Error()))
+		srv.Stop()
+	}
+}
+
+func newEventPublisher(cfg *config.Config, db *sql.DB, tasks events.TaskGetter, bus *events.Bus, workers *worker.Group) (events.Publisher, error) {
+	switch cfg.Events.Backend {
+	case "memory":
+		return bus, nil
+	case "postgres":
+		workers.Go("task-events-listener", events.NewListener(cfg.Database.URL, bus, tasks).Run)
+		return events.NewNotifyPublisher(db), nil
+	default:
+		return nil, fmt.Errorf("unknown events backend %q", cfg.Events.Backend)
+	}
+}
+
+// newWebhooks starts the delivery worker and returns the /webhooks routes,
+// or nil when webhooks are disabled.
+func newWebhooks(cfg *config.Config, db *sql.DB, workers *worker.Group) http.Handler {
+	if !cfg.Webhooks.Enabled {
+		return nil
+	}
+
+	store := webhook.NewStore(db)
+	policy := webhook.RetryPolicy{
+		MaxAttempts:    cfg.Webhooks.MaxAttempts,
+		InitialBackoff: cfg.Webhooks.InitialBackoff,
+		MaxBackoff:     cfg.Webhooks.MaxBackoff,
+	}
+	workers.Go("webhook-dispatcher", webhook.NewDispatcher(store, policy, cfg.Webhooks.Timeout, cfg.Webhooks.PollInterval).Run)
+	return webhook.NewHandler(store, cfg.Webhooks.AllowedHosts)
+}
+
+// newReminders starts the reminder scheduler and returns the
+// /tasks/{id}/reminders routes, or nil when reminders are disabled.
+func newReminders(cfg *config.Config, db *sql.DB, workers *worker.Group) (http.Handler, error) {
+	if !cfg.Reminders.Enabled {
+		return nil, nil
+	}
+
+	targets := reminder.TargetPolicy{
+		WebhookHosts: cfg.Reminders.Webhook.AllowedHosts,
+		EmailDomains: cfg.Reminders.Email.AllowedDomains,
+	}
+	notifiers := make(map[string]reminder.Notifier)
+	for _, channel := range cfg.Reminders.Channels {
+		switch channel {
+		case reminder.ChannelLog:
+			notifiers[channel] = reminder.LogNotifier{}
+		case reminder.ChannelWebhook:
+			c := cfg.Reminders.Webhook
+			notifiers[channel] = reminder.NewWebhookNotifier(c.URL, c.Secret, targets, cfg.Reminders.Timeout)
+		case reminder.ChannelEmail:
+			c := cfg.Reminders.Email
+			n, err := reminder.NewEmailNotifier(c.Addr, c.Username, c.Password, c.From, c.To, targets)
+			if err != nil {
+				return nil, fmt.Errorf("reminders email: %w", err)
+			}
+			notifiers[channel] = n
+		default:
+			return nil, fmt.Errorf("unknown reminder channel %q", channel)
+		}
+	}
+
+	store := reminder.NewStore(db)
+	policy := reminder.RetryPolicy{
+		MaxAttempts:    cfg.Reminders.MaxAttempts,
+		InitialBackoff: cfg.Reminders.InitialBackoff,
+		MaxBackoff:     cfg.Reminders.MaxBackoff,
+	}
+	scheduler := reminder.NewScheduler(store, notifiers, policy, cfg.Reminders.Timeout, cfg.Reminders.PollInterval)
+	workers.Go("reminder-scheduler", scheduler.Run)
+	return reminder.NewHandler(store, cfg.Reminders.Channels, targets), nil
 }
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sberTestTask/pkg/client"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newAddCmd(a *app) *cobra.Command {
+	var due, description string
+	cmd := &cobra.Command{
+		Use:   "add TITLE",
+		Short: "Create a task",
+		Long:  "Create a task. A --due without a time of day makes an all-day task.",
+		Example: `  todo add "Write report" --due tomorrow
+  todo add "Call Bob" --due "2024-06-07 15:00" -d "about the release"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dueDate, allDay, err := parseDue(due, now())
+			if err != nil {
+				return err
+			}
+			task, err := a.client.CreateTask(cmd.Context(), &client.Task{Title: args[0], Description: description, DueDate: &dueDate, AllDay: allDay})
+			if err != nil {
+				return err
+			}
+			return a.printTask(cmd.OutOrStdout(), task)
+		},
+	}
+	cmd.Flags().StringVar(&due, "due", "today", dueHelp)
+	cmd.Flags().StringVarP(&description, "description", "d", "", "task description")
+	cmd.RegisterFlagCompletionFunc("due", completeDue)
+	return cmd
+}
+
+func newListCmd(a *app) *cobra.Command {
+	var completed, overdue, date string
+	var opts client.ListOptions
+	cmd := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List tasks",
+		Example: `  todo ls --completed=false --date 2024-06-07
+  todo ls --date today -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if completed != "" {
+				v, err := strconv.ParseBool(completed)
+				if err != nil {
+					return fmt.Errorf("invalid --completed %q", completed)
+				}
+				opts.Completed = &v
+			}
+			if overdue != "" {
+				v, err := strconv.ParseBool(overdue)
+				if err != nil {
+					return fmt.Errorf("invalid --overdue %q", overdue)
+				}
+				opts.Overdue = &v
+			}
+			if date != "" {
+				d, _, err := parseDue(date, now())
+				if err != nil {
+					return err
+				}
+				opts.Date = &d
+			}
+
+			page, err := a.client.ListTasks(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			return a.printPage(cmd.OutOrStdout(), page)
+		},
+	}
+	cmd.Flags().StringVar(&completed, "completed", "", "only completed (true) or open (false) tasks")
+	cmd.Flags().StringVar(&overdue, "overdue", "", "only open tasks past their due date (true) or all others (false)")
+	cmd.Flags().StringVar(&date, "date", "", "only tasks due on this day")
+	cmd.Flags().IntVar(&opts.Limit, "limit", 0, "tasks per page (server default 10)")
+	cmd.Flags().IntVar(&opts.Page, "page", 0, "page number")
+	cmd.RegisterFlagCompletionFunc("completed", cobra.FixedCompletions([]string{"true", "false"}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("overdue", cobra.FixedCompletions([]string{"true", "false"}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("date", completeDue)
+	return cmd
+}
+
+func newShowCmd(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:               "show ID",
+		Short:             "Show a task",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeTaskIDs(nil),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			task, err := a.client.GetTask(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+			return a.printTask(cmd.OutOrStdout(), task)
+		},
+	}
+}
+
+func newDoneCmd(a *app) *cobra.Command {
+	open := false
+	var undo bool
+	cmd := &cobra.Command{
+		Use:               "done ID...",
+		Short:             "Mark tasks as completed",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: a.completeTaskIDs(&open),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			completed := !undo
+			for _, id := range ids {
+				task, err := a.client.UpdateTask(cmd.Context(), id, client.TaskUpdate{Completed: &completed})
+				if err != nil {
+					return fmt.Errorf("task %d: %w", id, err)
+				}
+				if err := a.printTask(cmd.OutOrStdout(), task); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&undo, "undo", false, "mark the tasks as open again")
+	return cmd
+}
+
+func newEditCmd(a *app) *cobra.Command {
+	var title, description, due string
+	var completed bool
+	cmd := &cobra.Command{
+		Use:               "edit ID",
+		Short:             "Change a task",
+		Example:           `  todo edit 12 --title "Write the report" --due "+2d"`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeTaskIDs(nil),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+
+			var update client.TaskUpdate
+			flags := cmd.Flags()
+			if flags.Changed("title") {
+				update.Title = &title
+			}
+			if flags.Changed("description") {
+				update.Description = &description
+			}
+			if flags.Changed("due") {
+				dueDate, allDay, err := parseDue(due, now())
+				if err != nil {
+					return err
+				}
+				update.DueDate, update.AllDay = &dueDate, &allDay
+			}
+			if flags.Changed("completed") {
+				update.Completed = &completed
+			}
+			if update == (client.TaskUpdate{}) {
+				return errors.New("nothing to change, use --title, --description, --due or --completed")
+			}
+
+			task, err := a.client.UpdateTask(cmd.Context(), id, update)
+			if err != nil {
+				return err
+			}
+			return a.printTask(cmd.OutOrStdout(), task)
+		},
+	}
+	cmd.Flags().StringVar(&title, "title", "", "new title")
+	cmd.Flags().StringVarP(&description, "description", "d", "", "new description")
+	cmd.Flags().StringVar(&due, "due", "", dueHelp)
+	cmd.Flags().BoolVar(&completed, "completed", false, "completion status")
+	cmd.RegisterFlagCompletionFunc("due", completeDue)
+	return cmd
+}
+
+func newRemoveCmd(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:               "rm ID...",
+		Aliases:           []string{"delete"},
+		Short:             "Delete tasks",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: a.completeTaskIDs(nil),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if err := a.client.DeleteTask(cmd.Context(), id); err != nil {
+					return fmt.Errorf("task %d: %w", id, err)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "deleted task %d\n", id)
+			}
+			return nil
+		},
+	}
+}
+
+func parseID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid task id %q", s)
+	}
+	return id, nil
+}
+
+func parseIDs(args []string) ([]int, error) {
+	ids := make([]int, len(args))
+	for i, arg := range args {
+		id, err := parseID(arg)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// completeTaskIDs suggests the IDs of the first tasks, optionally filtered
+// by completion, with their titles as descriptions.
+func (a *app) completeTaskIDs(completed *bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		// Completion does not run the persistent pre-run hook.
+		if err := a.init(); err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		page, err := a.client.ListTasks(cmd.Context(), client.ListOptions{Completed: completed, Limit: 50})
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		var ids []string
+		for _, task := range page.Tasks {
+			id := strconv.Itoa(task.ID)
+			if strings.HasPrefix(id, toComplete) {
+				ids = append(ids, id+"\t"+task.Title)
+			}
+		}
+		return ids, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sberTestTask/internal/auth"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/delivery/api"
+	"sberTestTask/internal/todo/service"
+	"sberTestTask/internal/todo/tests/mocks/serviceMock"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupServer starts the REST API on a mocked use case and returns a
+// function that runs the todo command against it.
+func setupServer(t *testing.T) (func(args ...string) (stdout, stderr string, err error), *serviceMock.MockTodoUsecase) {
+	uc := new(serviceMock.MockTodoUsecase)
+	router := chi.NewRouter()
+	api.RegisterRoutes(router, api.NewHandler(uc), api.RouteOptions{
+		Auth: auth.NewAuthenticator(map[string]string{"secret": "alice"}, true),
+	})
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	// An empty config file keeps the user's configuration out of the test.
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(config, nil, 0o600))
+	t.Setenv("TODO_TIME_ZONE", "")
+
+	today := time.Date(2099, 6, 7, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return today }
+	t.Cleanup(func() { now = time.Now })
+
+	run := func(args ...string) (string, string, error) {
+		var stdout, stderr bytes.Buffer
+		cmd := newRootCmd()
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		cmd.SetArgs(append([]string{"--config", config, "--server", srv.URL, "--api-key", "secret"}, args...))
+		err := cmd.ExecuteContext(context.Background())
+		return stdout.String(), stderr.String(), err
+	}
+	return run, uc
+}
+
+func TestAddCommand(t *testing.T) {
+	due := time.Date(2099, 6, 8, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name: "Table",
+			args: []string{"add", "Write report", "--due", "tomorrow", "-d", "quarterly"},
+			expected: "ID  DONE  DUE         TITLE         DESCRIPTION\n" +
+				"12        2099-06-08  Write report  quarterly\n",
+		},
+		{
+			name: "JSON",
+			args: []string{"add", "Write report", "--due", "tomorrow", "-d", "quarterly", "-o", "json"},
+			expected: `{
+  "id": 12,
+  "title": "Write report",
+  "description": "quarterly",
+  "due_date": "2099-06-08T00:00:00Z",
+  "completed": false,
+  "all_day": true
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, uc := setupServer(t)
+			uc.On("CreateTask", mock.Anything, mock.MatchedBy(func(task *todo.Task) bool {
+				return task.Title == "Write report" && task.Description == "quarterly" && task.AllDay && task.DueDate.Equal(due)
+			})).Run(func(args mock.Arguments) {
+				args.Get(1).(*todo.Task).ID = 12
+			}).Return(nil)
+
+			stdout, _, err := run(tt.args...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout)
+		})
+	}
+}
+
+func TestListCommand(t *testing.T) {
+	due := time.Date(2099, 6, 7, 0, 0, 0, 0, time.UTC)
+	completed := false
+	tests := []struct {
+		name     string
+		args     []string
+		filter   todo.TaskFilter
+		limit    int
+		page     int
+		pages    *todo.Pages
+		expected string
+	}{
+		{
+			name:   "Table with pages",
+			args:   []string{"ls", "--completed=false", "--limit", "2", "--page", "2"},
+			filter: todo.TaskFilter{Completed: &completed},
+			limit:  2,
+			page:   2,
+			pages: &todo.Pages{CountPage: 3, CurPage: 2, Tasks: []*todo.Task{
+				{ID: 3, Title: "Call Bob", DueDate: &due, AllDay: true},
+				{ID: 4, Title: "Pay rent", Description: "before noon", DueDate: &due, AllDay: true, Completed: true},
+			}},
+			expected: "ID  DONE  DUE         TITLE     DESCRIPTION\n" +
+				"3         2099-06-07  Call Bob  \n" +
+				"4   x     2099-06-07  Pay rent  before noon\n" +
+				"page 2 of 3\n",
+		},
+		{
+			name:     "Empty",
+			args:     []string{"list"},
+			limit:    10,
+			page:     1,
+			pages:    &todo.Pages{CountPage: 1, CurPage: 1, Tasks: []*todo.Task{}},
+			expected: "no tasks\n",
+		},
+		{
+			name:  "JSON",
+			args:  []string{"ls", "-o", "json"},
+			limit: 10,
+			page:  1,
+			pages: &todo.Pages{CountPage: 1, CurPage: 1, Tasks: []*todo.Task{{ID: 3, Title: "Call Bob", DueDate: &due, AllDay: true}}},
+			expected: `{
+  "count_page": 1,
+  "cur_page": 1,
+  "tasks": [
+    {
+      "id": 3,
+      "title": "Call Bob",
+      "due_date": "2099-06-07T00:00:00Z",
+      "completed": false,
+      "all_day": true
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, uc := setupServer(t)
+			uc.On("ListTasks", mock.Anything, tt.filter, tt.limit, tt.page).Return(tt.pages, nil)
+
+			stdout, _, err := run(tt.args...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout)
+		})
+	}
+}
+
+func TestShowCommand(t *testing.T) {
+	run, uc := setupServer(t)
+	due := time.Date(2099, 6, 7, 0, 0, 0, 0, time.UTC)
+	uc.On("GetTask", mock.Anything, 12).Return(&todo.Task{ID: 12, Title: "Write report", DueDate: &due, AllDay: true}, nil)
+
+	stdout, _, err := run("show", "12")
+	require.NoError(t, err)
+	assert.Equal(t, "ID  DONE  DUE         TITLE         DESCRIPTION\n"+
+		"12        2099-06-07  Write report  \n", stdout)
+}
+
+func TestDoneCommand(t *testing.T) {
+	due := time.Date(2099, 6, 7, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		args      []string
+		completed bool
+	}{
+		{"Done", []string{"done", "1", "2"}, true},
+		{"Undo", []string{"done", "--undo", "1", "2"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, uc := setupServer(t)
+			for _, id := range []int{1, 2} {
+				uc.On("GetTask", mock.Anything, id).Return(&todo.Task{ID: id, Title: "Task", DueDate: &due, AllDay: true, Completed: !tt.completed}, nil)
+				uc.On("UpdateTask", mock.Anything, &todo.Task{ID: id, Title: "Task", DueDate: &due, AllDay: true, Completed: tt.completed}).Return(nil)
+			}
+
+			stdout, _, err := run(append(tt.args, "-o", "json")...)
+			require.NoError(t, err)
+			uc.AssertExpectations(t)
+			assert.Equal(t, 2, bytes.Count([]byte(stdout), []byte(`"title": "Task"`)))
+		})
+	}
+}
+
+func TestEditCommand(t *testing.T) {
+	old := time.Date(2099, 6, 7, 0, 0, 0, 0, time.UTC)
+	due := time.Date(2099, 6, 9, 0, 0, 0, 0, time.UTC)
+	run, uc := setupServer(t)
+	uc.On("GetTask", mock.Anything, 12).Return(&todo.Task{ID: 12, Title: "Write report", Description: "keep", DueDate: &old, AllDay: true}, nil)
+	uc.On("UpdateTask", mock.Anything, &todo.Task{ID: 12, Title: "Write the report", Description: "keep", DueDate: &due, AllDay: true}).Return(nil)
+
+	stdout, _, err := run("edit", "12", "--title", "Write the report", "--due", "+2d")
+	require.NoError(t, err)
+	assert.Equal(t, "ID  DONE  DUE         TITLE             DESCRIPTION\n"+
+		"12        2099-06-09  Write the report  keep\n", stdout)
+	uc.AssertExpectations(t)
+}
+
+func TestRemoveCommand(t *testing.T) {
+	run, uc := setupServer(t)
+	for _, id := range []int{1, 2} {
+		uc.On("GetTask", mock.Anything, id).Return(&todo.Task{ID: id}, nil)
+		uc.On("DeleteTask", mock.Anything, id).Return(nil)
+	}
+
+	stdout, stderr, err := run("rm", "1", "2")
+	require.NoError(t, err)
+	assert.Empty(t, stdout)
+	assert.Equal(t, "deleted task 1\ndeleted task 2\n", stderr)
+	uc.AssertExpectations(t)
+}
+
+func TestCommandErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"Unknown output", []string{"ls", "-o", "yaml"}, `unknown output format "yaml", want table or json`},
+		{"Invalid id", []string{"show", "abc"}, `invalid task id "abc"`},
+		{"Invalid due", []string{"add", "Task", "--due", "soon"}, "soon"},
+		{"Nothing to edit", []string{"edit", "12"}, "nothing to change, use --title, --description, --due or --completed"},
+		{"Not found", []string{"rm", "404"}, "task 404: "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, uc := setupServer(t)
+			uc.On("GetTask", mock.Anything, 404).Return((*todo.Task)(nil), service.ErrIdNotFound)
+
+			_, stderr, err := run(tt.args...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+			assert.Contains(t, stderr, "Error: ")
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const dueHelp = `due date: today, tomorrow, +Nd, YYYY-MM-DD, "YYYY-MM-DD HH:MM" or RFC 3339`
+
+// now is replaced in tests.
+var now = time.Now
+
+// parseDue turns the --due and --date values into a time and reports
+// whether it is a plain day, which makes an all-day task. Plain days are
+// midnight UTC, like the dates the API accepts in its filters; a time of
+// day is taken in the local time zone.
+func parseDue(s string, now time.Time) (time.Time, bool, error) {
+	s = strings.TrimSpace(s)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch strings.ToLower(s) {
+	case "today":
+		return today, true, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true, nil
+	}
+
+	if days, ok := strings.CutPrefix(s, "+"); ok {
+		if n, err := strconv.Atoi(strings.TrimSuffix(days, "d")); err == nil && strings.HasSuffix(days, "d") {
+			return today.AddDate(0, 0, n), true, nil
+		}
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid date %q, want %s", s, strings.TrimPrefix(dueHelp, "due date: "))
+}
+
+func completeDue(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{"today", "tomorrow", "+7d"}, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDue(t *testing.T) {
+	now := time.Date(2024, 6, 7, 18, 30, 0, 0, time.UTC)
+	tests := []struct {
+		in     string
+		want   time.Time
+		allDay bool
+	}{
+		{"today", time.Date(2024, 6, 7, 0, 0, 0, 0, time.UTC), true},
+		{"Tomorrow", time.Date(2024, 6, 8, 0, 0, 0, 0, time.UTC), true},
+		{"+3d", time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC), true},
+		{"2024-12-31", time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), true},
+		{"2024-06-07T15:00:00+03:00", time.Date(2024, 6, 7, 12, 0, 0, 0, time.UTC), false},
+		{"2024-06-07 15:00", time.Date(2024, 6, 7, 15, 0, 0, 0, time.Local), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, allDay, err := parseDue(tt.in, now)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %v", got)
+			assert.Equal(t, tt.allDay, allDay)
+		})
+	}
+
+	for _, in := range []string{"", "soon", "+3", "+xd", "07.06.2024"} {
+		_, _, err := parseDue(in, now)
+		assert.Error(t, err, in)
+	}
+}
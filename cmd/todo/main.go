@@ -0,0 +1,118 @@
+// Command todo is a command-line client for the task API.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sberTestTask/pkg/client"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const defaultServer = "http://localhost:8080"
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	err := newRootCmd().ExecuteContext(ctx)
+	stop()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// app holds what every subcommand needs after the configuration was read.
+type app struct {
+	cfg        *viper.Viper
+	configFile string
+	client     *client.Client
+	output     string
+}
+
+func newRootCmd() *cobra.Command {
+	a := &app{cfg: viper.New()}
+
+	root := &cobra.Command{
+		Use:   "todo",
+		Short: "Manage tasks from the command line",
+		Long: `todo talks to the task REST API.
+
+The server URL and API key are read from flags, the TODO_SERVER and
+TODO_API_KEY environment variables or the config file, by default
+` + defaultConfigPath() + `:
+
+  server: http://localhost:8080
+  api_key: dev-secret-key
+  time_zone: Europe/Moscow
+
+time_zone (--tz, TODO_TIME_ZONE) sets the IANA time zone of date filters
+and overdue days; without it the server uses your account's zone.`,
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return a.init()
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&a.configFile, "config", "", "config file (default "+defaultConfigPath()+")")
+	flags.String("server", defaultServer, "API server URL")
+	flags.String("api-key", "", "API key")
+	flags.String("tz", "", "IANA time zone of dates, e.g. Europe/Moscow")
+	flags.StringVarP(&a.output, "output", "o", "table", "output format: table or json")
+	a.cfg.BindPFlag("server", flags.Lookup("server"))
+	a.cfg.BindPFlag("api_key", flags.Lookup("api-key"))
+	a.cfg.BindPFlag("time_zone", flags.Lookup("tz"))
+	root.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{"table", "json"}, cobra.ShellCompDirectiveNoFileComp))
+
+	root.AddCommand(
+		newAddCmd(a),
+		newListCmd(a),
+		newShowCmd(a),
+		newDoneCmd(a),
+		newEditCmd(a),
+		newRemoveCmd(a),
+	)
+	return root
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "todo.yaml"
+	}
+	return filepath.Join(dir, "todo", "config.yaml")
+}
+
+func (a *app) init() error {
+	if a.output != "table" && a.output != "json" {
+		return fmt.Errorf("unknown output format %q, want table or json", a.output)
+	}
+
+	a.cfg.SetEnvPrefix("todo")
+	a.cfg.AutomaticEnv()
+	configFile := a.configFile
+	if configFile == "" {
+		configFile = defaultConfigPath()
+	}
+	a.cfg.SetConfigFile(configFile)
+	if filepath.Ext(configFile) == "" {
+		a.cfg.SetConfigType("yaml")
+	}
+	if err := a.cfg.ReadInConfig(); err != nil {
+		// A missing default config file is fine, an explicit one must exist.
+		if a.configFile != "" || !os.IsNotExist(err) {
+			return fmt.Errorf("read config: %w", err)
+		}
+	}
+
+	var err error
+	a.client, err = client.New(a.cfg.GetString("server"),
+		client.WithAPIKey(a.cfg.GetString("api_key")),
+		client.WithTimeZone(a.cfg.GetString("time_zone")),
+	)
+	return err
+}
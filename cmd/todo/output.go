@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sberTestTask/pkg/client"
+	"text/tabwriter"
+	"time"
+)
+
+func (a *app) printTask(w io.Writer, task *client.Task) error {
+	if a.output == "json" {
+		return printJSON(w, task)
+	}
+	return printTable(w, []*client.Task{task})
+}
+
+func (a *app) printPage(w io.Writer, page *client.TaskPage) error {
+	if a.output == "json" {
+		return printJSON(w, page)
+	}
+	if len(page.Tasks) == 0 {
+		_, err := fmt.Fprintln(w, "no tasks")
+		return err
+	}
+	if err := printTable(w, page.Tasks); err != nil {
+		return err
+	}
+	if page.CountPage > 1 {
+		_, err := fmt.Fprintf(w, "page %d of %d\n", page.CurPage, page.CountPage)
+		return err
+	}
+	return nil
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printTable(w io.Writer, tasks []*client.Task) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDONE\tDUE\tTITLE\tDESCRIPTION")
+	for _, task := range tasks {
+		done := " "
+		if task.Completed {
+			done = "x"
+		}
+		due := formatDue(task.DueDate, task.AllDay)
+		if task.Overdue {
+			due += fmt.Sprintf(" (overdue %dd)", task.DaysOverdue)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", task.ID, done, due, task.Title, task.Description)
+	}
+	return tw.Flush()
+}
+
+func formatDue(due *time.Time, allDay bool) string {
+	if due == nil {
+		return "-"
+	}
+	// All-day tasks are stored as midnight UTC of their day.
+	if allDay {
+		return due.UTC().Format(time.DateOnly)
+	}
+	return due.Local().Format("2006-01-02 15:04")
+}
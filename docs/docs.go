@@ -16,6 +16,93 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/stats": {
+            "get": {
+                "description": "Counts by status, overdue tasks, the mean time to complete, the completion rate of tasks due in each day or week from from to to, and open tasks due on each of the next upcoming_days days. Days are calendar days in the tz zone. The list filters narrow every figure. Results are cached for a short time.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Task statistics",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Filter by completion status",
+                        "name": "completed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "example": "2024-06-07",
+                        "description": "Filter by due date",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by overdue status",
+                        "name": "overdue",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Europe/Moscow",
+                        "description": "IANA time zone of the days",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "day (default) or week",
+                        "name": "bucket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "First day of the completion series, by default 30 days or 12 weeks before to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "Last day of the completion series, by default today",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of upcoming days, 7 by default, at most 90",
+                        "name": "upcoming_days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Statistics",
+                        "schema": {
+                            "$ref": "#/definitions/api.StatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    }
+                }
+            }
+        },
         "/tasks": {
             "get": {
                 "description": "Get a list of tasks with optional filters",
@@ -41,6 +128,19 @@ const docTemplate = `{
                         "name": "date",
                         "in": "query"
                     },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by overdue status",
+                        "name": "overdue",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Europe/Moscow",
+                        "description": "IANA time zone of the date filter and computed fields",
+                        "name": "tz",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "Number of tasks per page",
@@ -52,25 +152,54 @@ const docTemplate = `{
                         "description": "Page number",
                         "name": "page",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "List of tasks",
                         "schema": {
-                            "$ref": "#/definitions/todo.Pages"
+                            "$ref": "#/definitions/api.PageResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Weak validator of the page"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Latest write among the listed tasks"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Weak validator of the page"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Latest write among the listed tasks"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/todo.ErrorResponse"
+                            "$ref": "#/definitions/httpapi.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/todo.ErrorResponse"
+                            "$ref": "#/definitions/httpapi.Problem"
                         }
                     }
                 }
@@ -94,7 +223,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/todo.Task"
+                            "$ref": "#/definitions/api.CreateTaskRequest"
                         }
                     }
                 ],
@@ -102,211 +231,1457 @@ const docTemplate = `{
                     "201": {
                         "description": "Task created successfully",
                         "schema": {
-                            "$ref": "#/definitions/todo.Task"
+                            "$ref": "#/definitions/api.TaskResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Malformed JSON",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Body too large",
                         "schema": {
-                            "$ref": "#/definitions/todo.ErrorResponse"
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/todo.ErrorResponse"
+                            "$ref": "#/definitions/httpapi.Problem"
                         }
                     }
                 }
             }
         },
-        "/tasks/{id}": {
+        "/tasks.ics": {
             "get": {
-                "description": "Get a task by ID",
+                "description": "iCalendar feed of tasks matching the list filters, as VTODO (default) or VEVENT components. Calendar apps can authenticate with a feed token from /tasks/feed-token in the token query parameter.",
                 "produces": [
-                    "application/json"
+                    "text/calendar"
                 ],
                 "tags": [
                     "tasks"
                 ],
-                "summary": "Get a task by ID",
+                "summary": "Calendar feed",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Task ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "Feed token",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "vtodo or vevent",
+                        "name": "component",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by completion status",
+                        "name": "completed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "example": "2024-06-07",
+                        "description": "Filter by due date",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Europe/Moscow",
+                        "description": "IANA time zone of the date filter",
+                        "name": "tz",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Task found",
+                        "description": "iCalendar file",
                         "schema": {
-                            "$ref": "#/definitions/todo.Task"
+                            "type": "string"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/todo.ErrorResponse"
+                            "$ref": "#/definitions/httpapi.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Invalid feed token or API key",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/todo.ErrorResponse"
+                            "$ref": "#/definitions/httpapi.Problem"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update a task with the input payload",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/tasks/events": {
+            "get": {
+                "description": "Server-Sent Events feed of created, updated and deleted tasks. Send Last-Event-ID to resume after a reconnect.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
                     "tasks"
                 ],
-                "summary": "Update a task",
+                "summary": "Stream task changes",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Task ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Id of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "$ref": "#/definitions/events.Event"
+                        }
                     },
-                    {
-                        "description": "Task updates",
-                        "name": "task",
-                        "in": "body",
-                        "required": true,
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/httpapi.Problem"
                         }
                     }
+                }
+            }
+        },
+        "/tasks/export": {
+            "get": {
+                "description": "Stream all tasks matching the list filters as CSV with the columns id, title, description, due_date, completed and all_day. Due dates of all-day tasks are written as YYYY-MM-DD",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Export tasks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Export format, only csv is supported",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by completion status",
+                        "name": "completed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "example": "2024-06-07",
+                        "description": "Filter by due date",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Europe/Moscow",
+                        "description": "IANA time zone of the date filter",
+                        "name": "tz",
+                        "in": "query"
+                    }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Task updated successfully",
+                        "description": "CSV file",
                         "schema": {
-                            "$ref": "#/definitions/todo.Task"
+                            "type": "string"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/todo.ErrorResponse"
+                            "$ref": "#/definitions/httpapi.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/feed-token": {
+            "get": {
+                "description": "Token for subscribing to /tasks.ics from a calendar app. Requires an API key; the token stops working when that key is removed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Get a calendar feed token",
+                "responses": {
+                    "200": {
+                        "description": "Feed token",
                         "schema": {
-                            "$ref": "#/definitions/todo.ErrorResponse"
+                            "$ref": "#/definitions/api.FeedTokenResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/todo.ErrorResponse"
+                            "$ref": "#/definitions/httpapi.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a task by ID",
+            }
+        },
+        "/tasks/import": {
+            "post": {
+                "description": "Create tasks from a CSV file with a header row. Columns are matched by name (title, description, due_date, completed, all_day); use map=Header:column to map other header names. due_date accepts RFC 3339 or YYYY-MM-DD, which makes the task all-day unless the all_day column says otherwise. If any row is invalid nothing is imported and every error is reported.",
+                "consumes": [
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "tasks"
                 ],
-                "summary": "Delete a task",
+                "summary": "Import tasks",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Task ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "CSV file",
+                        "name": "file",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only validate the file",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Header mapping, e.g. Deadline:due_date",
+                        "name": "map",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Dry run result",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.ImportResult"
+                        }
+                    },
+                    "201": {
+                        "description": "Tasks imported",
+                        "schema": {
+                            "$ref": "#/definitions/api.ImportResult"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/todo.ErrorResponse"
+                            "$ref": "#/definitions/httpapi.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid rows",
                         "schema": {
-                            "$ref": "#/definitions/todo.ErrorResponse"
+                            "$ref": "#/definitions/api.ImportResult"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/todo.ErrorResponse"
+                            "$ref": "#/definitions/httpapi.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "todo.ErrorResponse": {
+        },
+        "/tasks/import/ics": {
+            "post": {
+                "description": "Create tasks from the VTODO components of an iCalendar file, using SUMMARY, DESCRIPTION, DUE and STATUS. Recurring items (RRULE with FREQ, INTERVAL, COUNT, UNTIL and weekly BYDAY) become one task per occurrence. If any item is invalid nothing is imported; rows in the result are the lines where the items begin.",
+                "consumes": [
+                    "text/calendar"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Import tasks from iCalendar",
+                "parameters": [
+                    {
+                        "description": "iCalendar file",
+                        "name": "file",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only validate the file",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dry run result",
+                        "schema": {
+                            "$ref": "#/definitions/api.ImportResult"
+                        }
+                    },
+                    "201": {
+                        "description": "Tasks imported",
+                        "schema": {
+                            "$ref": "#/definitions/api.ImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "File too large",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid items",
+                        "schema": {
+                            "$ref": "#/definitions/api.ImportResult"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}": {
+            "get": {
+                "description": "Get a task by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Get a task by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified of a cached copy; ignored while computed fields follow the clock",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Task found",
+                        "schema": {
+                            "$ref": "#/definitions/api.TaskResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Weak validator of the task"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time of the last write"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Weak validator of the task"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time of the last write"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update a task with the input payload",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Update a task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Task updates",
+                        "name": "task",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateTaskRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Task updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/api.TaskResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Body too large",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a task by ID",
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Delete a task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/reminders": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reminders"
+                ],
+                "summary": "List reminders of a task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reminders with their delivery status",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/reminder.Reminder"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Notify through a channel at an offset before the task's due date. Moving the due date reschedules the reminder.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reminders"
+                ],
+                "summary": "Add a reminder",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "offset (e.g. 1h), channel and optional target from the configured allowlist",
+                        "name": "reminder",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/reminder.Reminder"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Reminder created",
+                        "schema": {
+                            "$ref": "#/definitions/reminder.Reminder"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid reminder, with the invalid fields in errors",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}/reminders/{reminderID}": {
+            "delete": {
+                "tags": [
+                    "reminders"
+                ],
+                "summary": "Delete a reminder",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Reminder ID",
+                        "name": "reminderID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Reminder deleted"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhooks",
+                "responses": {
+                    "200": {
+                        "description": "Subscriptions",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhook.Subscription"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribe a URL to task events. Every delivery is a JSON POST signed with HMAC-SHA256 of the body using the secret, sent in the X-Webhook-Signature header as \"sha256=\u003chex\u003e\".",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Register a webhook",
+                "parameters": [
+                    {
+                        "description": "URL, secret and events: task.created, task.updated, task.completed, task.deleted",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/webhook.Subscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Subscription created",
+                        "schema": {
+                            "$ref": "#/definitions/webhook.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed JSON",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid subscription, with the invalid fields in errors",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscription",
+                        "schema": {
+                            "$ref": "#/definitions/webhook.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a subscription together with its delivery log",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Subscription deleted"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Most recent deliveries of a subscription, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Webhook delivery log",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "pending, delivered or dead",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of deliveries, 50 by default",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deliveries",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhook.Delivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{deliveryID}/retry": {
+            "post": {
+                "description": "Move a delivery in the dead state back to the queue",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Retry a dead delivery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Delivery ID",
+                        "name": "deliveryID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Delivery queued"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.CompletionBucketResponse": {
+            "type": "object",
+            "properties": {
+                "completed": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "due": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 0.75
+                },
+                "start": {
+                    "type": "string",
+                    "example": "2024-06-03"
+                }
+            }
+        },
+        "api.CreateTaskRequest": {
+            "type": "object",
+            "properties": {
+                "all_day": {
+                    "description": "AllDay makes the task due on the calendar day of due_date.",
+                    "type": "boolean"
+                },
+                "completed": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "2 litres"
+                },
+                "due_date": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-06-07T15:00:00Z"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Buy milk"
+                }
+            }
+        },
+        "api.DayCountResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2024-06-07"
+                }
+            }
+        },
+        "api.FeedTokenResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "/v1/tasks.ics?token=ZGV2ZWxvcGVy.c2lnbmF0dXJl"
+                }
+            }
+        },
+        "api.ImportError": {
             "type": "object",
             "properties": {
+                "column": {
+                    "type": "string",
+                    "example": "due_date"
+                },
                 "message": {
                     "type": "string",
-                    "example": "Error"
+                    "example": "due_date must be a date"
+                },
+                "row": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "api.ImportResult": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.ImportError"
+                    }
+                },
+                "imported": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "rows": {
+                    "type": "integer",
+                    "example": 2
                 }
             }
         },
-        "todo.Pages": {
+        "api.PageResponse": {
             "type": "object",
             "properties": {
                 "count_page": {
-                    "type": "integer"
+                    "type": "integer",
+                    "example": 3
                 },
                 "cur_page": {
-                    "type": "integer"
+                    "type": "integer",
+                    "example": 1
                 },
                 "tasks": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/todo.Task"
+                        "$ref": "#/definitions/api.TaskResponse"
                     }
                 }
             }
         },
-        "todo.Task": {
+        "api.StatsResponse": {
+            "type": "object",
+            "properties": {
+                "avg_time_to_complete": {
+                    "description": "AvgTimeToComplete is the mean number of seconds from creation to\ncompletion, null when no completed task has both.",
+                    "type": "number",
+                    "example": 86400
+                },
+                "completed": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "completion": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.CompletionBucketResponse"
+                    }
+                },
+                "open": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "overdue": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "upcoming": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.DayCountResponse"
+                    }
+                }
+            }
+        },
+        "api.TaskResponse": {
             "type": "object",
             "properties": {
+                "all_day": {
+                    "type": "boolean"
+                },
                 "completed": {
                     "type": "boolean"
                 },
+                "days_overdue": {
+                    "type": "integer",
+                    "example": 0
+                },
                 "description": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "2 litres"
+                },
+                "due_date": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-06-07T15:00:00Z"
+                },
+                "due_in": {
+                    "type": "integer",
+                    "example": 3600
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "overdue": {
+                    "description": "Overdue, DueIn (seconds, negative once due) and DaysOverdue are\ncomputed in the caller's time zone.",
+                    "type": "boolean"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Buy milk"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time",
+                    "example": "2024-06-07T15:00:00Z"
+                }
+            }
+        },
+        "api.UpdateTaskRequest": {
+            "type": "object",
+            "properties": {
+                "all_day": {
+                    "type": "boolean"
+                },
+                "completed": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "2 litres"
                 },
                 "due_date": {
+                    "description": "DueDate is RFC 3339, or YYYY-MM-DD, which makes the task all-day\nunless all_day is given too.",
                     "type": "string",
                     "example": "2024-06-07T15:00:00Z"
                 },
+                "title": {
+                    "type": "string",
+                    "example": "Buy milk"
+                }
+            }
+        },
+        "events.Event": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "task": {
+                    "$ref": "#/definitions/todo.Task"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/events.Type"
+                }
+            }
+        },
+        "events.Type": {
+            "type": "string",
+            "enum": [
+                "created",
+                "updated",
+                "deleted",
+                "completed"
+            ],
+            "x-enum-varnames": [
+                "TaskCreated",
+                "TaskUpdated",
+                "TaskDeleted",
+                "TaskCompleted"
+            ]
+        },
+        "httpapi.Problem": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "title cannot be empty"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/validation.FieldError"
+                    }
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 422
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Unprocessable Entity"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "about:blank"
+                }
+            }
+        },
+        "reminder.Reminder": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "channel": {
+                    "description": "Channel is the name of a configured notifier: log, webhook or email.",
+                    "type": "string",
+                    "example": "email"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "string",
+                    "example": "1h"
+                },
+                "remind_at": {
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/reminder.Status"
+                },
+                "target": {
+                    "description": "Target overrides the channel's default recipient: a URL for webhook,\nan address for email.",
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "reminder.Status": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "sent",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "StatusPending",
+                "StatusSent",
+                "StatusFailed"
+            ]
+        },
+        "todo.Task": {
+            "type": "object",
+            "properties": {
+                "all_day": {
+                    "description": "AllDay tasks are due on a calendar day rather than at an instant.\nTheir DueDate is midnight UTC of that day and they become overdue\nonce the day has passed in the caller's time zone.",
+                    "type": "boolean"
+                },
+                "completed": {
+                    "type": "boolean"
+                },
+                "days_overdue": {
+                    "description": "DaysOverdue counts calendar days since the due date, 0 unless overdue.",
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "due_date": {
+                    "type": "string"
+                },
+                "due_in": {
+                    "description": "DueIn is the number of seconds until the due date, negative once it\nhas passed. Not set for completed tasks.",
+                    "type": "integer"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "overdue": {
+                    "description": "Computed when the task is read, relative to the service clock and\ntime zone; never stored.",
+                    "type": "boolean"
+                },
                 "title": {
                     "type": "string"
+                },
+                "updated_at": {
+                    "description": "UpdatedAt is set by the database on every write and backs the\nLast-Modified header; it is ignored on input.",
+                    "type": "string"
+                }
+            }
+        },
+        "validation.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "title"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "cannot be empty"
+                }
+            }
+        },
+        "webhook.Delivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "status": {
+                    "$ref": "#/definitions/webhook.Status"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "webhook.Status": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "delivered",
+                "dead"
+            ],
+            "x-enum-varnames": [
+                "StatusPending",
+                "StatusDelivered",
+                "StatusDead"
+            ]
+        },
+        "webhook.Subscription": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
                 }
             }
         }
@@ -317,7 +1692,7 @@ const docTemplate = `{
 var SwaggerInfo = &swag.Spec{
 	Version:          "1.0",
 	Host:             "localhost:8080",
-	BasePath:         "/",
+	BasePath:         "/v1",
 	Schemes:          []string{},
 	Title:            "Swagger Example API",
 	Description:      "This is a sample server Petstore server.",
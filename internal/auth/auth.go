@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sberTestTask/internal/httpapi"
+	"sberTestTask/internal/logger"
+	"slices"
+	"strings"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+var (
+	ErrMissingKey = errors.New("missing api key")
+	ErrInvalidKey = errors.New("invalid api key")
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	User   string
+	APIKey string
+}
+
+type Authenticator struct {
+	// users maps API keys to user names.
+	users    map[string]string
+	required bool
+
+	feeds     *FeedTokens
+	feedPaths []string
+}
+
+type Option func(*Authenticator)
+
+// WithFeedTokens lets GET requests to the given paths authenticate with a
+// feed token in the token query parameter instead of an API key.
+func WithFeedTokens(tokens *FeedTokens, paths ...string) Option {
+	return func(a *Authenticator) {
+		a.feeds = tokens
+		a.feedPaths = paths
+	}
+}
+
+// NewAuthenticator authenticates requests by static API keys mapped to user
+// names. When required is false, requests without a key pass through anonymously.
+func NewAuthenticator(users map[string]string, required bool, opts ...Option) *Authenticator {
+	a := &Authenticator{users: users, required: required}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+type ctxKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext returns the principal of the request, if it was authenticated.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(Principal)
+	return p, ok
+}
+
+func (a *Authenticator) lookup(key string) (Principal, bool) {
+	for k, user := range a.users {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			return Principal{User: user, APIKey: k}, true
+		}
+	}
+	return Principal{}, false
+}
+
+// keysOf returns the API keys of a user.
+func (a *Authenticator) keysOf(user string) []string {
+	var keys []string
+	for k, u := range a.users {
+		if u == user {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// KeyFromRequest extracts the API key from X-API-Key or a bearer token.
+func KeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// Authenticate returns the principal of an API key, as found in a request
+// by KeyFromRequest. An empty key is anonymous, reported by ok false, unless
+// keys are required.
+func (a *Authenticator) Authenticate(key string) (p Principal, ok bool, err error) {
+	if key == "" {
+		if a.required {
+			return Principal{}, false, ErrMissingKey
+		}
+		return Principal{}, false, nil
+	}
+	p, ok = a.lookup(key)
+	if !ok {
+		return Principal{}, false, ErrInvalidKey
+	}
+	return p, true, nil
+}
+
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := KeyFromRequest(r)
+		if token := r.URL.Query().Get(FeedTokenParam); key == "" && token != "" && a.isFeed(r) {
+			principal, ok := a.feeds.Verify(token, a.keysOf)
+			if !ok {
+				httpapi.WriteProblem(w, http.StatusUnauthorized, "invalid feed token", nil)
+				return
+			}
+			logger.AddAttrs(r.Context(), slog.String("user", principal.User))
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+			return
+		}
+
+		principal, ok, err := a.Authenticate(key)
+		if err != nil {
+			httpapi.WriteProblem(w, http.StatusUnauthorized, err.Error(), nil)
+			return
+		}
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		logger.AddAttrs(r.Context(), slog.String("user", principal.User))
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+func (a *Authenticator) isFeed(r *http.Request) bool {
+	return a.feeds != nil && r.Method == http.MethodGet && slices.Contains(a.feedPaths, r.URL.Path)
+}
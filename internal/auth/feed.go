@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// FeedTokenParam is the query parameter that carries a feed token.
+const FeedTokenParam = "token"
+
+// FeedTokens issues per-user tokens for read-only feeds that clients such
+// as calendar apps fetch without custom headers. A token is the user name
+// and the API key it was issued with, signed with the secret. Removing the
+// key revokes the tokens issued with it; changing the secret revokes all
+// tokens.
+type FeedTokens struct {
+	secret []byte
+}
+
+func NewFeedTokens(secret string) *FeedTokens {
+	return &FeedTokens{secret: []byte(secret)}
+}
+
+// Issue returns a token for the principal's user that is valid as long as
+// the principal's API key is.
+func (f *FeedTokens) Issue(p Principal) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(p.User)) + "." + f.sign(p.User, p.APIKey)
+}
+
+// Verify returns the principal a token was issued to. keys returns the
+// current API keys of a user; the token must have been issued with one of them.
+func (f *FeedTokens) Verify(token string, keys func(user string) []string) (Principal, bool) {
+	encodedUser, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return Principal{}, false
+	}
+	user, err := base64.RawURLEncoding.DecodeString(encodedUser)
+	if err != nil {
+		return Principal{}, false
+	}
+	for _, key := range keys(string(user)) {
+		if hmac.Equal([]byte(signature), []byte(f.sign(string(user), key))) {
+			return Principal{User: string(user), APIKey: key}, true
+		}
+	}
+	return Principal{}, false
+}
+
+func (f *FeedTokens) sign(user, key string) string {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write([]byte("feed:" + user + "\n" + key))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,237 @@
+// Package backup writes and restores NDJSON archives of all tasks.
+//
+// An archive is one JSON object per line: a header, one record per entity
+// and a manifest with the number of records of each kind and the SHA-256 of
+// every byte before the manifest line.
+//
+//	{"kind":"header","format":"todo-backup","version":1,"created_at":"..."}
+//	{"kind":"task","task":{"id":1,"title":"...","due_date":"...","completed":false}}
+//	{"kind":"webhook_subscription","webhook_subscription":{"id":1,"url":"...","secret":"...","events":["task.created"]}}
+//	{"kind":"reminder","reminder":{"task_id":1,"offset":"1h","channel":"email","status":"pending"}}
+//	{"kind":"manifest","counts":{"reminder":1,"task":1,"webhook_subscription":1},"sha256":"..."}
+//
+// Tasks come first, so that reminders can be restored against them. Webhook
+// subscriptions and reminders are archived only when Backup is given their
+// stores; archives hold the subscription secrets. New kinds can be added
+// without a version change as long as older readers may skip them.
+package backup
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sberTestTask/internal/reminder"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/repository"
+	"sberTestTask/internal/webhook"
+	"time"
+)
+
+const (
+	Format  = "todo-backup"
+	Version = 1
+
+	kindHeader       = "header"
+	kindManifest     = "manifest"
+	kindTask         = "task"
+	kindSubscription = "webhook_subscription"
+	kindReminder     = "reminder"
+
+	maxLineSize = 1 << 20
+)
+
+type header struct {
+	Kind      string    `json:"kind"`
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type record struct {
+	Kind         string                `json:"kind"`
+	Task         *todo.Task            `json:"task,omitempty"`
+	Subscription *webhook.Subscription `json:"webhook_subscription,omitempty"`
+	Reminder     *reminder.Reminder    `json:"reminder,omitempty"`
+}
+
+// SubscriptionStore reads and writes webhook subscriptions with their secrets.
+type SubscriptionStore interface {
+	StreamSubscriptions(ctx context.Context, fn func(*webhook.Subscription) error) error
+	// RestoreSubscription inserts sub under its ID, or under a new one if
+	// the ID is 0. An existing subscription with the ID is replaced if
+	// overwrite is set and kept otherwise, which restored reports.
+	RestoreSubscription(ctx context.Context, sub *webhook.Subscription, overwrite bool) (restored bool, err error)
+}
+
+// ReminderStore reads and writes reminders. Reminders are identified by
+// their task, offset, channel and target; their IDs are not kept.
+type ReminderStore interface {
+	StreamReminders(ctx context.Context, fn func(*reminder.Reminder) error) error
+	// RestoreReminder inserts rem unless its task does not exist. An
+	// existing reminder is replaced if overwrite is set and kept otherwise.
+	RestoreReminder(ctx context.Context, rem *reminder.Reminder, overwrite bool) (restored bool, err error)
+}
+
+type options struct {
+	subscriptions SubscriptionStore
+	reminders     ReminderStore
+}
+
+type Option func(*options)
+
+// WithSubscriptions includes webhook subscriptions in backups and restores.
+func WithSubscriptions(store SubscriptionStore) Option {
+	return func(o *options) {
+		o.subscriptions = store
+	}
+}
+
+// WithReminders includes reminders in backups and restores.
+func WithReminders(store ReminderStore) Option {
+	return func(o *options) {
+		o.reminders = store
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Manifest closes an archive.
+type Manifest struct {
+	Kind   string         `json:"kind"`
+	Counts map[string]int `json:"counts"`
+	SHA256 string         `json:"sha256"`
+}
+
+// Backup streams all tasks of repo into w, followed by the webhook
+// subscriptions and reminders of the stores given as options.
+func Backup(ctx context.Context, repo repository.TodoRepository, w io.Writer, now time.Time, opts ...Option) (*Manifest, error) {
+	o := newOptions(opts)
+	bw := bufio.NewWriter(w)
+	hash := sha256.New()
+	enc := json.NewEncoder(io.MultiWriter(bw, hash))
+
+	if err := enc.Encode(header{Kind: kindHeader, Format: Format, Version: Version, CreatedAt: now.UTC()}); err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{Kind: kindManifest, Counts: map[string]int{kindTask: 0}}
+	err := repo.StreamTasks(ctx, todo.TaskFilter{}, func(task *todo.Task) error {
+		manifest.Counts[kindTask]++
+		return enc.Encode(record{Kind: kindTask, Task: task})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if o.subscriptions != nil {
+		manifest.Counts[kindSubscription] = 0
+		err := o.subscriptions.StreamSubscriptions(ctx, func(sub *webhook.Subscription) error {
+			manifest.Counts[kindSubscription]++
+			return enc.Encode(record{Kind: kindSubscription, Subscription: sub})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if o.reminders != nil {
+		manifest.Counts[kindReminder] = 0
+		err := o.reminders.StreamReminders(ctx, func(rem *reminder.Reminder) error {
+			manifest.Counts[kindReminder]++
+			return enc.Encode(record{Kind: kindReminder, Reminder: rem})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	manifest.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	if err := json.NewEncoder(bw).Encode(manifest); err != nil {
+		return nil, err
+	}
+	return manifest, bw.Flush()
+}
+
+// Verify reads a whole archive and checks its header, record counts and
+// checksum. Every kind of record in the archive must be in the manifest.
+func Verify(r io.Reader) (*Manifest, error) {
+	hash := sha256.New()
+	counts := make(map[string]int)
+	var manifest *Manifest
+
+	err := scan(r, func(n int, line []byte, kind string) error {
+		if manifest != nil {
+			return fmt.Errorf("line %d: data after the manifest", n)
+		}
+		if kind == kindManifest {
+			manifest = &Manifest{}
+			return json.Unmarshal(line, manifest)
+		}
+		hash.Write(line)
+		hash.Write([]byte("\n"))
+		if kind != kindHeader {
+			counts[kind]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest == nil {
+		return nil, errors.New("archive is truncated: no manifest")
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != manifest.SHA256 {
+		return nil, errors.New("checksum mismatch")
+	}
+	for kind, n := range manifest.Counts {
+		if counts[kind] != n {
+			return nil, fmt.Errorf("manifest lists %d %s records, archive has %d", n, kind, counts[kind])
+		}
+	}
+	for kind, n := range counts {
+		if _, ok := manifest.Counts[kind]; !ok {
+			return nil, fmt.Errorf("archive has %d %s records missing from the manifest", n, kind)
+		}
+	}
+	return manifest, nil
+}
+
+// scan calls fn for every line with its kind after checking the header.
+func scan(r io.Reader, fn func(n int, line []byte, kind string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		var kind struct {
+			Kind string `json:"kind"`
+		}
+		if err := json.Unmarshal(line, &kind); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+
+		if n == 1 {
+			var h header
+			if err := json.Unmarshal(line, &h); err != nil || h.Kind != kindHeader || h.Format != Format {
+				return errors.New("not a todo backup")
+			}
+			if h.Version > Version {
+				return fmt.Errorf("archive version %d is newer than supported version %d", h.Version, Version)
+			}
+		}
+		if err := fn(n, line, kind.Kind); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,241 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sberTestTask/internal/reminder"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/tests/mocks/repositoryMock"
+	"sberTestTask/internal/webhook"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testArchive(t *testing.T) []byte {
+	date := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	tasks := []*todo.Task{
+		{ID: 1, Title: "First", DueDate: &date},
+		{ID: 2, Title: "Second", Description: "desc", DueDate: &date, Completed: true},
+	}
+
+	repo := new(repositoryMock.MockTodoRepository)
+	repo.On("StreamTasks", mock.Anything, todo.TaskFilter{}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(*todo.Task) error)
+			for _, task := range tasks {
+				assert.NoError(t, fn(task))
+			}
+		}).Return(nil)
+
+	var buf bytes.Buffer
+	manifest, err := Backup(context.Background(), repo, &buf, date)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"task": 2}, manifest.Counts)
+	return buf.Bytes()
+}
+
+func TestBackupVerify(t *testing.T) {
+	archive := testArchive(t)
+
+	lines := strings.Split(strings.TrimSpace(string(archive)), "\n")
+	assert.Len(t, lines, 4)
+	assert.Equal(t, `{"kind":"header","format":"todo-backup","version":1,"created_at":"2024-06-07T15:00:00Z"}`, lines[0])
+	assert.Equal(t, `{"kind":"task","task":{"id":1,"title":"First","due_date":"2024-06-07T15:00:00Z","completed":false}}`, lines[1])
+
+	manifest, err := Verify(bytes.NewReader(archive))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, manifest.Counts["task"])
+}
+
+func TestVerifyRejectsDamagedArchives(t *testing.T) {
+	archive := string(testArchive(t))
+	lines := strings.SplitAfter(archive, "\n")
+
+	tests := map[string]struct {
+		archive string
+		err     string
+	}{
+		"tampered":  {strings.Replace(archive, "First", "Fir5t", 1), "checksum mismatch"},
+		"truncated": {strings.Join(lines[:3], ""), "archive is truncated: no manifest"},
+		"dropped record": {lines[0] + lines[2] + lines[3],
+			"checksum mismatch"},
+		"not a backup": {`{"kind":"task"}` + "\n", "not a todo backup"},
+		"newer version": {strings.Replace(archive, `"version":1`, `"version":2`, 1),
+			"archive version 2 is newer than supported version 1"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Verify(strings.NewReader(tt.archive))
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestVerifyRejectsUndeclaredKinds(t *testing.T) {
+	body := `{"kind":"header","format":"todo-backup","version":1,"created_at":"2024-06-07T15:00:00Z"}` + "\n" +
+		`{"kind":"task","task":{"id":1,"title":"First","completed":false}}` + "\n" +
+		`{"kind":"reminder","reminder":{"task_id":1,"offset":"1h","channel":"log"}}` + "\n"
+	sum := sha256.Sum256([]byte(body))
+	archive := body + `{"kind":"manifest","counts":{"task":1},"sha256":"` + hex.EncodeToString(sum[:]) + `"}` + "\n"
+
+	_, err := Verify(strings.NewReader(archive))
+	assert.EqualError(t, err, "archive has 1 reminder records missing from the manifest")
+}
+
+// fakeStores keeps webhook subscriptions and reminders in memory.
+type fakeStores struct {
+	subscriptions []*webhook.Subscription
+	reminders     []*reminder.Reminder
+}
+
+func (f *fakeStores) StreamSubscriptions(_ context.Context, fn func(*webhook.Subscription) error) error {
+	for _, sub := range f.subscriptions {
+		if err := fn(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeStores) RestoreSubscription(_ context.Context, sub *webhook.Subscription, overwrite bool) (bool, error) {
+	if sub.ID == 0 {
+		sub.ID = 100 + len(f.subscriptions)
+	}
+	for i, existing := range f.subscriptions {
+		if existing.ID == sub.ID {
+			if overwrite {
+				f.subscriptions[i] = sub
+			}
+			return overwrite, nil
+		}
+	}
+	f.subscriptions = append(f.subscriptions, sub)
+	return true, nil
+}
+
+func (f *fakeStores) StreamReminders(_ context.Context, fn func(*reminder.Reminder) error) error {
+	for _, rem := range f.reminders {
+		if err := fn(rem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeStores) RestoreReminder(_ context.Context, rem *reminder.Reminder, _ bool) (bool, error) {
+	f.reminders = append(f.reminders, rem)
+	return true, nil
+}
+
+func TestBackupRestoreSubscriptionsAndReminders(t *testing.T) {
+	ctx := context.Background()
+	date := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	source := &fakeStores{
+		subscriptions: []*webhook.Subscription{
+			{ID: 3, URL: "https://example.com/hook", Secret: "s3cret", Events: []string{"task.created"}, CreatedAt: date},
+		},
+		reminders: []*reminder.Reminder{
+			{ID: 9, TaskID: 7, Offset: reminder.Offset(time.Hour), Channel: "log", Status: reminder.StatusSent, CreatedAt: date},
+		},
+	}
+	repo := new(repositoryMock.MockTodoRepository)
+	repo.On("StreamTasks", mock.Anything, todo.TaskFilter{}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(*todo.Task) error)
+			assert.NoError(t, fn(&todo.Task{ID: 7, Title: "Task", DueDate: &date}))
+		}).Return(nil)
+
+	var buf bytes.Buffer
+	manifest, err := Backup(ctx, repo, &buf, date, WithSubscriptions(source), WithReminders(source))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"task": 1, "webhook_subscription": 1, "reminder": 1}, manifest.Counts)
+	assert.Contains(t, buf.String(), `"secret":"s3cret"`)
+
+	_, err = Verify(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+
+	// Renumbered tasks take their reminders along.
+	target := new(repositoryMock.MockTodoRepository)
+	target.On("CreateTasks", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).([]*todo.Task)[0].ID = 42
+	}).Return(nil)
+	restored := &fakeStores{}
+	stats, err := Restore(ctx, target, bytes.NewReader(buf.Bytes()), Renumber, WithSubscriptions(restored), WithReminders(restored))
+	assert.NoError(t, err)
+	assert.Equal(t, Stats{Created: 1, Subscriptions: 1, Reminders: 1}, stats)
+	if assert.Len(t, restored.subscriptions, 1) {
+		assert.Equal(t, 100, restored.subscriptions[0].ID)
+		assert.Equal(t, "s3cret", restored.subscriptions[0].Secret)
+	}
+	if assert.Len(t, restored.reminders, 1) {
+		assert.Equal(t, 42, restored.reminders[0].TaskID)
+		assert.Equal(t, reminder.StatusSent, restored.reminders[0].Status)
+	}
+
+	// Without the stores the records are skipped.
+	target = new(repositoryMock.MockTodoRepository)
+	target.On("GetTasks", ctx, []int{7}).Return([]*todo.Task{}, nil)
+	target.On("SaveTasks", ctx, mock.Anything).Return(nil)
+	stats, err = Restore(ctx, target, bytes.NewReader(buf.Bytes()), Skip)
+	assert.NoError(t, err)
+	assert.Equal(t, Stats{Created: 1}, stats)
+}
+
+func TestRestore(t *testing.T) {
+	archive := testArchive(t)
+	ctx := context.Background()
+	existing := []*todo.Task{{ID: 2, Title: "Already here"}}
+
+	t.Run("skip", func(t *testing.T) {
+		repo := new(repositoryMock.MockTodoRepository)
+		repo.On("GetTasks", ctx, []int{1, 2}).Return(existing, nil)
+		repo.On("SaveTasks", ctx, mock.MatchedBy(func(tasks []*todo.Task) bool {
+			return len(tasks) == 1 && tasks[0].ID == 1
+		})).Return(nil)
+
+		stats, err := Restore(ctx, repo, bytes.NewReader(archive), Skip)
+		assert.NoError(t, err)
+		assert.Equal(t, Stats{Created: 1, Skipped: 1}, stats)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		repo := new(repositoryMock.MockTodoRepository)
+		repo.On("GetTasks", ctx, []int{1, 2}).Return(existing, nil)
+		repo.On("SaveTasks", ctx, mock.MatchedBy(func(tasks []*todo.Task) bool {
+			return len(tasks) == 2 && tasks[1].Title == "Second"
+		})).Return(nil)
+
+		stats, err := Restore(ctx, repo, bytes.NewReader(archive), Overwrite)
+		assert.NoError(t, err)
+		assert.Equal(t, Stats{Created: 1, Updated: 1}, stats)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("renumber", func(t *testing.T) {
+		repo := new(repositoryMock.MockTodoRepository)
+		repo.On("CreateTasks", ctx, mock.MatchedBy(func(tasks []*todo.Task) bool {
+			return len(tasks) == 2 && tasks[0].ID == 0 && tasks[1].ID == 0
+		})).Return(nil)
+
+		stats, err := Restore(ctx, repo, bytes.NewReader(archive), Renumber)
+		assert.NoError(t, err)
+		assert.Equal(t, Stats{Created: 2}, stats)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestParseStrategy(t *testing.T) {
+	s, err := ParseStrategy("renumber")
+	assert.NoError(t, err)
+	assert.Equal(t, Renumber, s)
+
+	_, err = ParseStrategy("merge")
+	assert.Error(t, err)
+}
@@ -0,0 +1,173 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/repository"
+)
+
+// Strategy decides what happens to archived tasks whose ID already exists.
+type Strategy string
+
+const (
+	// Skip keeps the existing task.
+	Skip Strategy = "skip"
+	// Overwrite replaces the existing task with the archived one.
+	Overwrite Strategy = "overwrite"
+	// Renumber inserts every archived task under a new ID.
+	Renumber Strategy = "renumber"
+)
+
+func ParseStrategy(s string) (Strategy, error) {
+	switch Strategy(s) {
+	case Skip, Overwrite, Renumber:
+		return Strategy(s), nil
+	default:
+		return "", fmt.Errorf("unknown conflict strategy %q, want skip, overwrite or renumber", s)
+	}
+}
+
+const batchSize = 500
+
+// Stats counts what Restore did with the archived tasks, and how many
+// webhook subscriptions and reminders it wrote.
+type Stats struct {
+	Created int
+	Updated int
+	Skipped int
+
+	Subscriptions int
+	Reminders     int
+}
+
+// Restore loads a verified archive into repo in batches, and its webhook
+// subscriptions and reminders into the stores given as options; records of
+// kinds without a store are skipped. Under Renumber, reminders follow their
+// tasks to the new IDs. Restore is not atomic: after a failure the records
+// written so far stay restored, and running it again with Skip continues
+// where it stopped.
+func Restore(ctx context.Context, repo repository.TodoRepository, r io.Reader, strategy Strategy, opts ...Option) (Stats, error) {
+	o := newOptions(opts)
+	var stats Stats
+	// ids maps archived task IDs to the new ones under Renumber.
+	ids := make(map[int]int)
+	batch := make([]*todo.Task, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := restoreBatch(ctx, repo, batch, strategy, ids, &stats)
+		batch = batch[:0]
+		return err
+	}
+
+	err := scan(r, func(n int, line []byte, kind string) error {
+		switch {
+		case kind == kindTask:
+			var rec record
+			if err := json.Unmarshal(line, &rec); err != nil || rec.Task == nil {
+				return fmt.Errorf("line %d: invalid task record", n)
+			}
+			batch = append(batch, rec.Task)
+			if len(batch) == batchSize {
+				return flush()
+			}
+		case kind == kindSubscription && o.subscriptions != nil:
+			var rec record
+			if err := json.Unmarshal(line, &rec); err != nil || rec.Subscription == nil {
+				return fmt.Errorf("line %d: invalid webhook subscription record", n)
+			}
+			if strategy == Renumber {
+				rec.Subscription.ID = 0
+			}
+			restored, err := o.subscriptions.RestoreSubscription(ctx, rec.Subscription, strategy == Overwrite)
+			if restored {
+				stats.Subscriptions++
+			}
+			return err
+		case kind == kindReminder && o.reminders != nil:
+			var rec record
+			if err := json.Unmarshal(line, &rec); err != nil || rec.Reminder == nil {
+				return fmt.Errorf("line %d: invalid reminder record", n)
+			}
+			// The tasks precede the reminders; the last batch of them
+			// must be written first.
+			if err := flush(); err != nil {
+				return err
+			}
+			if strategy == Renumber {
+				id, ok := ids[rec.Reminder.TaskID]
+				if !ok {
+					return nil
+				}
+				rec.Reminder.TaskID = id
+			}
+			restored, err := o.reminders.RestoreReminder(ctx, rec.Reminder, strategy == Overwrite)
+			if restored {
+				stats.Reminders++
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	return stats, flush()
+}
+
+func restoreBatch(ctx context.Context, repo repository.TodoRepository, tasks []*todo.Task, strategy Strategy, ids map[int]int, stats *Stats) error {
+	if strategy == Renumber {
+		archived := make([]int, len(tasks))
+		for i, task := range tasks {
+			archived[i] = task.ID
+			task.ID = 0
+		}
+		if err := repo.CreateTasks(ctx, tasks); err != nil {
+			return err
+		}
+		for i, task := range tasks {
+			ids[archived[i]] = task.ID
+		}
+		stats.Created += len(tasks)
+		return nil
+	}
+
+	archived := make([]int, len(tasks))
+	for i, task := range tasks {
+		archived[i] = task.ID
+	}
+	found, err := repo.GetTasks(ctx, archived)
+	if err != nil {
+		return err
+	}
+	existing := make(map[int]bool, len(found))
+	for _, task := range found {
+		existing[task.ID] = true
+	}
+
+	save := tasks
+	if strategy == Skip {
+		save = make([]*todo.Task, 0, len(tasks))
+		for _, task := range tasks {
+			if !existing[task.ID] {
+				save = append(save, task)
+			}
+		}
+	}
+	if err := repo.SaveTasks(ctx, save); err != nil {
+		return err
+	}
+
+	if strategy == Skip {
+		stats.Skipped += len(tasks) - len(save)
+		stats.Created += len(save)
+	} else {
+		stats.Updated += len(existing)
+		stats.Created += len(tasks) - len(existing)
+	}
+	return nil
+}
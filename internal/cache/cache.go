@@ -0,0 +1,19 @@
+// Package cache provides the storage behind read-through caches. Values
+// are opaque bytes so that a shared backend can be added next to the
+// in-process LRU.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Backend stores values by key. Implementations must be safe for
+// concurrent use.
+type Backend interface {
+	// Get returns the value stored under key and whether it was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl, or until evicted when ttl is 0.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+	c.Set(ctx, "a", []byte("1"), 0)
+	c.Set(ctx, "b", []byte("2"), 0)
+	// Reading a makes b the least recently used.
+	_, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+	c.Set(ctx, "c", []byte("3"), 0)
+
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok)
+	v, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", string(v))
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRUExpires(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 7, 12, 0, 0, 0, time.UTC)
+	c := NewLRU(10)
+	c.now = func() time.Time { return now }
+
+	c.Set(ctx, "short", []byte("x"), time.Second)
+	c.Set(ctx, "forever", []byte("y"), 0)
+	now = now.Add(time.Second)
+
+	_, ok, _ := c.Get(ctx, "short")
+	assert.False(t, ok)
+	_, ok, _ = c.Get(ctx, "forever")
+	assert.True(t, ok)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestLRUSetReplacesAndDelete(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+	c.Set(ctx, "a", []byte("1"), 0)
+	c.Set(ctx, "a", []byte("2"), 0)
+	v, _, _ := c.Get(ctx, "a")
+	assert.Equal(t, "2", string(v))
+
+	c.Delete(ctx, "a", "missing")
+	_, ok, _ := c.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
@@ -4,15 +4,154 @@ import (
 	"github.com/spf13/viper"
 	"log"
 	"os"
+	"time"
 )
 
 type Config struct {
 	Database struct {
-		URL string `mapstructure:"url"`
+		URL             string        `mapstructure:"url"`
+		MaxOpenConns    int           `mapstructure:"max_open_conns"`
+		MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+		ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+		ConnectRetries  int           `mapstructure:"connect_retries"`
+		ConnectBackoff  time.Duration `mapstructure:"connect_backoff"`
 	} `mapstructure:"database"`
 	Server struct {
-		Port string `mapstructure:"port"`
+		Port              string        `mapstructure:"port"`
+		ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+		ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+		WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+		IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+		ShutdownTimeout   time.Duration `mapstructure:"shutdown_timeout"`
+		DrainPeriod       time.Duration `mapstructure:"drain_period"`
+		// MaxBodySize limits JSON request bodies in bytes; imports have
+		// their own limit.
+		MaxBodySize int64 `mapstructure:"max_body_size"`
 	} `mapstructure:"server"`
+	Tasks struct {
+		// TimeZone is the default IANA zone of date filters and days
+		// overdue.
+		TimeZone string `mapstructure:"time_zone"`
+	} `mapstructure:"tasks"`
+	Cache struct {
+		Enabled     bool          `mapstructure:"enabled"`
+		Size        int           `mapstructure:"size"`
+		TTL         time.Duration `mapstructure:"ttl"`
+		NegativeTTL time.Duration `mapstructure:"negative_ttl"`
+	} `mapstructure:"cache"`
+	API struct {
+		// Deprecations by API version (v1, unversioned, ...).
+		Deprecations map[string]APIDeprecation `mapstructure:"deprecations"`
+	} `mapstructure:"api"`
+	// CacheControl is the Cache-Control header of successful responses:
+	// Default for GET requests, Routes per method and chi route pattern.
+	CacheControl struct {
+		Default string              `mapstructure:"default"`
+		Routes  []CacheControlRoute `mapstructure:"routes"`
+	} `mapstructure:"cache_control"`
+	Events struct {
+		Backend     string `mapstructure:"backend"`
+		HistorySize int    `mapstructure:"history_size"`
+	} `mapstructure:"events"`
+	Webhooks struct {
+		Enabled        bool          `mapstructure:"enabled"`
+		MaxAttempts    int           `mapstructure:"max_attempts"`
+		InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+		MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+		Timeout        time.Duration `mapstructure:"timeout"`
+		PollInterval   time.Duration `mapstructure:"poll_interval"`
+		// AllowedHosts limits subscription URLs to these hosts; "*."
+		// entries match subdomains. Empty allows any public host.
+		AllowedHosts []string `mapstructure:"allowed_hosts"`
+	} `mapstructure:"webhooks"`
+	Reminders struct {
+		Enabled        bool          `mapstructure:"enabled"`
+		Channels       []string      `mapstructure:"channels"`
+		MaxAttempts    int           `mapstructure:"max_attempts"`
+		InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+		MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+		Timeout        time.Duration `mapstructure:"timeout"`
+		PollInterval   time.Duration `mapstructure:"poll_interval"`
+		Webhook        struct {
+			URL          string   `mapstructure:"url"`
+			Secret       string   `mapstructure:"secret"`
+			AllowedHosts []string `mapstructure:"allowed_hosts"`
+		} `mapstructure:"webhook"`
+		Email struct {
+			Addr           string   `mapstructure:"addr"`
+			Username       string   `mapstructure:"username"`
+			Password       string   `mapstructure:"password"`
+			From           string   `mapstructure:"from"`
+			To             string   `mapstructure:"to"`
+			AllowedDomains []string `mapstructure:"allowed_domains"`
+		} `mapstructure:"email"`
+	} `mapstructure:"reminders"`
+	WebSocket struct {
+		AllowedOrigins []string `mapstructure:"allowed_origins"`
+	} `mapstructure:"websocket"`
+	GRPC struct {
+		Port string `mapstructure:"port"`
+	} `mapstructure:"grpc"`
+	GraphQL struct {
+		ComplexityLimit int `mapstructure:"complexity_limit"`
+		MaxDepth        int `mapstructure:"max_depth"`
+	} `mapstructure:"graphql"`
+	Log struct {
+		Format string `mapstructure:"format"`
+		Level  string `mapstructure:"level"`
+	} `mapstructure:"log"`
+	Auth struct {
+		Required   bool     `mapstructure:"required"`
+		APIKeys    []APIKey `mapstructure:"api_keys"`
+		FeedSecret string   `mapstructure:"feed_secret"`
+	} `mapstructure:"auth"`
+	RateLimit struct {
+		Enabled bool             `mapstructure:"enabled"`
+		Store   string           `mapstructure:"store"`
+		KeyBy   string           `mapstructure:"key_by"`
+		Default RateLimitRule    `mapstructure:"default"`
+		Routes  []RateLimitRoute `mapstructure:"routes"`
+	} `mapstructure:"rate_limit"`
+	Tracing struct {
+		Exporter    string  `mapstructure:"exporter"`
+		Endpoint    string  `mapstructure:"endpoint"`
+		Insecure    bool    `mapstructure:"insecure"`
+		File        string  `mapstructure:"file"`
+		ServiceName string  `mapstructure:"service_name"`
+		SampleRatio float64 `mapstructure:"sample_ratio"`
+	} `mapstructure:"tracing"`
+}
+
+type APIKey struct {
+	Key  string `mapstructure:"key"`
+	User string `mapstructure:"user"`
+	// TimeZone is the user's IANA zone for date filters, overridden by
+	// the tz query parameter.
+	TimeZone string `mapstructure:"time_zone"`
+}
+
+type RateLimitRule struct {
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
+}
+
+// APIDeprecation dates are YYYY-MM-DD or RFC 3339; sunset is optional.
+type APIDeprecation struct {
+	Since  string `mapstructure:"since"`
+	Sunset string `mapstructure:"sunset"`
+	Link   string `mapstructure:"link"`
+}
+
+type CacheControlRoute struct {
+	Method  string `mapstructure:"method"`
+	Pattern string `mapstructure:"pattern"`
+	Value   string `mapstructure:"value"`
+}
+
+type RateLimitRoute struct {
+	Method        string `mapstructure:"method"`
+	Pattern       string `mapstructure:"pattern"`
+	RateLimitRule `mapstructure:",squash"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -28,6 +167,65 @@ func LoadConfig() (*Config, error) {
 
 	viper.BindEnv("database.url", "DATABASE_URL")
 	viper.BindEnv("server.port", "SERVER_PORT")
+	viper.BindEnv("grpc.port", "GRPC_PORT")
+	viper.BindEnv("log.level", "LOG_LEVEL")
+	viper.BindEnv("auth.feed_secret", "FEED_SECRET")
+	viper.BindEnv("reminders.email.password", "SMTP_PASSWORD")
+
+	viper.SetDefault("database.max_open_conns", 25)
+	viper.SetDefault("database.max_idle_conns", 25)
+	viper.SetDefault("database.conn_max_lifetime", 5*time.Minute)
+	viper.SetDefault("database.connect_retries", 5)
+	viper.SetDefault("database.connect_backoff", time.Second)
+
+	viper.SetDefault("server.read_timeout", 10*time.Second)
+	viper.SetDefault("server.read_header_timeout", 5*time.Second)
+	viper.SetDefault("server.write_timeout", 30*time.Second)
+	viper.SetDefault("server.idle_timeout", 120*time.Second)
+	viper.SetDefault("server.shutdown_timeout", 30*time.Second)
+	viper.SetDefault("server.drain_period", 5*time.Second)
+	viper.SetDefault("server.max_body_size", 1<<20)
+
+	viper.SetDefault("tasks.time_zone", "UTC")
+
+	viper.SetDefault("cache.enabled", true)
+	viper.SetDefault("cache.size", 10000)
+	viper.SetDefault("cache.ttl", 30*time.Second)
+	viper.SetDefault("cache.negative_ttl", 5*time.Second)
+
+	viper.SetDefault("cache_control.default", "private, no-cache")
+
+	viper.SetDefault("events.backend", "memory")
+	viper.SetDefault("events.history_size", 1000)
+
+	viper.SetDefault("webhooks.max_attempts", 8)
+	viper.SetDefault("webhooks.initial_backoff", 10*time.Second)
+	viper.SetDefault("webhooks.max_backoff", time.Hour)
+	viper.SetDefault("webhooks.timeout", 10*time.Second)
+	viper.SetDefault("webhooks.poll_interval", time.Second)
+
+	viper.SetDefault("reminders.channels", []string{"log"})
+	viper.SetDefault("reminders.max_attempts", 5)
+	viper.SetDefault("reminders.initial_backoff", 30*time.Second)
+	viper.SetDefault("reminders.max_backoff", 30*time.Minute)
+	viper.SetDefault("reminders.timeout", 10*time.Second)
+	viper.SetDefault("reminders.poll_interval", 5*time.Second)
+	viper.SetDefault("reminders.email.addr", "localhost:1025")
+
+	viper.SetDefault("grpc.port", "9090")
+
+	viper.SetDefault("graphql.complexity_limit", 1000)
+	viper.SetDefault("graphql.max_depth", 10)
+
+	viper.SetDefault("log.format", "json")
+	viper.SetDefault("log.level", "info")
+
+	viper.SetDefault("rate_limit.store", "memory")
+	viper.SetDefault("rate_limit.key_by", "api_key")
+
+	viper.SetDefault("tracing.exporter", "none")
+	viper.SetDefault("tracing.service_name", "todo-service")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
 
 	if err := viper.ReadInConfig(); err != nil {
 		log.Printf("Error reading config file, %s", err)
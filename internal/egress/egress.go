@@ -0,0 +1,65 @@
+// Package egress builds HTTP clients for requests to URLs supplied by API
+// callers, which must not reach services inside the deployment.
+package egress
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// PublicClient returns a client that only connects to public addresses,
+// ignores proxy settings and returns redirects instead of following them.
+func PublicClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{Timeout: timeout, Control: DialPublic}).DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// DialPublic refuses connections to loopback, private, link-local and other
+// non-public addresses. It runs after name resolution, so an allowed host
+// name cannot be pointed at an internal service.
+func DialPublic(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if addr := addrPort.Addr(); !IsPublic(addr) {
+		return fmt.Errorf("address %s is not public", addr.Unmap())
+	}
+	return nil
+}
+
+// IsPublic reports whether addr is a global unicast address outside the
+// private ranges.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !addr.IsLoopback() && !addr.IsLinkLocalUnicast()
+}
+
+// HostAllowed reports whether host matches an entry of allowed, exactly or,
+// for "*." entries, as a subdomain.
+func HostAllowed(host string, allowed []string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range allowed {
+		entry = strings.ToLower(entry)
+		if host == entry {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(entry, "*"); ok && strings.HasSuffix(host, suffix) && strings.HasPrefix(suffix, ".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package egress
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialPublic(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1:80", "10.1.2.3:443", "192.168.0.10:80", "169.254.169.254:80", "[::1]:80", "[fe80::1]:80", "0.0.0.0:80", "[::ffff:127.0.0.1]:80"} {
+		assert.Error(t, DialPublic("tcp", addr, nil), addr)
+	}
+	for _, addr := range []string{"93.184.216.34:443", "[2606:2800:220:1:248:1893:25c8:1946]:443"} {
+		assert.NoError(t, DialPublic("tcp", addr, nil), addr)
+	}
+}
+
+func TestPublicClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	client := PublicClient(time.Second)
+	_, err := client.Get(srv.URL)
+	assert.ErrorContains(t, err, "address 127.0.0.1 is not public")
+	assert.ErrorIs(t, client.CheckRedirect(nil, nil), http.ErrUseLastResponse)
+}
+
+func TestHostAllowed(t *testing.T) {
+	allowed := []string{"hooks.example.com", "*.example.org"}
+	for host, want := range map[string]bool{
+		"hooks.example.com": true,
+		"HOOKS.example.com": true,
+		"a.example.com":     false,
+		"a.example.org":     true,
+		"a.b.example.org":   true,
+		"example.org":       false,
+		"badexample.org":    false,
+	} {
+		assert.Equal(t, want, HostAllowed(host, allowed), host)
+	}
+}
@@ -0,0 +1,61 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type checkerFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func NewCheckerFunc(name string, fn func(ctx context.Context) error) HealthChecker {
+	return &checkerFunc{name: name, fn: fn}
+}
+
+func (c *checkerFunc) Name() string                    { return c.name }
+func (c *checkerFunc) Check(ctx context.Context) error { return c.fn(ctx) }
+
+type dbChecker struct {
+	db *sql.DB
+}
+
+func NewDBChecker(db *sql.DB) HealthChecker {
+	return &dbChecker{db: db}
+}
+
+func (c *dbChecker) Name() string { return "database" }
+
+func (c *dbChecker) Check(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
+type migrationChecker struct {
+	db       *sql.DB
+	expected int64
+}
+
+// NewMigrationChecker verifies that the goose schema version in the database
+// is the one the binary was built against.
+func NewMigrationChecker(db *sql.DB, expected int64) HealthChecker {
+	return &migrationChecker{db: db, expected: expected}
+}
+
+func (c *migrationChecker) Name() string { return "migrations" }
+
+func (c *migrationChecker) Check(ctx context.Context) error {
+	var version int64
+	query := `SELECT COALESCE(MAX(version_id), 0) FROM (
+		SELECT DISTINCT ON (version_id) version_id, is_applied FROM goose_db_version ORDER BY version_id, id DESC
+	) v WHERE is_applied`
+	err := c.db.QueryRowContext(ctx, query).Scan(&version)
+	if err != nil {
+		return err
+	}
+	if version != c.expected {
+		return fmt.Errorf("schema version %d, expected %d", version, c.expected)
+	}
+	return nil
+}
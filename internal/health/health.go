@@ -0,0 +1,116 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const checkTimeout = 3 * time.Second
+
+// HealthChecker is implemented by every dependency that takes part in the
+// readiness probe.
+type HealthChecker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type ComponentStatus struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+type Registry struct {
+	mu           sync.RWMutex
+	checkers     []HealthChecker
+	shuttingDown atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) Register(checkers ...HealthChecker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkers = append(r.checkers, checkers...)
+}
+
+// SetShuttingDown makes the readiness probe fail so that traffic is drained
+// before the server stops.
+func (r *Registry) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// Check runs all registered checkers concurrently.
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checkers := append([]HealthChecker(nil), r.checkers...)
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Components: make(map[string]ComponentStatus, len(checkers)+1)}
+	if r.shuttingDown.Load() {
+		report.Status = StatusDown
+		report.Components["shutdown"] = ComponentStatus{Status: StatusDown, Error: "server is shutting down"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checkers {
+		wg.Add(1)
+		go func(c HealthChecker) {
+			defer wg.Done()
+			start := time.Now()
+			err := c.Check(ctx)
+			status := ComponentStatus{Status: StatusUp, Duration: time.Since(start).String()}
+			if err != nil {
+				status.Status = StatusDown
+				status.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[c.Name()] = status
+			if err != nil {
+				report.Status = StatusDown
+			}
+		}(c)
+	}
+	wg.Wait()
+	return report
+}
+
+// Liveness reports that the process is up and serving requests.
+func (r *Registry) Liveness(w http.ResponseWriter, _ *http.Request) {
+	writeReport(w, Report{Status: StatusUp})
+}
+
+// Readiness reports whether the service can accept traffic.
+func (r *Registry) Readiness(w http.ResponseWriter, req *http.Request) {
+	writeReport(w, r.Check(req.Context()))
+}
+
+func writeReport(w http.ResponseWriter, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status != StatusUp {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
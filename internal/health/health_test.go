@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		name           string
+		checkers       []HealthChecker
+		shuttingDown   bool
+		expectedStatus int
+		expectedReport string
+	}{
+		{
+			name: "All Up",
+			checkers: []HealthChecker{
+				NewCheckerFunc("database", func(ctx context.Context) error { return nil }),
+			},
+			expectedStatus: http.StatusOK,
+			expectedReport: StatusUp,
+		},
+		{
+			name: "Component Down",
+			checkers: []HealthChecker{
+				NewCheckerFunc("database", func(ctx context.Context) error { return nil }),
+				NewCheckerFunc("migrations", func(ctx context.Context) error { return errors.New("schema version 1, expected 2") }),
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedReport: StatusDown,
+		},
+		{
+			name:           "Shutting Down",
+			shuttingDown:   true,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedReport: StatusDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			registry.Register(tt.checkers...)
+			if tt.shuttingDown {
+				registry.SetShuttingDown()
+			}
+
+			rr := httptest.NewRecorder()
+			registry.Readiness(rr, httptest.NewRequest("GET", "/readyz", nil))
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			var report Report
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&report))
+			assert.Equal(t, tt.expectedReport, report.Status)
+			for _, c := range tt.checkers {
+				assert.Contains(t, report.Components, c.Name())
+			}
+		})
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetShuttingDown()
+
+	rr := httptest.NewRecorder()
+	registry.Liveness(rr, httptest.NewRequest("GET", "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status":"up"}`, rr.Body.String())
+}
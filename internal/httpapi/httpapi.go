@@ -0,0 +1,153 @@
+// Package httpapi holds the error responses and JSON body rules shared by
+// the REST handlers and the middlewares in front of them.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sberTestTask/internal/validation"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultMaxBodySize limits JSON request bodies.
+const DefaultMaxBodySize = 1 << 20
+
+// Problem is an RFC 9457 problem details body. Errors lists the invalid
+// fields of a request that failed validation.
+type Problem struct {
+	Type   string            `json:"type" example:"about:blank"`
+	Title  string            `json:"title" example:"Unprocessable Entity"`
+	Status int               `json:"status" example:"422"`
+	Detail string            `json:"detail,omitempty" example:"title cannot be empty"`
+	Errors validation.Errors `json:"errors,omitempty"`
+}
+
+// WriteProblem answers with status and a Problem body.
+func WriteProblem(w http.ResponseWriter, status int, detail string, errs validation.Errors) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail, Errors: errs})
+}
+
+// WriteInvalid answers 422 with the field errors of err, a
+// validation.Errors, or 400 with err's text otherwise.
+func WriteInvalid(w http.ResponseWriter, err error) {
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	WriteProblem(w, http.StatusUnprocessableEntity, errs.Error(), errs)
+}
+
+// TooLargeError reports a body over the limit of http.MaxBytesReader.
+type TooLargeError struct {
+	What  string
+	Limit int64
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("%s is larger than %d bytes", e.What, e.Limit)
+}
+
+// BodyErrorStatus is 413 for bodies over their limit and 400 for other
+// unreadable bodies.
+func BodyErrorStatus(err error) int {
+	var tooLarge *TooLargeError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// Decode reads the JSON object in the request body, at most limit bytes,
+// into v, a pointer to a request DTO. Unknown fields, serverFields and
+// values of the wrong type are returned as field errors, for the caller to
+// report along with its own rules; names must match exactly. An unreadable
+// body is answered right away and Decode returns false.
+func Decode(w http.ResponseWriter, r *http.Request, v any, limit int64, serverFields ...string) (validation.Errors, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			err = &TooLargeError{What: "request body", Limit: limit}
+		}
+		WriteProblem(w, BodyErrorStatus(err), err.Error(), nil)
+		return nil, false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
+		return nil, false
+	}
+	return decodeFields(fields, v, serverFields), true
+}
+
+// decodeFields sets the fields of the struct v points to from fields, keyed
+// by JSON name, and collects every field that cannot be set.
+func decodeFields(fields map[string]json.RawMessage, v any, serverFields []string) validation.Errors {
+	var errs validation.Errors
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	target := reflect.ValueOf(v).Elem()
+	known := jsonFields(target.Type())
+	for _, name := range names {
+		i, ok := known[name]
+		switch {
+		case slices.Contains(serverFields, name):
+			errs.Add(name, "is set by the server")
+		case !ok:
+			errs.Add(name, "is not a known field")
+		default:
+			if err := json.Unmarshal(fields[name], target.Field(i).Addr().Interface()); err != nil {
+				errs.Add(name, typeMessage(err))
+			}
+		}
+	}
+	return errs
+}
+
+// jsonFields maps the JSON names of the fields of struct type t to their
+// index.
+func jsonFields(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}
+
+func typeMessage(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		switch typeErr.Type.Kind() {
+		case reflect.String:
+			return "must be a string"
+		case reflect.Bool:
+			return "must be true or false"
+		case reflect.Int, reflect.Int64, reflect.Float64:
+			return "must be a number"
+		case reflect.Slice:
+			return "must be an array"
+		}
+	}
+	var parseErr *time.ParseError
+	if errors.As(err, &parseErr) {
+		return "must be an RFC 3339 date-time"
+	}
+	return "has an invalid value"
+}
@@ -0,0 +1,281 @@
+// Package ical reads and writes the subset of iCalendar (RFC 5545) needed to
+// exchange tasks with calendar applications.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405"
+	// lineLimit is the maximum length of a content line in octets, not
+	// counting the line break.
+	lineLimit = 75
+)
+
+// Property is a single content line such as DUE;TZID=Europe/Moscow:20240607T150000.
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Component is a BEGIN/END block with its properties and nested components.
+type Component struct {
+	Name       string
+	Line       int
+	Properties []Property
+	Components []*Component
+}
+
+// Prop returns the first property with the given name.
+func (c *Component) Prop(name string) (Property, bool) {
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Property{}, false
+}
+
+// Writer writes content lines folded at 75 octets and terminated by CRLF.
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+func (w *Writer) Begin(name string) { w.line("BEGIN:" + name) }
+
+func (w *Writer) End(name string) { w.line("END:" + name) }
+
+// Text writes a property with an escaped TEXT value.
+func (w *Writer) Text(name, value string) { w.line(name + ":" + EscapeText(value)) }
+
+// Raw writes a property whose value is already in iCalendar form.
+func (w *Writer) Raw(name, value string) { w.line(name + ":" + value) }
+
+// Time writes a DATE-TIME value in UTC.
+func (w *Writer) Time(name string, t time.Time) {
+	w.line(name + ":" + t.UTC().Format(dateTimeLayout) + "Z")
+}
+
+// Date writes a DATE value with the calendar day of t.
+func (w *Writer) Date(name string, t time.Time) {
+	w.line(name + ";VALUE=DATE:" + t.Format(dateLayout))
+}
+
+// Flush writes any buffered data and returns the first error encountered.
+func (w *Writer) Flush() error {
+	if w.err == nil {
+		w.err = w.w.Flush()
+	}
+	return w.err
+}
+
+func (w *Writer) line(s string) {
+	if w.err != nil {
+		return
+	}
+	// Continuation lines start with a space, which counts towards the
+	// limit. Never split a UTF-8 sequence.
+	limit := lineLimit
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		if _, w.err = w.w.WriteString(s[:cut] + "\r\n "); w.err != nil {
+			return
+		}
+		s = s[cut:]
+		limit = lineLimit - 1
+	}
+	_, w.err = w.w.WriteString(s + "\r\n")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func EscapeText(s string) string { return textEscaper.Replace(s) }
+
+func UnescapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// Parse reads an iCalendar stream and returns its top-level component,
+// usually VCALENDAR.
+func Parse(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var root *Component
+	var stack []*Component
+	for _, l := range lines {
+		if l.text == "" {
+			continue
+		}
+		prop, err := parseLine(l.text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", l.number, err)
+		}
+
+		switch prop.Name {
+		case "BEGIN":
+			c := &Component{Name: strings.ToUpper(prop.Value), Line: l.number}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, c)
+			} else if root != nil {
+				return nil, fmt.Errorf("line %d: more than one top-level component", l.number)
+			} else {
+				root = c
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", l.number, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property outside of a component", l.number)
+			}
+			c := stack[len(stack)-1]
+			c.Properties = append(c.Properties, prop)
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("no calendar data")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+type contentLine struct {
+	number int
+	text   string
+}
+
+// unfold joins continuation lines, keeping the number of the line each
+// logical line started on.
+func unfold(r io.Reader) ([]contentLine, error) {
+	var lines []contentLine
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if n == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if len(lines) > 0 && text != "" && (text[0] == ' ' || text[0] == '\t') {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		lines = append(lines, contentLine{number: n, text: text})
+	}
+	return lines, scanner.Err()
+}
+
+func parseLine(line string) (Property, error) {
+	// The value starts at the first colon outside of a quoted parameter.
+	inQuotes := false
+	sep := -1
+	for i := 0; i < len(line) && sep < 0; i++ {
+		switch line[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case ':':
+			if !inQuotes {
+				sep = i
+			}
+		}
+	}
+	if sep < 0 {
+		return Property{}, fmt.Errorf("invalid content line %q", line)
+	}
+
+	parts := splitParams(line[:sep])
+	prop := Property{Name: strings.ToUpper(parts[0]), Value: line[sep+1:]}
+	for _, param := range parts[1:] {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return Property{}, fmt.Errorf("invalid parameter %q", param)
+		}
+		if prop.Params == nil {
+			prop.Params = make(map[string]string)
+		}
+		prop.Params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+	return prop, nil
+}
+
+func splitParams(s string) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case ';':
+			if !inQuotes {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// IsDate reports whether the property holds a DATE rather than a DATE-TIME.
+func (p Property) IsDate() bool {
+	return p.Params["VALUE"] == "DATE" || len(p.Value) == len(dateLayout)
+}
+
+// Time parses a DATE or DATE-TIME property. Floating times and dates are
+// interpreted in UTC, times with a TZID in that time zone.
+func (p Property) Time() (time.Time, error) {
+	if p.IsDate() {
+		return time.Parse(dateLayout, p.Value)
+	}
+	if v, ok := strings.CutSuffix(p.Value, "Z"); ok {
+		return time.Parse(dateTimeLayout, v)
+	}
+
+	loc := time.UTC
+	if tzid := p.Params["TZID"]; tzid != "" {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, fmt.Errorf("unknown time zone %q", tzid)
+		}
+	}
+	return time.ParseInLocation(dateTimeLayout, p.Value, loc)
+}
@@ -0,0 +1,140 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"SUMMARY:Buy milk\\, eggs\r\n" +
+		"DESCRIPTION:first line\\nsecond \r\n" +
+		" line\r\n" +
+		"DUE;TZID=\"Europe/Moscow\":20240607T150000\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	cal, err := Parse(strings.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "VCALENDAR", cal.Name)
+	assert.Len(t, cal.Components, 1)
+
+	todo := cal.Components[0]
+	assert.Equal(t, 3, todo.Line)
+	summary, _ := todo.Prop("SUMMARY")
+	assert.Equal(t, "Buy milk, eggs", UnescapeText(summary.Value))
+	description, _ := todo.Prop("DESCRIPTION")
+	assert.Equal(t, "first line\nsecond line", UnescapeText(description.Value))
+
+	due, ok := todo.Prop("DUE")
+	assert.True(t, ok)
+	assert.Equal(t, "Europe/Moscow", due.Params["TZID"])
+	dueTime, err := due.Time()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 6, 7, 12, 0, 0, 0, time.UTC), dueTime.UTC())
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"missing end":        "BEGIN:VCALENDAR\nBEGIN:VTODO\nEND:VCALENDAR\n",
+		"orphan property":    "SUMMARY:x\n",
+		"empty":              "",
+		"invalid line":       "BEGIN:VCALENDAR\nnot a property\nEND:VCALENDAR\n",
+		"two top components": "BEGIN:VCALENDAR\nEND:VCALENDAR\nBEGIN:VCALENDAR\nEND:VCALENDAR\n",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPropertyTime(t *testing.T) {
+	tests := []struct {
+		prop     Property
+		expected time.Time
+	}{
+		{Property{Value: "20240607T150000Z"}, time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)},
+		{Property{Value: "20240607T150000"}, time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)},
+		{Property{Value: "20240607", Params: map[string]string{"VALUE": "DATE"}}, time.Date(2024, 6, 7, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := tt.prop.Time()
+		assert.NoError(t, err)
+		assert.True(t, tt.expected.Equal(got), "%s: got %s", tt.prop.Value, got)
+	}
+
+	_, err := Property{Value: "20240607T150000", Params: map[string]string{"TZID": "Nowhere/City"}}.Time()
+	assert.EqualError(t, err, `unknown time zone "Nowhere/City"`)
+}
+
+func TestWriterFoldsLongLines(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.Text("SUMMARY", strings.Repeat("я", 100)+"; done")
+	assert.NoError(t, w.Flush())
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	assert.Greater(t, len(lines), 1)
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), lineLimit)
+	}
+
+	cal, err := Parse(strings.NewReader("BEGIN:VCALENDAR\r\n" + buf.String() + "END:VCALENDAR\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("я", 100)+"; done", UnescapeText(cal.Properties[0].Value))
+}
+
+func TestRecurrenceOccurrences(t *testing.T) {
+	start := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC) // a Wednesday
+	horizon := start.AddDate(1, 0, 0)
+	date := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 9, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		rule     string
+		expected []time.Time
+	}{
+		{"FREQ=DAILY;INTERVAL=2;COUNT=3", []time.Time{date(1, 31), date(2, 2), date(2, 4)}},
+		{"FREQ=WEEKLY;UNTIL=20240214T090000Z", []time.Time{date(1, 31), date(2, 7), date(2, 14)}},
+		{"FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=4", []time.Time{date(1, 31), date(2, 2), date(2, 5), date(2, 7)}},
+		// Months without a 31st are skipped.
+		{"FREQ=MONTHLY;COUNT=3", []time.Time{date(1, 31), date(3, 31), date(5, 31)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := ParseRecurrence(tt.rule)
+			assert.NoError(t, err)
+			got, err := rule.Occurrences(start, horizon, 100)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	rule, _ := ParseRecurrence("FREQ=DAILY")
+	got, err := rule.Occurrences(start, horizon, 400)
+	assert.NoError(t, err)
+	assert.Len(t, got, 367)
+	_, err = rule.Occurrences(start, horizon, 100)
+	assert.EqualError(t, err, "recurrence yields more than 100 occurrences")
+}
+
+func TestParseRecurrenceErrors(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=MONTHLY;BYMONTHDAY=1",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20240101",
+	} {
+		_, err := ParseRecurrence(rule)
+		assert.Error(t, err, rule)
+	}
+}
@@ -0,0 +1,153 @@
+package ical
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence is a parsed RRULE. Only FREQ, INTERVAL, COUNT, UNTIL and, for
+// weekly rules, BYDAY without ordinals are supported.
+type Recurrence struct {
+	Freq     string
+	Interval int
+	Count    int
+	Until    time.Time
+	ByDay    []time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+func ParseRecurrence(value string) (Recurrence, error) {
+	r := Recurrence{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		name, v, ok := strings.Cut(part, "=")
+		if !ok {
+			return r, fmt.Errorf("invalid RRULE part %q", part)
+		}
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			r.Freq = strings.ToUpper(v)
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(v)
+			if err == nil && r.Interval < 1 {
+				err = fmt.Errorf("INTERVAL must be positive")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(v)
+			if err == nil && r.Count < 1 {
+				err = fmt.Errorf("COUNT must be positive")
+			}
+		case "UNTIL":
+			r.Until, err = Property{Value: v}.Time()
+		case "BYDAY":
+			for _, day := range strings.Split(v, ",") {
+				wd, ok := weekdays[strings.ToUpper(day)]
+				if !ok {
+					return r, fmt.Errorf("unsupported BYDAY value %q", day)
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "WKST":
+		default:
+			return r, fmt.Errorf("unsupported RRULE part %s", name)
+		}
+		if err != nil {
+			return r, fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+
+	switch r.Freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	case "":
+		return r, fmt.Errorf("missing FREQ")
+	default:
+		return r, fmt.Errorf("unsupported FREQ %s", r.Freq)
+	}
+	if len(r.ByDay) > 0 && r.Freq != "WEEKLY" {
+		return r, fmt.Errorf("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return r, fmt.Errorf("COUNT and UNTIL cannot be combined")
+	}
+	return r, nil
+}
+
+// Occurrences expands the rule starting at start. Rules without COUNT or
+// UNTIL are expanded up to horizon. It fails if the rule yields more than
+// max occurrences.
+func (r Recurrence) Occurrences(start, horizon time.Time, max int) ([]time.Time, error) {
+	end := horizon
+	if !r.Until.IsZero() {
+		end = r.Until
+	}
+
+	var out []time.Time
+	add := func(t time.Time) (bool, error) {
+		if t.Before(start) {
+			return true, nil
+		}
+		if r.Count > 0 && len(out) == r.Count || r.Count == 0 && t.After(end) {
+			return false, nil
+		}
+		if len(out) == max {
+			return false, fmt.Errorf("recurrence yields more than %d occurrences", max)
+		}
+		out = append(out, t)
+		return true, nil
+	}
+
+	y, m, d := start.Date()
+	hh, mm, ss := start.Clock()
+	for k := 0; ; k++ {
+		n := k * r.Interval
+		var candidates []time.Time
+		switch r.Freq {
+		case "DAILY":
+			candidates = []time.Time{start.AddDate(0, 0, n)}
+		case "WEEKLY":
+			if len(r.ByDay) == 0 {
+				candidates = []time.Time{start.AddDate(0, 0, 7*n)}
+				break
+			}
+			// Weeks start on Monday.
+			monday := start.AddDate(0, 0, 7*n-(int(start.Weekday())+6)%7)
+			for _, wd := range r.ByDay {
+				candidates = append(candidates, monday.AddDate(0, 0, (int(wd)+6)%7))
+			}
+			slices.SortFunc(candidates, time.Time.Compare)
+		case "MONTHLY":
+			// Months without this day, such as the 31st, are skipped.
+			t := time.Date(y, m+time.Month(n), d, hh, mm, ss, 0, start.Location())
+			if t.Day() == d {
+				candidates = []time.Time{t}
+			}
+		case "YEARLY":
+			t := time.Date(y+n, m, d, hh, mm, ss, 0, start.Location())
+			if t.Day() == d {
+				candidates = []time.Time{t}
+			}
+		}
+
+		for _, t := range candidates {
+			more, err := add(t)
+			if err != nil {
+				return nil, err
+			}
+			if !more {
+				return out, nil
+			}
+		}
+		// Guards against rules that never produce a date, like
+		// FREQ=YEARLY starting on February 29 with a large INTERVAL.
+		if k > 100*max {
+			return out, nil
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(&buf, FormatJSON, "info")
+	assert.NoError(t, err)
+	defaultLogger := slog.Default()
+	slog.SetDefault(l)
+	defer slog.SetDefault(defaultLogger)
+
+	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+	router.Use(Middleware)
+	router.Get("/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		AddAttrs(r.Context(), slog.String("task_id", chi.URLParam(r, "id")))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest("GET", "/tasks/7", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, "req-1", rr.Header().Get(middleware.RequestIDHeader))
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "request completed", entry["msg"])
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Equal(t, "/tasks/{id}", entry["route"])
+	assert.Equal(t, "7", entry["task_id"])
+	assert.Equal(t, float64(http.StatusNoContent), entry["status"])
+}
+
+func TestNewInvalidLevel(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, FormatText, "verbose")
+	assert.Error(t, err)
+}
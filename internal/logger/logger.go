@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sberTestTask/internal/tracing"
+	"strings"
+	"sync"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New builds the service logger. Records logged with a context carry the
+// trace and span ids of the active span.
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		h = slog.NewJSONHandler(w, opts)
+	case FormatText, "":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+	return slog.New(tracing.NewLogHandler(h)), nil
+}
+
+type ctxKey struct{}
+
+// holder lets middlewares further down the chain enrich the request logger,
+// so that fields such as task_id also end up in the access log line.
+type holder struct {
+	mu     sync.RWMutex
+	logger *slog.Logger
+}
+
+// WithContext returns a copy of ctx carrying l.
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &holder{logger: l})
+}
+
+// FromContext returns the logger carried by ctx or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if h, ok := ctx.Value(ctxKey{}).(*holder); ok {
+		h.mu.RLock()
+		defer h.mu.RUnlock()
+		return h.logger
+	}
+	return slog.Default()
+}
+
+// AddAttrs adds attrs to the logger carried by ctx. It is a no-op when ctx
+// has no logger.
+func AddAttrs(ctx context.Context, attrs ...any) {
+	if h, ok := ctx.Value(ctxKey{}).(*holder); ok {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.logger = h.logger.With(attrs...)
+	}
+}
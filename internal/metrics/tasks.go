@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"log/slog"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/repository"
+	"time"
+)
+
+const collectTimeout = 2 * time.Second
+
+// taskCollector exposes business gauges computed from the repository on each scrape.
+type taskCollector struct {
+	repo    repository.TodoRepository
+	open    *prometheus.Desc
+	overdue *prometheus.Desc
+}
+
+func NewTaskCollector(repo repository.TodoRepository) prometheus.Collector {
+	return &taskCollector{
+		repo:    repo,
+		open:    prometheus.NewDesc("todo_tasks_open", "Number of tasks that are not completed.", nil, nil),
+		overdue: prometheus.NewDesc("todo_tasks_overdue", "Number of open tasks past their due date.", nil, nil),
+	}
+}
+
+func (c *taskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.open
+	ch <- c.overdue
+}
+
+func (c *taskCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	completed := false
+	if open, err := c.repo.CountTasks(ctx, todo.TaskFilter{Completed: &completed}); err != nil {
+		slog.Error("collect open tasks", slog.String("error", err.Error()))
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(open))
+	}
+
+	if overdue, err := c.repo.CountOverdueTasks(ctx, time.Now()); err != nil {
+		slog.Error("collect overdue tasks", slog.String("error", err.Error()))
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.overdue, prometheus.GaugeValue, float64(overdue))
+	}
+}
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS task_reminders (
+    id              BIGSERIAL PRIMARY KEY,
+    task_id         INT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    offset_seconds  INT NOT NULL CHECK (offset_seconds >= 0),
+    channel         TEXT NOT NULL,
+    target          TEXT NOT NULL DEFAULT '',
+    remind_at       TIMESTAMPTZ NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending',
+    attempts        INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_error      TEXT,
+    sent_at         TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (task_id, offset_seconds, channel, target)
+);
+
+CREATE INDEX IF NOT EXISTS task_reminders_due_idx ON task_reminders (next_attempt_at) WHERE status = 'pending';
+
+-- Moving a task's due date reschedules its reminders, including those
+-- already sent for the old date. due_date holds UTC wall time.
+CREATE OR REPLACE FUNCTION reschedule_task_reminders() RETURNS trigger AS $$
+BEGIN
+    UPDATE task_reminders
+    SET remind_at = (NEW.due_date AT TIME ZONE 'UTC') - make_interval(secs => offset_seconds),
+        next_attempt_at = (NEW.due_date AT TIME ZONE 'UTC') - make_interval(secs => offset_seconds),
+        status = 'pending', attempts = 0, last_error = NULL, sent_at = NULL
+    WHERE task_id = NEW.id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_reschedule_reminders
+    AFTER UPDATE OF due_date ON tasks
+    FOR EACH ROW WHEN (OLD.due_date IS DISTINCT FROM NEW.due_date)
+    EXECUTE FUNCTION reschedule_task_reminders();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER tasks_reschedule_reminders ON tasks;
+DROP FUNCTION reschedule_task_reminders();
+DROP TABLE task_reminders;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Existing due dates were written as UTC wall time. The reminder trigger
+-- depends on the column and is recreated around the type change.
+DROP TRIGGER tasks_reschedule_reminders ON tasks;
+
+ALTER TABLE tasks ALTER COLUMN due_date TYPE TIMESTAMPTZ USING due_date AT TIME ZONE 'UTC';
+ALTER TABLE tasks ADD COLUMN all_day BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE OR REPLACE FUNCTION reschedule_task_reminders() RETURNS trigger AS $$
+BEGIN
+    UPDATE task_reminders
+    SET remind_at = NEW.due_date - make_interval(secs => offset_seconds),
+        next_attempt_at = NEW.due_date - make_interval(secs => offset_seconds),
+        status = 'pending', attempts = 0, last_error = NULL, sent_at = NULL
+    WHERE task_id = NEW.id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_reschedule_reminders
+    AFTER UPDATE OF due_date ON tasks
+    FOR EACH ROW WHEN (OLD.due_date IS DISTINCT FROM NEW.due_date)
+    EXECUTE FUNCTION reschedule_task_reminders();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER tasks_reschedule_reminders ON tasks;
+
+ALTER TABLE tasks DROP COLUMN all_day;
+ALTER TABLE tasks ALTER COLUMN due_date TYPE TIMESTAMP USING due_date AT TIME ZONE 'UTC';
+
+CREATE OR REPLACE FUNCTION reschedule_task_reminders() RETURNS trigger AS $$
+BEGIN
+    UPDATE task_reminders
+    SET remind_at = (NEW.due_date AT TIME ZONE 'UTC') - make_interval(secs => offset_seconds),
+        next_attempt_at = (NEW.due_date AT TIME ZONE 'UTC') - make_interval(secs => offset_seconds),
+        status = 'pending', attempts = 0, last_error = NULL, sent_at = NULL
+    WHERE task_id = NEW.id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_reschedule_reminders
+    AFTER UPDATE OF due_date ON tasks
+    FOR EACH ROW WHEN (OLD.due_date IS DISTINCT FROM NEW.due_date)
+    EXECUTE FUNCTION reschedule_task_reminders();
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tasks ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE tasks ADD COLUMN completed_at TIMESTAMPTZ;
+
+-- Take what history the outbox still holds; other tasks count as created
+-- now and, if completed, are left out of the time-to-complete average.
+UPDATE tasks t SET created_at = o.at
+FROM (SELECT (task->>'id')::int AS id, MIN(created_at) AS at FROM task_outbox WHERE event = 'created' GROUP BY 1) o
+WHERE o.id = t.id;
+
+UPDATE tasks t SET completed_at = o.at
+FROM (SELECT (task->>'id')::int AS id, MAX(created_at) AS at FROM task_outbox WHERE event = 'completed' GROUP BY 1) o
+WHERE o.id = t.id AND t.completed;
+
+-- completed_at follows the completed flag on every write path, including
+-- COPY and the restore upsert.
+CREATE OR REPLACE FUNCTION set_task_completed_at() RETURNS trigger AS $$
+BEGIN
+    IF NOT coalesce(NEW.completed, FALSE) THEN
+        NEW.completed_at = NULL;
+    ELSIF TG_OP = 'INSERT' OR NOT coalesce(OLD.completed, FALSE) THEN
+        NEW.completed_at = now();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_set_completed_at
+    BEFORE INSERT OR UPDATE OF completed ON tasks
+    FOR EACH ROW EXECUTE FUNCTION set_task_completed_at();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER tasks_set_completed_at ON tasks;
+DROP FUNCTION set_task_completed_at();
+ALTER TABLE tasks DROP COLUMN completed_at;
+ALTER TABLE tasks DROP COLUMN created_at;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tasks ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+UPDATE tasks SET updated_at = GREATEST(created_at, coalesce(completed_at, created_at));
+
+-- updated_at backs Last-Modified, so every write path bumps it, including
+-- the restore upsert.
+CREATE OR REPLACE FUNCTION set_task_updated_at() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at = now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_set_updated_at
+    BEFORE UPDATE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION set_task_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER tasks_set_updated_at ON tasks;
+DROP FUNCTION set_task_updated_at();
+ALTER TABLE tasks DROP COLUMN updated_at;
+-- +goose StatementEnd
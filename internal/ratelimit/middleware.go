@@ -0,0 +1,166 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"sberTestTask/internal/auth"
+	"sberTestTask/internal/httpapi"
+	"sberTestTask/internal/logger"
+	"sberTestTask/internal/routing"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KeyFunc identifies the client a call is accounted to from its context,
+// which carries the authenticated principal, and its remote address.
+type KeyFunc func(ctx context.Context, remoteAddr string) string
+
+const (
+	KeyByAPIKey = "api_key"
+	KeyByUser   = "user"
+	KeyByIP     = "ip"
+)
+
+// NewKeyFunc returns a KeyFunc for the given strategy. Calls without an
+// authenticated principal always fall back to the client IP.
+func NewKeyFunc(keyBy string) (KeyFunc, error) {
+	switch keyBy {
+	case KeyByAPIKey, "":
+		return func(ctx context.Context, remoteAddr string) string {
+			p, ok := auth.FromContext(ctx)
+			switch {
+			case ok && p.APIKey != "":
+				return "key:" + p.APIKey
+			case ok:
+				// Feed tokens identify a user but carry no API key.
+				return "user:" + p.User
+			}
+			return "ip:" + clientIP(remoteAddr)
+		}, nil
+	case KeyByUser:
+		return func(ctx context.Context, remoteAddr string) string {
+			if p, ok := auth.FromContext(ctx); ok {
+				return "user:" + p.User
+			}
+			return "ip:" + clientIP(remoteAddr)
+		}, nil
+	case KeyByIP:
+		return func(_ context.Context, remoteAddr string) string { return "ip:" + clientIP(remoteAddr) }, nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit key %q", keyBy)
+	}
+}
+
+func clientIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// Route overrides the default limit for one chi route pattern.
+type Route struct {
+	Method  string
+	Pattern string
+	Limit   Limit
+}
+
+type Limiter struct {
+	store        Store
+	defaultLimit Limit
+	routes       map[string]Limit
+	key          KeyFunc
+}
+
+func NewLimiter(store Store, defaultLimit Limit, routes []Route, key KeyFunc) *Limiter {
+	l := &Limiter{store: store, defaultLimit: defaultLimit, routes: make(map[string]Limit, len(routes)), key: key}
+	for _, route := range routes {
+		l.routes[routeKey(route.Method, route.Pattern)] = route.Limit
+	}
+	return l
+}
+
+func routeKey(method, pattern string) string {
+	return strings.ToUpper(method) + " " + pattern
+}
+
+// limitFor resolves the chi route pattern the request will be served by and
+// returns its limit and bucket scope.
+func (l *Limiter) limitFor(r *http.Request) (Limit, string) {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.Routes != nil && len(l.routes) > 0 {
+		tctx := chi.NewRouteContext()
+		if rctx.Routes.Match(tctx, r.Method, r.URL.Path) {
+			return l.routeLimit(r.Method, tctx.RoutePattern())
+		}
+	}
+	return l.defaultLimit, "default"
+}
+
+func (l *Limiter) routeLimit(method, pattern string) (Limit, string) {
+	// Patterns without a version apply to every API version and share one
+	// bucket, so that older versions are no way around.
+	for _, key := range []string{routeKey(method, pattern), routeKey(method, routing.Unversioned(pattern))} {
+		if limit, ok := l.routes[key]; ok {
+			return limit, key
+		}
+	}
+	return l.defaultLimit, "default"
+}
+
+// Take accounts one call to the route method and pattern, such as GRPC and
+// a full RPC method name, for callers outside net/http. Calls without a
+// route rule share the default bucket with HTTP requests of the client.
+// The result is allowed when the route is unlimited.
+func (l *Limiter) Take(ctx context.Context, remoteAddr, method, pattern string) (Result, error) {
+	limit, scope := l.routeLimit(method, pattern)
+	return l.take(ctx, l.key(ctx, remoteAddr), limit, scope)
+}
+
+func (l *Limiter) take(ctx context.Context, client string, limit Limit, scope string) (Result, error) {
+	if limit.Rate <= 0 || limit.Burst <= 0 {
+		return Result{Allowed: true}, nil
+	}
+	return l.store.Take(ctx, client+"|"+scope, limit)
+}
+
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, scope := l.limitFor(r)
+		if limit.Rate <= 0 || limit.Burst <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		res, err := l.take(r.Context(), l.key(r.Context(), r.RemoteAddr), limit, scope)
+		if err != nil {
+			// Fail open: an unavailable store must not take the API down.
+			logger.FromContext(r.Context()).ErrorContext(r.Context(), "rate limit store", slog.String("error", err.Error()))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(CeilSeconds(res.Reset)))
+		if !res.Allowed {
+			retryAfter := CeilSeconds(res.RetryAfter)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			httpapi.WriteProblem(w, http.StatusTooManyRequests,
+				fmt.Sprintf("rate limit exceeded, retry in %d seconds", retryAfter), nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CeilSeconds rounds d up to whole seconds, as Retry-After expects.
+func CeilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRouter(store Store) *chi.Mux {
+	key, _ := NewKeyFunc(KeyByIP)
+	limiter := NewLimiter(store, Limit{Rate: 10, Burst: 10}, []Route{
+		{Method: "post", Pattern: "/tasks", Limit: Limit{Rate: 1, Burst: 2}},
+	}, key)
+
+	router := chi.NewRouter()
+	router.Use(limiter.Middleware)
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router.Post("/tasks", ok)
+	router.Get("/tasks", ok)
+	router.Route("/v1", func(r chi.Router) {
+		r.Post("/tasks", ok)
+	})
+	return router
+}
+
+func TestMiddleware(t *testing.T) {
+	now := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	router := setupRouter(store)
+
+	do := func(method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/tasks", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do("POST")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rr.Header().Get("RateLimit-Remaining"))
+
+	assert.Equal(t, http.StatusOK, do("POST").Code)
+
+	rr = do("POST")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), `"status":429`)
+
+	// Other routes use the default bucket.
+	rr = do("GET")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "10", rr.Header().Get("RateLimit-Limit"))
+
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusOK, do("POST").Code)
+}
+
+func TestMiddlewareVersionedRoute(t *testing.T) {
+	store := NewMemoryStore()
+	store.now = func() time.Time { return time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC) }
+	router := setupRouter(store)
+
+	// /v1/tasks shares the bucket of the unversioned /tasks rule.
+	codes := make([]int, 0, 3)
+	for _, path := range []string{"/v1/tasks", "/tasks", "/v1/tasks"} {
+		req := httptest.NewRequest("POST", path, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		codes = append(codes, rr.Code)
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+}
+
+func TestMemoryStoreCleanup(t *testing.T) {
+	now := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	_, err := store.Take(context.Background(), "ip:10.0.0.1", Limit{Rate: 1, Burst: 2})
+	assert.NoError(t, err)
+	store.cleanup()
+	assert.Len(t, store.buckets, 1)
+
+	now = now.Add(2 * time.Second)
+	store.cleanup()
+	assert.Len(t, store.buckets, 0)
+}
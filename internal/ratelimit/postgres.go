@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so that all
+// replicas share the same limits.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	defer tx.Rollback()
+
+	// Buckets are refilled by the database clock, which all replicas share;
+	// now() is the same for every statement of the transaction.
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO rate_limit_buckets (key, tokens, updated_at, full_at) VALUES ($1, $2, now(), now()) ON CONFLICT (key) DO NOTHING",
+		key, float64(limit.Burst))
+	if err != nil {
+		return Result{}, err
+	}
+
+	var b bucket
+	var now time.Time
+	err = tx.QueryRowContext(ctx, "SELECT tokens, updated_at, now() FROM rate_limit_buckets WHERE key = $1 FOR UPDATE", key).Scan(&b.tokens, &b.updated, &now)
+	if err != nil {
+		return Result{}, err
+	}
+
+	res := b.take(limit, now)
+	_, err = tx.ExecContext(ctx, "UPDATE rate_limit_buckets SET tokens = $1, updated_at = $2, full_at = $3 WHERE key = $4",
+		b.tokens, b.updated, now.Add(res.Reset), key)
+	if err != nil {
+		return Result{}, err
+	}
+	return res, tx.Commit()
+}
+
+// Run periodically deletes buckets that have refilled completely, so the
+// table does not keep every client ever seen. Any replica may run it.
+func (s *PostgresStore) Run(ctx context.Context) error {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := s.cleanup(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("rate limit cleanup", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+func (s *PostgresStore) cleanup(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM rate_limit_buckets WHERE full_at <= now()")
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
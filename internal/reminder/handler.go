@@ -0,0 +1,174 @@
+package reminder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"sberTestTask/internal/httpapi"
+	"sberTestTask/internal/logger"
+	"sberTestTask/internal/validation"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxOffset keeps reminders within a year before the due date.
+const maxOffset = 366 * 24 * time.Hour
+
+type Handler struct {
+	store    *Store
+	channels []string
+	targets  TargetPolicy
+}
+
+// NewHandler returns the /tasks/{id}/reminders routes. channels are the
+// names of the configured notifiers; targets limits reminder targets.
+func NewHandler(store *Store, channels []string, targets TargetPolicy) http.Handler {
+	h := &Handler{store: store, channels: channels, targets: targets}
+	r := chi.NewRouter()
+	r.Post("/", h.CreateReminder)
+	r.Get("/", h.ListReminders)
+	r.Delete("/{reminderID}", h.DeleteReminder)
+	return r
+}
+
+// @Summary Add a reminder
+// @Description Notify through a channel at an offset before the task's due date. Moving the due date reschedules the reminder.
+// @Tags reminders
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Task ID"
+// @Param reminder body reminder.Reminder true "offset (e.g. 1h), channel and optional target from the configured allowlist"
+// @Success 201 {object} reminder.Reminder "Reminder created"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 404 {object} httpapi.Problem "Not Found"
+// @Failure 409 {object} httpapi.Problem "Conflict"
+// @Failure 413 {object} httpapi.Problem "Request body too large"
+// @Failure 422 {object} httpapi.Problem "Invalid reminder, with the invalid fields in errors"
+// @Failure 500 {object} httpapi.Problem "Internal Server Error"
+// @Router /tasks/{id}/reminders [post]
+func (h *Handler) CreateReminder(w http.ResponseWriter, r *http.Request) {
+	taskID, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+	var rem Reminder
+	errs, ok := httpapi.Decode(w, r, &rem, httpapi.DefaultMaxBodySize,
+		"id", "task_id", "remind_at", "status", "attempts", "last_error", "sent_at", "created_at")
+	if !ok {
+		return
+	}
+	rem.TaskID = taskID
+	if err := h.validate(&rem, errs); err != nil {
+		httpapi.WriteInvalid(w, err)
+		return
+	}
+
+	if err := h.store.Create(r.Context(), &rem); err != nil {
+		switch {
+		case errors.Is(err, ErrTaskNotFound):
+			httpapi.WriteProblem(w, http.StatusNotFound, err.Error(), nil)
+		case errors.Is(err, ErrExists):
+			httpapi.WriteProblem(w, http.StatusConflict, err.Error(), nil)
+		default:
+			h.serverError(w, r, "create reminder", err)
+		}
+		return
+	}
+	logger.AddAttrs(r.Context(), slog.Int64("reminder_id", rem.ID))
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rem)
+}
+
+// @Summary List reminders of a task
+// @Tags reminders
+// @Produce  json
+// @Param id path int true "Task ID"
+// @Success 200 {array} reminder.Reminder "Reminders with their delivery status"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 500 {object} httpapi.Problem "Internal Server Error"
+// @Router /tasks/{id}/reminders [get]
+func (h *Handler) ListReminders(w http.ResponseWriter, r *http.Request) {
+	taskID, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+	reminders, err := h.store.List(r.Context(), taskID)
+	if err != nil {
+		h.serverError(w, r, "list reminders", err)
+		return
+	}
+	json.NewEncoder(w).Encode(reminders)
+}
+
+// @Summary Delete a reminder
+// @Tags reminders
+// @Param id path int true "Task ID"
+// @Param reminderID path int true "Reminder ID"
+// @Success 204 "Reminder deleted"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 404 {object} httpapi.Problem "Not Found"
+// @Router /tasks/{id}/reminders/{reminderID} [delete]
+func (h *Handler) DeleteReminder(w http.ResponseWriter, r *http.Request) {
+	taskID, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "reminderID"), 10, 64)
+	if err != nil {
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	logger.AddAttrs(r.Context(), slog.Int64("reminder_id", id))
+	if err := h.store.Delete(r.Context(), taskID, id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			httpapi.WriteProblem(w, http.StatusNotFound, err.Error(), nil)
+			return
+		}
+		h.serverError(w, r, "delete reminder", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func taskID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
+		return 0, false
+	}
+	logger.AddAttrs(r.Context(), slog.Int("task_id", id))
+	return id, true
+}
+
+func (h *Handler) serverError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	logger.FromContext(r.Context()).ErrorContext(r.Context(), msg, slog.String("error", err.Error()))
+	httpapi.WriteProblem(w, http.StatusInternalServerError, "error on server", nil)
+}
+
+// validate adds the rule violations of rem to errs, the fields that could
+// not be decoded, and returns them as a validation.Errors.
+func (h *Handler) validate(rem *Reminder, errs validation.Errors) error {
+	add := func(field, message string) {
+		if !slices.ContainsFunc(errs, func(e validation.FieldError) bool { return e.Field == field }) {
+			errs.Add(field, message)
+		}
+	}
+	d := time.Duration(rem.Offset)
+	if d < 0 || d > maxOffset {
+		add("offset", fmt.Sprintf("must be between 0 and %s", Offset(maxOffset)))
+	} else if d%time.Second != 0 {
+		add("offset", "must be a whole number of seconds")
+	}
+	if !slices.Contains(h.channels, rem.Channel) {
+		add("channel", "must be one of: "+strings.Join(h.channels, ", "))
+	} else if err := h.targets.Validate(rem.Channel, rem.Target); err != nil {
+		add("target", strings.TrimPrefix(err.Error(), "target "))
+	}
+	return errs.Err()
+}
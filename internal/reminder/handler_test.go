@@ -0,0 +1,66 @@
+package reminder
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sberTestTask/internal/httpapi"
+	"sberTestTask/internal/validation"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateReminderInvalid(t *testing.T) {
+	// Invalid requests are answered before the store is used.
+	router := chi.NewRouter()
+	router.Mount("/tasks/{id}/reminders", NewHandler(nil, []string{ChannelLog, ChannelEmail}, TargetPolicy{EmailDomains: []string{"example.com"}}))
+
+	tests := []struct {
+		name           string
+		url            string
+		body           string
+		expectedStatus int
+		expectedErrors validation.Errors
+	}{
+		{"invalid task id", "/tasks/abc/reminders", `{"offset":"1h","channel":"log"}`, http.StatusBadRequest, nil},
+		{"malformed JSON", "/tasks/1/reminders", `{"offset":`, http.StatusBadRequest, nil},
+		{"body too large", "/tasks/1/reminders", `{"target":"` + strings.Repeat("a", httpapi.DefaultMaxBodySize) + `"}`, http.StatusRequestEntityTooLarge, nil},
+		{"server fields", "/tasks/1/reminders", `{"offset":"1h","channel":"log","status":"sent","task_id":2}`, http.StatusUnprocessableEntity, validation.Errors{
+			{Field: "status", Message: "is set by the server"},
+			{Field: "task_id", Message: "is set by the server"},
+		}},
+		{"all violations at once", "/tasks/1/reminders", `{"offset":"1.5s","channel":"sms","priority":1}`, http.StatusUnprocessableEntity, validation.Errors{
+			{Field: "priority", Message: "is not a known field"},
+			{Field: "offset", Message: "must be a whole number of seconds"},
+			{Field: "channel", Message: "must be one of: log, email"},
+		}},
+		{"offset not a duration", "/tasks/1/reminders", `{"offset":"soon","channel":"log"}`, http.StatusUnprocessableEntity, validation.Errors{
+			{Field: "offset", Message: "has an invalid value"},
+		}},
+		{"offset too long", "/tasks/1/reminders", `{"offset":"9000h","channel":"log"}`, http.StatusUnprocessableEntity, validation.Errors{
+			{Field: "offset", Message: "must be between 0 and 8784h"},
+		}},
+		{"target not allowed", "/tasks/1/reminders", `{"offset":"1h","channel":"email","target":"bob@example.org"}`, http.StatusUnprocessableEntity, validation.Errors{
+			{Field: "target", Message: "domain example.org is not allowed"},
+		}},
+		{"target for the log channel", "/tasks/1/reminders", `{"offset":"1h","channel":"log","target":"bob@example.com"}`, http.StatusUnprocessableEntity, validation.Errors{
+			{Field: "target", Message: "is not used by the log channel"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("POST", tt.url, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+			var problem httpapi.Problem
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+			assert.Equal(t, tt.expectedStatus, problem.Status)
+			assert.Equal(t, tt.expectedErrors, problem.Errors)
+		})
+	}
+}
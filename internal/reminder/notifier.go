@@ -0,0 +1,206 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"sberTestTask/internal/egress"
+	"sberTestTask/internal/webhook"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogNotifier writes reminders to the application log.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, n *Notification) error {
+	slog.InfoContext(ctx, n.Subject(),
+		slog.Int64("reminder_id", n.ReminderID),
+		slog.Int("task_id", n.TaskID),
+		slog.String("offset", n.Offset.String()))
+	return nil
+}
+
+// WebhookNotifier posts the notification as JSON. The body is signed like
+// task webhooks when a secret is set.
+type WebhookNotifier struct {
+	url     string
+	secret  string
+	targets TargetPolicy
+	client  *http.Client
+	// targetClient only connects to public addresses and does not follow
+	// redirects.
+	targetClient *http.Client
+}
+
+// NewWebhookNotifier posts to url unless the reminder has its own target,
+// which must be allowed by targets and resolve to a public address.
+func NewWebhookNotifier(url, secret string, targets TargetPolicy, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:          url,
+		secret:       secret,
+		targets:      targets,
+		client:       &http.Client{Timeout: timeout},
+		targetClient: egress.PublicClient(timeout),
+	}
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n *Notification) error {
+	url, client := w.url, w.client
+	if n.Target != "" {
+		// Reminders stored before the policy changed are checked again.
+		if err := w.targets.Validate(ChannelWebhook, n.Target); err != nil {
+			return err
+		}
+		url, client = n.Target, w.targetClient
+	}
+	if url == "" {
+		return errors.New("no webhook URL")
+	}
+
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todo-reminders/1.0")
+	req.Header.Set(webhook.EventHeader, "task.reminder")
+	req.Header.Set(webhook.DeliveryHeader, strconv.FormatInt(n.ReminderID, 10))
+	if w.secret != "" {
+		req.Header.Set(webhook.SignatureHeader, webhook.Sign(w.secret, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// EmailNotifier sends a plain-text mail through an SMTP server, e.g. a
+// local relay or a development stand-in such as MailHog.
+type EmailNotifier struct {
+	addr    string
+	auth    smtp.Auth
+	from    string
+	to      string
+	targets TargetPolicy
+	// sendMail is replaced in tests.
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailNotifier sends to the default to address unless the reminder has
+// its own target, which must be allowed by targets. Authentication is used
+// only when username is set.
+func NewEmailNotifier(addr, username, password, from, to string, targets TargetPolicy) (*EmailNotifier, error) {
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP address: %w", err)
+	}
+	e := &EmailNotifier{addr: addr, from: from, to: to, targets: targets, sendMail: smtp.SendMail}
+	if username != "" {
+		e.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return e, nil
+}
+
+func (e *EmailNotifier) Notify(ctx context.Context, n *Notification) error {
+	to := n.Target
+	if to != "" {
+		if err := e.targets.Validate(ChannelEmail, to); err != nil {
+			return err
+		}
+	} else {
+		to = e.to
+	}
+	if to == "" {
+		return errors.New("no recipient")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return e.sendMail(e.addr, e.auth, e.from, []string{to}, e.message(n, to))
+}
+
+func (e *EmailNotifier) message(n *Notification, to string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerSafe(n.Subject()))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	fmt.Fprintf(&b, "Task #%d %q is due %s.\r\n", n.TaskID, n.Title, n.DueDate.UTC().Format(time.RFC1123))
+	if n.Description != "" {
+		b.WriteString("\r\n")
+		b.WriteString(strings.ReplaceAll(n.Description, "\n", "\r\n"))
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String())
+}
+
+// headerSafe keeps user text from adding header lines.
+func headerSafe(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// TargetPolicy lists where reminders may be sent instead of their channel's
+// configured recipient. Entries are host names or email domains; "*."
+// entries also match subdomains. Without entries, targets are rejected.
+type TargetPolicy struct {
+	WebhookHosts []string
+	EmailDomains []string
+}
+
+// Validate reports whether target is a recipient the channel's notifier
+// can use.
+func (p TargetPolicy) Validate(channel, target string) error {
+	if target == "" {
+		return nil
+	}
+	switch channel {
+	case ChannelWebhook:
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+			return errors.New("target must be an http or https URL")
+		}
+		if !egress.HostAllowed(u.Hostname(), p.WebhookHosts) {
+			return fmt.Errorf("target host %s is not allowed", u.Hostname())
+		}
+	case ChannelEmail:
+		addr, err := mail.ParseAddress(target)
+		if err != nil || addr.Name != "" || addr.Address != target {
+			return errors.New("target must be an email address")
+		}
+		domain := target[strings.LastIndex(target, "@")+1:]
+		if !egress.HostAllowed(domain, p.EmailDomains) {
+			return fmt.Errorf("target domain %s is not allowed", domain)
+		}
+	case ChannelLog:
+		return errors.New("target is not used by the log channel")
+	}
+	return nil
+}
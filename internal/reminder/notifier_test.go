@@ -0,0 +1,156 @@
+package reminder
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"sberTestTask/internal/webhook"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNotification() *Notification {
+	return &Notification{
+		ReminderID: 7,
+		TaskID:     12,
+		Title:      "Write report",
+		DueDate:    time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC),
+		Offset:     Offset(time.Hour),
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var body []byte
+	var header http.Header
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+	}))
+	defer receiver.Close()
+
+	n := NewWebhookNotifier(receiver.URL, "s3cret", TargetPolicy{}, time.Second)
+	require.NoError(t, n.Notify(context.Background(), testNotification()))
+
+	assert.Equal(t, "task.reminder", header.Get(webhook.EventHeader))
+	assert.Equal(t, "7", header.Get(webhook.DeliveryHeader))
+	assert.True(t, webhook.Verify("s3cret", body, header.Get(webhook.SignatureHeader)))
+	assert.JSONEq(t, `{"reminder_id":7,"task_id":12,"title":"Write report","due_date":"2024-06-07T15:00:00Z","offset":"1h"}`, string(body))
+}
+
+func TestWebhookNotifierError(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer receiver.Close()
+
+	n := NewWebhookNotifier("", "", TargetPolicy{}, time.Second)
+	assert.EqualError(t, n.Notify(context.Background(), testNotification()), "no webhook URL")
+
+	n = NewWebhookNotifier(receiver.URL, "", TargetPolicy{}, time.Second)
+	assert.EqualError(t, n.Notify(context.Background(), testNotification()), "unexpected status 502 Bad Gateway")
+}
+
+func TestWebhookNotifierTargets(t *testing.T) {
+	var calls int
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer receiver.Close()
+
+	// The allowlist names the receiver, but it listens on a loopback address.
+	n := NewWebhookNotifier(receiver.URL, "", TargetPolicy{WebhookHosts: []string{"127.0.0.1", "hooks.example.com"}}, time.Second)
+	notification := testNotification()
+	notification.Target = receiver.URL
+	err := n.Notify(context.Background(), notification)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "address 127.0.0.1 is not public")
+
+	notification.Target = "https://internal.example.net/hook"
+	assert.EqualError(t, n.Notify(context.Background(), notification), "target host internal.example.net is not allowed")
+	assert.Zero(t, calls)
+
+	// The configured URL is trusted.
+	require.NoError(t, n.Notify(context.Background(), testNotification()))
+	assert.Equal(t, 1, calls)
+}
+
+func TestEmailNotifier(t *testing.T) {
+	n, err := NewEmailNotifier("localhost:1025", "", "", "todo@localhost", "team@example.com", TargetPolicy{EmailDomains: []string{"example.com"}})
+	require.NoError(t, err)
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var msg string
+	n.sendMail = func(addr string, a smtp.Auth, from string, to []string, m []byte) error {
+		gotAddr, gotFrom, gotTo, msg = addr, from, to, string(m)
+		return nil
+	}
+
+	notification := testNotification()
+	notification.Title = "Write\r\nBcc: victim@example.com"
+	notification.Target = "bob@example.com"
+	require.NoError(t, n.Notify(context.Background(), notification))
+
+	assert.Equal(t, "localhost:1025", gotAddr)
+	assert.Equal(t, "todo@localhost", gotFrom)
+	assert.Equal(t, []string{"bob@example.com"}, gotTo)
+	assert.Contains(t, msg, "To: bob@example.com\r\n")
+	assert.Contains(t, msg, "Subject: Reminder: \"Write\\r\\nBcc: victim@example.com\" is due 2024-06-07 15:00 UTC\r\n")
+	assert.NotContains(t, msg, "\r\nBcc:")
+
+	notification.Target = "victim@elsewhere.org"
+	assert.EqualError(t, n.Notify(context.Background(), notification), "target domain elsewhere.org is not allowed")
+}
+
+func TestTargetPolicy(t *testing.T) {
+	p := TargetPolicy{WebhookHosts: []string{"hooks.example.com", "*.corp.example"}, EmailDomains: []string{"example.com"}}
+	tests := []struct {
+		channel string
+		target  string
+		err     string
+	}{
+		{ChannelEmail, "", ""},
+		{ChannelEmail, "bob@example.com", ""},
+		{ChannelEmail, "bob@EXAMPLE.com", ""},
+		{ChannelEmail, "Bob <bob@example.com>", "target must be an email address"},
+		{ChannelEmail, "bob@evil.example.com", "target domain evil.example.com is not allowed"},
+		{ChannelWebhook, "https://hooks.example.com/reminders", ""},
+		{ChannelWebhook, "https://a.corp.example:8443/hook", ""},
+		{ChannelWebhook, "https://corp.example/hook", "target host corp.example is not allowed"},
+		{ChannelWebhook, "http://169.254.169.254/latest/meta-data", "target host 169.254.169.254 is not allowed"},
+		{ChannelWebhook, "ftp://hooks.example.com", "target must be an http or https URL"},
+		{ChannelLog, "anything", "target is not used by the log channel"},
+	}
+	for _, tt := range tests {
+		err := p.Validate(tt.channel, tt.target)
+		if tt.err == "" {
+			assert.NoError(t, err, tt.target)
+		} else {
+			assert.EqualError(t, err, tt.err, tt.target)
+		}
+	}
+
+	// Without an allowlist only the configured recipients are used.
+	assert.Error(t, TargetPolicy{}.Validate(ChannelWebhook, "https://hooks.example.com/reminders"))
+	assert.Error(t, TargetPolicy{}.Validate(ChannelEmail, "bob@example.com"))
+}
+
+func TestOffsetJSON(t *testing.T) {
+	for in, want := range map[string]string{`"1h"`: `"1h"`, `"90m"`: `"1h30m"`, `"24h"`: `"24h"`, `"45s"`: `"45s"`, `"0s"`: `"0s"`} {
+		var o Offset
+		require.NoError(t, json.Unmarshal([]byte(in), &o), in)
+		out, err := json.Marshal(o)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(out), in)
+	}
+
+	var o Offset
+	assert.Error(t, json.Unmarshal([]byte(`3600`), &o))
+	assert.Error(t, json.Unmarshal([]byte(`"an hour"`), &o))
+}
@@ -0,0 +1,100 @@
+// Package reminder notifies about tasks whose due date approaches. Every
+// task can have reminders at offsets before its due date; a Scheduler
+// claims due reminders from Postgres and hands them to a Notifier chosen
+// by the reminder's channel.
+package reminder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Channel names used in the configuration and in reminders.
+const (
+	ChannelLog     = "log"
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+)
+
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusSent    Status = "sent"
+	// StatusFailed marks a reminder that failed every attempt.
+	StatusFailed Status = "failed"
+)
+
+type Reminder struct {
+	ID     int64  `json:"id"`
+	TaskID int    `json:"task_id"`
+	Offset Offset `json:"offset" swaggertype:"string" example:"1h"`
+	// Channel is the name of a configured notifier: log, webhook or email.
+	Channel string `json:"channel" example:"email"`
+	// Target overrides the channel's default recipient: a URL for webhook,
+	// an address for email.
+	Target    string     `json:"target,omitempty"`
+	RemindAt  time.Time  `json:"remind_at"`
+	Status    Status     `json:"status"`
+	Attempts  int        `json:"attempts"`
+	LastError *string    `json:"last_error,omitempty"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Offset is how long before the due date a reminder fires. It is written
+// in JSON as a Go duration string such as "1h" or "30m".
+type Offset time.Duration
+
+func (o Offset) String() string {
+	s := time.Duration(o).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+func (o Offset) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.String())
+}
+
+func (o *Offset) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("offset must be a duration string like \"1h\": %w", err)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*o = Offset(d)
+	return nil
+}
+
+// Notification is what a Notifier sends.
+type Notification struct {
+	ReminderID  int64     `json:"reminder_id"`
+	TaskID      int       `json:"task_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	DueDate     time.Time `json:"due_date"`
+	Offset      Offset    `json:"offset"`
+	// Target is the reminder's recipient, empty for the channel default.
+	Target string `json:"-"`
+}
+
+// Subject is a one-line summary used by the email and log notifiers.
+func (n *Notification) Subject() string {
+	return fmt.Sprintf("Reminder: %q is due %s", n.Title, n.DueDate.UTC().Format("2006-01-02 15:04 MST"))
+}
+
+// Notifier delivers a notification. An error makes the scheduler retry.
+type Notifier interface {
+	Notify(ctx context.Context, n *Notification) error
+}
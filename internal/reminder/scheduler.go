@@ -0,0 +1,148 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const batchSize = 50
+
+// RetryPolicy controls how failed notifications are retried. The delay
+// starts at InitialBackoff and doubles after every failed attempt up to
+// MaxBackoff. A reminder that failed MaxAttempts times is marked failed.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func (p RetryPolicy) backoff(attempts int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempts && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxBackoff)
+}
+
+// queue is the part of the Store used by the Scheduler.
+type queue interface {
+	claim(ctx context.Context, limit int, lease time.Duration) ([]*job, error)
+	finish(ctx context.Context, j *job, res result) error
+}
+
+// job is a leased reminder with the task it is about.
+type job struct {
+	id       int64
+	remindAt time.Time
+	attempts int
+	channel  string
+	n        *Notification
+}
+
+type result struct {
+	status Status
+	next   time.Time
+	err    error
+}
+
+// Scheduler sends due reminders through the notifier of their channel.
+type Scheduler struct {
+	queue        queue
+	notifiers    map[string]Notifier
+	policy       RetryPolicy
+	timeout      time.Duration
+	pollInterval time.Duration
+	now          func() time.Time
+}
+
+// NewScheduler sends through notifiers by channel name. timeout bounds a
+// single notification.
+func NewScheduler(store *Store, notifiers map[string]Notifier, policy RetryPolicy, timeout, pollInterval time.Duration) *Scheduler {
+	return &Scheduler{
+		queue:        store,
+		notifiers:    notifiers,
+		policy:       policy,
+		timeout:      timeout,
+		pollInterval: pollInterval,
+		now:          time.Now,
+	}
+}
+
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.poll(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("reminder poll", slog.String("error", err.Error()))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll sends all reminders that are due.
+func (s *Scheduler) poll(ctx context.Context) error {
+	for {
+		// The lease outlives the notification timeout so that a slow
+		// backend is not asked to send the same reminder twice.
+		jobs, err := s.queue.claim(ctx, batchSize, 2*s.timeout)
+		if err != nil {
+			return err
+		}
+		s.sendAll(ctx, jobs)
+		if len(jobs) < batchSize {
+			return nil
+		}
+	}
+}
+
+func (s *Scheduler) sendAll(ctx context.Context, jobs []*job) {
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := s.send(ctx, j)
+			// Record the outcome even if shutdown began during the send.
+			if err := s.queue.finish(context.WithoutCancel(ctx), j, res); err != nil {
+				slog.Error("record reminder", slog.Int64("reminder_id", j.id), slog.String("error", err.Error()))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) send(ctx context.Context, j *job) result {
+	err := s.notify(ctx, j)
+	now := s.now()
+	if err == nil {
+		return result{status: StatusSent, next: now}
+	}
+
+	log := slog.With(slog.Int64("reminder_id", j.id), slog.Int("task_id", j.n.TaskID), slog.String("channel", j.channel),
+		slog.Int("attempt", j.attempts+1), slog.String("error", err.Error()))
+	if j.attempts+1 >= s.policy.MaxAttempts {
+		log.Error("reminder failed")
+		return result{status: StatusFailed, next: now, err: err}
+	}
+	retryIn := s.policy.backoff(j.attempts + 1)
+	log.Warn("reminder attempt failed", slog.Duration("retry_in", retryIn))
+	return result{status: StatusPending, next: now.Add(retryIn), err: err}
+}
+
+func (s *Scheduler) notify(ctx context.Context, j *job) error {
+	notifier, ok := s.notifiers[j.channel]
+	if !ok {
+		return fmt.Errorf("channel %q is not configured", j.channel)
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return notifier.Notify(ctx, j.n)
+}
@@ -0,0 +1,162 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeQueue keeps reminders in memory and hands out those that are due.
+type fakeQueue struct {
+	mu       sync.Mutex
+	now      time.Time
+	pending  []*job
+	due      map[int64]time.Time
+	finished map[int64]result
+}
+
+func newFakeQueue(now time.Time, jobs ...*job) *fakeQueue {
+	q := &fakeQueue{now: now, pending: jobs, due: map[int64]time.Time{}, finished: map[int64]result{}}
+	for _, j := range jobs {
+		q.due[j.id] = now
+	}
+	return q
+}
+
+func (q *fakeQueue) claim(_ context.Context, limit int, lease time.Duration) ([]*job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var claimed []*job
+	for _, j := range q.pending {
+		if len(claimed) == limit {
+			break
+		}
+		if !q.due[j.id].After(q.now) {
+			q.due[j.id] = q.now.Add(lease)
+			claimed = append(claimed, j)
+		}
+	}
+	return claimed, nil
+}
+
+func (q *fakeQueue) finish(_ context.Context, j *job, res result) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.finished[j.id] = res
+	j.attempts++
+	if res.status == StatusPending {
+		q.due[j.id] = res.next
+		return nil
+	}
+	for i, p := range q.pending {
+		if p == j {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (q *fakeQueue) advance(d time.Duration) {
+	q.mu.Lock()
+	q.now = q.now.Add(d)
+	q.mu.Unlock()
+}
+
+// fakeNotifier records notifications and fails the first failures calls.
+type fakeNotifier struct {
+	mu       sync.Mutex
+	failures int
+	sent     []*Notification
+	calls    int
+}
+
+func (f *fakeNotifier) Notify(_ context.Context, n *Notification) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.calls <= f.failures {
+		return errors.New("smtp: connection refused")
+	}
+	f.sent = append(f.sent, n)
+	return nil
+}
+
+func newJob(id int64, channel string) *job {
+	return &job{id: id, channel: channel, n: &Notification{ReminderID: id, TaskID: int(id), Title: "Task"}}
+}
+
+func newTestScheduler(q *fakeQueue, notifiers map[string]Notifier, policy RetryPolicy) *Scheduler {
+	return &Scheduler{
+		queue:     q,
+		notifiers: notifiers,
+		policy:    policy,
+		timeout:   time.Second,
+		now:       func() time.Time { return q.now },
+	}
+}
+
+func TestSchedulerSendsByChannel(t *testing.T) {
+	email, hook := &fakeNotifier{}, &fakeNotifier{}
+	q := newFakeQueue(time.Now(), newJob(1, ChannelEmail), newJob(2, ChannelWebhook), newJob(3, ChannelEmail))
+	s := newTestScheduler(q, map[string]Notifier{ChannelEmail: email, ChannelWebhook: hook}, RetryPolicy{MaxAttempts: 3})
+
+	assert.NoError(t, s.poll(context.Background()))
+
+	assert.Len(t, email.sent, 2)
+	assert.Len(t, hook.sent, 1)
+	assert.Equal(t, int64(2), hook.sent[0].ReminderID)
+	for id := int64(1); id <= 3; id++ {
+		assert.Equal(t, StatusSent, q.finished[id].status)
+	}
+	assert.Empty(t, q.pending)
+
+	// Nothing is sent twice.
+	assert.NoError(t, s.poll(context.Background()))
+	assert.Equal(t, 2, email.calls)
+}
+
+func TestSchedulerRetriesWithBackoff(t *testing.T) {
+	notifier := &fakeNotifier{failures: 2}
+	start := time.Now()
+	j := newJob(1, ChannelEmail)
+	q := newFakeQueue(start, j)
+	s := newTestScheduler(q, map[string]Notifier{ChannelEmail: notifier}, RetryPolicy{MaxAttempts: 5, InitialBackoff: 30 * time.Second, MaxBackoff: time.Hour})
+	ctx := context.Background()
+
+	assert.NoError(t, s.poll(ctx))
+	res := q.finished[1]
+	assert.Equal(t, StatusPending, res.status)
+	assert.EqualError(t, res.err, "smtp: connection refused")
+	assert.Equal(t, start.Add(30*time.Second), res.next)
+
+	// Not due yet.
+	assert.NoError(t, s.poll(ctx))
+	assert.Equal(t, 1, notifier.calls)
+
+	q.advance(30 * time.Second)
+	assert.NoError(t, s.poll(ctx))
+	assert.Equal(t, q.now.Add(time.Minute), q.finished[1].next)
+
+	q.advance(time.Minute)
+	assert.NoError(t, s.poll(ctx))
+	assert.Equal(t, StatusSent, q.finished[1].status)
+	assert.Equal(t, 3, j.attempts)
+}
+
+func TestSchedulerMarksFailed(t *testing.T) {
+	q := newFakeQueue(time.Now(), newJob(1, "sms"))
+	s := newTestScheduler(q, map[string]Notifier{}, RetryPolicy{MaxAttempts: 1})
+
+	assert.NoError(t, s.poll(context.Background()))
+	res := q.finished[1]
+	assert.Equal(t, StatusFailed, res.status)
+	assert.EqualError(t, res.err, `channel "sms" is not configured`)
+	assert.Empty(t, q.pending)
+}
@@ -0,0 +1,193 @@
+package reminder
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"time"
+)
+
+var (
+	ErrNotFound     = errors.New("reminder not found")
+	ErrTaskNotFound = errors.New("task not found")
+	ErrExists       = errors.New("reminder already exists")
+)
+
+// Store keeps reminders in Postgres. remind_at follows the task's due date
+// through a trigger on the tasks table.
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+const reminderColumns = `id, task_id, offset_seconds, channel, target, remind_at, status, attempts, last_error, sent_at, created_at`
+
+func (s *Store) Create(ctx context.Context, r *Reminder) error {
+	err := s.db.QueryRowContext(ctx, `INSERT INTO task_reminders (task_id, offset_seconds, channel, target, remind_at, next_attempt_at)
+SELECT t.id, $2, $3, $4, at, at
+FROM tasks t, LATERAL (SELECT t.due_date - make_interval(secs => $2) AS at) r
+WHERE t.id = $1
+RETURNING `+reminderColumns,
+		r.TaskID, int(time.Duration(r.Offset).Seconds()), r.Channel, r.Target,
+	).Scan(scanTargets(r)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTaskNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrExists
+	}
+	return err
+}
+
+// List returns the reminders of a task, earliest first.
+func (s *Store) List(ctx context.Context, taskID int) ([]*Reminder, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+reminderColumns+" FROM task_reminders WHERE task_id = $1 ORDER BY remind_at, id", taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []*Reminder{}
+	for rows.Next() {
+		r := &Reminder{}
+		if err := rows.Scan(scanTargets(r)...); err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, r)
+	}
+	return reminders, rows.Err()
+}
+
+func (s *Store) Delete(ctx context.Context, taskID int, id int64) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM task_reminders WHERE id = $1 AND task_id = $2", id, taskID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// StreamReminders calls fn for every reminder in ID order.
+func (s *Store) StreamReminders(ctx context.Context, fn func(*Reminder) error) error {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+reminderColumns+" FROM task_reminders ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r := &Reminder{}
+		if err := rows.Scan(scanTargets(r)...); err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// RestoreReminder inserts a reminder from a backup with its delivery state.
+// remind_at is computed from the task's current due date; reminders of
+// missing tasks or tasks without a due date are not restored. An existing
+// reminder with the same task, offset, channel and target is replaced if
+// overwrite is set and kept otherwise.
+func (s *Store) RestoreReminder(ctx context.Context, r *Reminder, overwrite bool) (bool, error) {
+	onConflict := "DO NOTHING"
+	if overwrite {
+		onConflict = `DO UPDATE SET remind_at = EXCLUDED.remind_at, status = EXCLUDED.status, attempts = EXCLUDED.attempts,
+    next_attempt_at = EXCLUDED.next_attempt_at, last_error = EXCLUDED.last_error, sent_at = EXCLUDED.sent_at,
+    created_at = EXCLUDED.created_at`
+	}
+	res, err := s.db.ExecContext(ctx, `INSERT INTO task_reminders (task_id, offset_seconds, channel, target, remind_at,
+    status, attempts, next_attempt_at, last_error, sent_at, created_at)
+SELECT t.id, $2, $3, $4, at, $5, $6, at, $7, $8, $9
+FROM tasks t, LATERAL (SELECT t.due_date - make_interval(secs => $2) AS at) r
+WHERE t.id = $1 AND t.due_date IS NOT NULL
+ON CONFLICT (task_id, offset_seconds, channel, target) `+onConflict,
+		r.TaskID, int(time.Duration(r.Offset).Seconds()), r.Channel, r.Target,
+		r.Status, r.Attempts, r.LastError, r.SentAt, r.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func scanTargets(r *Reminder) []any {
+	return []any{&r.ID, &r.TaskID, (*offsetSeconds)(&r.Offset), &r.Channel, &r.Target, &r.RemindAt,
+		&r.Status, &r.Attempts, &r.LastError, &r.SentAt, &r.CreatedAt}
+}
+
+// offsetSeconds scans offset_seconds into an Offset.
+type offsetSeconds Offset
+
+func (o *offsetSeconds) Scan(src any) error {
+	n, ok := src.(int64)
+	if !ok {
+		return errors.New("offset_seconds: unexpected type")
+	}
+	*o = offsetSeconds(time.Duration(n) * time.Second)
+	return nil
+}
+
+// claim leases up to limit due reminders of open tasks. SKIP LOCKED lets
+// several replicas poll at the same time without sending a reminder twice;
+// a leased reminder is not handed out again until the lease expires.
+func (s *Store) claim(ctx context.Context, limit int, lease time.Duration) ([]*job, error) {
+	rows, err := s.db.QueryContext(ctx, `UPDATE task_reminders r
+SET next_attempt_at = now() + make_interval(secs => $2)
+FROM tasks t
+WHERE t.id = r.task_id AND r.id IN (
+    SELECT r.id FROM task_reminders r JOIN tasks t ON t.id = r.task_id
+    WHERE r.status = 'pending' AND r.next_attempt_at <= now() AND NOT coalesce(t.completed, false)
+    ORDER BY r.next_attempt_at LIMIT $1
+    FOR UPDATE OF r SKIP LOCKED
+)
+RETURNING r.id, r.remind_at, r.attempts, r.channel, r.target, r.offset_seconds, t.id, t.title, coalesce(t.description, ''), t.due_date`,
+		limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*job
+	for rows.Next() {
+		j := &job{n: &Notification{}}
+		err := rows.Scan(&j.id, &j.remindAt, &j.attempts, &j.channel, &j.n.Target, (*offsetSeconds)(&j.n.Offset),
+			&j.n.TaskID, &j.n.Title, &j.n.Description, &j.n.DueDate)
+		if err != nil {
+			return nil, err
+		}
+		j.n.ReminderID = j.id
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// finish stores the outcome of an attempt. A reminder rescheduled by a due
+// date change in the meantime is left alone.
+func (s *Store) finish(ctx context.Context, j *job, res result) error {
+	var lastError *string
+	if res.err != nil {
+		msg := res.err.Error()
+		lastError = &msg
+	}
+	var sentAt *time.Time
+	if res.status == StatusSent {
+		sentAt = &res.next
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE task_reminders
+SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, sent_at = $5
+WHERE id = $6 AND remind_at = $7`, res.status, j.attempts+1, res.next, lastError, sentAt, j.id, j.remindAt)
+	return err
+}
@@ -0,0 +1,19 @@
+// Package routing holds helpers for matching route patterns shared by the
+// HTTP middlewares that take per-route settings.
+package routing
+
+import "strings"
+
+// Unversioned strips an API version prefix such as /v1 from a route
+// pattern, so that a setting for /tasks also covers /v1/tasks.
+func Unversioned(pattern string) string {
+	rest, ok := strings.CutPrefix(pattern, "/v")
+	if !ok {
+		return pattern
+	}
+	digits := len(rest) - len(strings.TrimLeft(rest, "0123456789"))
+	if digits == 0 || (digits < len(rest) && rest[digits] != '/') {
+		return pattern
+	}
+	return rest[digits:]
+}
@@ -0,0 +1,20 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnversioned(t *testing.T) {
+	for pattern, want := range map[string]string{
+		"/v1/tasks":       "/tasks",
+		"/v12/tasks/{id}": "/tasks/{id}",
+		"/v2":             "",
+		"/tasks":          "/tasks",
+		"/votes":          "/votes",
+		"/v1x/tasks":      "/v1x/tasks",
+	} {
+		assert.Equal(t, want, Unversioned(pattern), pattern)
+	}
+}
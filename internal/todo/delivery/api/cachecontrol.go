@@ -0,0 +1,94 @@
+package api
+
+import (
+	"bufio"
+	"github.com/go-chi/chi/v5"
+	"net"
+	"net/http"
+	"sberTestTask/internal/routing"
+	"strings"
+)
+
+// CacheControl configures the Cache-Control header of successful
+// responses. Default applies to GET requests of routes without a rule of
+// their own; an empty value sends no header.
+type CacheControl struct {
+	Default string
+	Routes  []CacheControlRoute
+}
+
+// CacheControlRoute overrides the default for one chi route pattern.
+type CacheControlRoute struct {
+	Method  string
+	Pattern string
+	Value   string
+}
+
+func cacheControlMiddleware(cfg CacheControl) func(http.Handler) http.Handler {
+	routes := make(map[string]string, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routes[strings.ToUpper(route.Method)+" "+route.Pattern] = route.Value
+	}
+	valueFor := func(r *http.Request) string {
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.Routes != nil && len(routes) > 0 {
+			tctx := chi.NewRouteContext()
+			if rctx.Routes.Match(tctx, r.Method, r.URL.Path) {
+				// Patterns without a version apply to every API version.
+				pattern := tctx.RoutePattern()
+				for _, key := range []string{r.Method + " " + pattern, r.Method + " " + routing.Unversioned(pattern)} {
+					if value, ok := routes[key]; ok {
+						return value
+					}
+				}
+			}
+		}
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			return cfg.Default
+		}
+		return ""
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if value := valueFor(r); value != "" {
+				w = &cacheControlWriter{ResponseWriter: w, value: value}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// cacheControlWriter sets Cache-Control once the status is known, so that
+// errors are never cached, and leaves a header set by the handler alone.
+type cacheControlWriter struct {
+	http.ResponseWriter
+	value       string
+	wroteHeader bool
+}
+
+func (w *cacheControlWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if (code < 300 || code == http.StatusNotModified) && w.Header().Get("Cache-Control") == "" {
+			w.Header().Set("Cache-Control", w.value)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cacheControlWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *cacheControlWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack keeps WebSocket upgrades working, which assert http.Hijacker.
+func (w *cacheControlWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// taskETag returns a weak validator for a task: a hash of its JSON without
+// due_in. due_in counts down every second and would otherwise defeat
+// revalidation; the fields that change with the clock less often, overdue
+// and days_overdue, are part of the hash.
+func taskETag(task *TaskResponse) string {
+	return weakETag(withoutDueIn(task))
+}
+
+// pagesETag is taskETag for a page of tasks, which also changes when tasks
+// are added to or removed from the page.
+func pagesETag(pages *PageResponse) string {
+	page := *pages
+	page.Tasks = make([]*TaskResponse, len(pages.Tasks))
+	for i, task := range pages.Tasks {
+		page.Tasks[i] = withoutDueIn(task)
+	}
+	return weakETag(&page)
+}
+
+func withoutDueIn(task *TaskResponse) *TaskResponse {
+	t := *task
+	t.DueIn = nil
+	return &t
+}
+
+func weakETag(v interface{}) string {
+	b, _ := json.Marshal(v)
+	sum := sha256.Sum256(b)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// lastModified returns the latest UpdatedAt of tasks, zero if none is set.
+func lastModified(tasks ...*TaskResponse) time.Time {
+	var last time.Time
+	for _, task := range tasks {
+		if task.UpdatedAt != nil && task.UpdatedAt.After(last) {
+			last = *task.UpdatedAt
+		}
+	}
+	return last
+}
+
+// notModified sets the ETag and Last-Modified headers and answers 304 Not
+// Modified when the request's validators still match. If-None-Match takes
+// precedence; If-Modified-Since is only honoured when exact reports that
+// modified changes with every change of the representation, which does not
+// hold for lists (deletions) or tasks whose computed fields follow the clock.
+func notModified(w http.ResponseWriter, r *http.Request, etag string, modified time.Time, exact bool) bool {
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	match := false
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		match = etagMatches(inm, etag)
+	} else if ims := r.Header.Get("If-Modified-Since"); ims != "" && exact && !modified.IsZero() {
+		if since, err := http.ParseTime(ims); err == nil {
+			match = !modified.Truncate(time.Second).After(since)
+		}
+	}
+	if match {
+		w.WriteHeader(http.StatusNotModified)
+	}
+	return match
+}
+
+// etagMatches applies the weak comparison of If-None-Match.
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
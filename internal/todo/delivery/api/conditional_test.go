@@ -0,0 +1,160 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sberTestTask/internal/todo"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetTaskConditional(t *testing.T) {
+	router, mockUsecase := setupRouterWithMock()
+
+	due := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, 6, 1, 10, 30, 0, 500, time.UTC)
+	overdue, days := false, 0
+	dueIn, laterDueIn := int64(3600), int64(3590)
+	completed := &todo.Task{ID: 1, Title: "Done", DueDate: &due, Completed: true, UpdatedAt: &updated, Overdue: &overdue, DaysOverdue: &days}
+	mockUsecase.On("GetTask", mock.Anything, 1).Return(completed, nil)
+	// due_in counts down between the two reads of task 2.
+	mockUsecase.On("GetTask", mock.Anything, 2).Return(&todo.Task{ID: 2, Title: "Open", DueDate: &due, UpdatedAt: &updated, Overdue: &overdue, DaysOverdue: &days, DueIn: &dueIn}, nil).Once()
+	mockUsecase.On("GetTask", mock.Anything, 2).Return(&todo.Task{ID: 2, Title: "Open", DueDate: &due, UpdatedAt: &updated, Overdue: &overdue, DaysOverdue: &days, DueIn: &laterDueIn}, nil)
+
+	get := func(id string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/tasks/"+id, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("1", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	etag := rr.Header().Get("ETag")
+	assert.Regexp(t, `^W/"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, "Sat, 01 Jun 2024 10:30:00 GMT", rr.Header().Get("Last-Modified"))
+
+	tests := []struct {
+		name   string
+		id     string
+		header http.Header
+		status int
+	}{
+		{"matching etag", "1", http.Header{"If-None-Match": {`"other", ` + etag}}, http.StatusNotModified},
+		{"strong form of the etag", "1", http.Header{"If-None-Match": {etag[2:]}}, http.StatusNotModified},
+		{"any etag", "1", http.Header{"If-None-Match": {"*"}}, http.StatusNotModified},
+		{"stale etag", "1", http.Header{"If-None-Match": {`W/"other"`}}, http.StatusOK},
+		{"not modified since", "1", http.Header{"If-Modified-Since": {"Sat, 01 Jun 2024 10:30:00 GMT"}}, http.StatusNotModified},
+		{"modified since", "1", http.Header{"If-Modified-Since": {"Sat, 01 Jun 2024 10:29:59 GMT"}}, http.StatusOK},
+		// If-None-Match wins over If-Modified-Since.
+		{"stale etag, not modified since", "1", http.Header{"If-None-Match": {`W/"other"`}, "If-Modified-Since": {"Sat, 01 Jun 2024 10:30:00 GMT"}}, http.StatusOK},
+		// The computed fields of an open task follow the clock.
+		{"open task, not modified since", "2", http.Header{"If-Modified-Since": {"Sat, 01 Jun 2024 10:30:00 GMT"}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := get(tt.id, tt.header)
+			assert.Equal(t, tt.status, rr.Code)
+			if tt.status == http.StatusNotModified {
+				assert.Empty(t, rr.Body.String())
+				assert.Equal(t, etag, rr.Header().Get("ETag"))
+			}
+		})
+	}
+
+	t.Run("due_in is not part of the etag", func(t *testing.T) {
+		rr := get("2", http.Header{"If-None-Match": {get("2", nil).Header().Get("ETag")}})
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+	})
+}
+
+func TestListTasksConditional(t *testing.T) {
+	router, mockUsecase := setupRouterWithMockForList()
+
+	due := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	older := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 6, 2, 10, 0, 0, 0, time.UTC)
+	first := &todo.Task{ID: 1, Title: "First", DueDate: &due, UpdatedAt: &newer}
+	second := &todo.Task{ID: 2, Title: "Second", DueDate: &due, UpdatedAt: &older}
+	mockUsecase.On("ListTasks", mock.Anything, todo.TaskFilter{}, 10, 1).
+		Return(&todo.Pages{CountPage: 1, CurPage: 1, Tasks: []*todo.Task{first, second}}, nil).Once()
+	mockUsecase.On("ListTasks", mock.Anything, todo.TaskFilter{}, 10, 1).
+		Return(&todo.Pages{CountPage: 1, CurPage: 1, Tasks: []*todo.Task{first, second}}, nil).Once()
+	// Deleting a task changes the page but not the latest updated_at.
+	mockUsecase.On("ListTasks", mock.Anything, todo.TaskFilter{}, 10, 1).
+		Return(&todo.Pages{CountPage: 1, CurPage: 1, Tasks: []*todo.Task{first}}, nil).Once()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/tasks", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	etag := rr.Header().Get("ETag")
+	assert.Equal(t, "Sun, 02 Jun 2024 10:00:00 GMT", rr.Header().Get("Last-Modified"))
+
+	req := httptest.NewRequest("GET", "/tasks", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+
+	req = httptest.NewRequest("GET", "/tasks", nil)
+	req.Header.Set("If-Modified-Since", "Sun, 02 Jun 2024 10:00:00 GMT")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEqual(t, etag, rr.Header().Get("ETag"))
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestCacheControl(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(cacheControlMiddleware(CacheControl{
+		Default: "private, no-cache",
+		Routes:  []CacheControlRoute{{Method: "get", Pattern: "/tasks/{id}", Value: "private, max-age=5"}},
+	}))
+	ok := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) }
+	router.Get("/tasks", ok)
+	router.Post("/tasks", ok)
+	router.Get("/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if chi.URLParam(r, "id") == "0" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if chi.URLParam(r, "id") == "1" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		ok(w, r)
+	})
+	router.Get("/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		ok(w, r)
+	})
+
+	tests := []struct {
+		method string
+		url    string
+		want   string
+	}{
+		{"GET", "/tasks", "private, no-cache"},
+		{"POST", "/tasks", ""},
+		{"GET", "/tasks/2", "private, max-age=5"},
+		{"GET", "/tasks/1", "private, max-age=5"},
+		{"GET", "/tasks/0", ""},
+		{"GET", "/events", "no-store"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.url, nil))
+			assert.Equal(t, tt.want, rr.Header().Get("Cache-Control"))
+		})
+	}
+}
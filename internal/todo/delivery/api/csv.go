@@ -0,0 +1,362 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sberTestTask/internal/httpapi"
+	"sberTestTask/internal/logger"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/validation"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxImportSize = 10 << 20
+	maxImportRows = 10000
+)
+
+// csvColumns are the columns written by ExportTasks and recognised by
+// ImportTasks. The id column is ignored on import.
+var csvColumns = []string{"id", "title", "description", "due_date", "completed", "all_day"}
+
+// @Summary Export tasks
+// @Description Stream all tasks matching the list filters as CSV with the columns id, title, description, due_date, completed and all_day. Due dates of all-day tasks are written as YYYY-MM-DD
+// @Tags tasks
+// @Produce text/csv
+// @Param format query string false "Export format, only csv is supported"
+// @Param completed query bool false "Filter by completion status"
+// @Param date query string false "Filter by due date" Format(date) example(2024-06-07)
+// @Param tz query string false "IANA time zone of the date filter" example(Europe/Moscow)
+// @Success 200 {string} string "CSV file"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 500 {object} httpapi.Problem "Internal Server Error"
+// @Router /tasks/export [get]
+func (h *Handler) ExportTasks(w http.ResponseWriter, r *http.Request) {
+	if format := r.URL.Query().Get("format"); format != "" && format != "csv" {
+		httpapi.WriteProblem(w, http.StatusBadRequest, "unsupported format", nil)
+		return
+	}
+	filter, err := parseFilters(r)
+	if err != nil {
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// The csv writer buffers its output, so nothing reaches the client until
+	// the buffer fills or is flushed. Until then a failure can still be
+	// reported with a proper status.
+	out := &countingWriter{w: w}
+	cw := csv.NewWriter(out)
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="tasks.csv"`)
+		return cw.Write(csvColumns)
+	}
+
+	err = h.uc.ExportTasks(r.Context(), filter, func(task *todo.Task) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return cw.Write(taskRecord(task))
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		cw.Flush()
+		err = cw.Error()
+	}
+	if err != nil {
+		if out.n == 0 {
+			w.Header().Del("Content-Disposition")
+			httpapi.WriteProblem(w, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		// The status has been sent already; a truncated file is all the
+		// client will notice.
+		logger.FromContext(r.Context()).ErrorContext(r.Context(), "export interrupted", slog.String("error", err.Error()))
+	}
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func taskRecord(task *todo.Task) []string {
+	var dueDate string
+	switch {
+	case task.DueDate == nil:
+	case task.AllDay:
+		dueDate = task.DueDate.UTC().Format(time.DateOnly)
+	default:
+		dueDate = task.DueDate.Format(time.RFC3339)
+	}
+	return []string{strconv.Itoa(task.ID), cellText(task.Title), cellText(task.Description), dueDate,
+		strconv.FormatBool(task.Completed), strconv.FormatBool(task.AllDay)}
+}
+
+// cellText keeps spreadsheets from running user text as a formula by
+// prefixing text that starts like one with a quote.
+func cellText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// @Summary Import tasks
+// @Description Create tasks from a CSV file with a header row. Columns are matched by name (title, description, due_date, completed, all_day); use map=Header:column to map other header names. due_date accepts RFC 3339 or YYYY-MM-DD, which makes the task all-day unless the all_day column says otherwise. If any row is invalid nothing is imported and every error is reported.
+// @Tags tasks
+// @Accept text/csv
+// @Produce  json
+// @Param file body string true "CSV file"
+// @Param dry_run query bool false "Only validate the file"
+// @Param map query []string false "Header mapping, e.g. Deadline:due_date" collectionFormat(multi)
+// @Success 200 {object} ImportResult "Dry run result"
+// @Success 201 {object} ImportResult "Tasks imported"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 413 {object} httpapi.Problem "File too large"
+// @Failure 422 {object} ImportResult "Invalid rows"
+// @Failure 500 {object} httpapi.Problem "Internal Server Error"
+// @Router /tasks/import [post]
+func (h *Handler) ImportTasks(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	mapping, err := parseMapping(r.URL.Query()["map"])
+	if err != nil {
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	tasks, result, err := readTasksCSV(http.MaxBytesReader(w, r.Body, maxImportSize), mapping)
+	if err != nil {
+		httpapi.WriteProblem(w, httpapi.BodyErrorStatus(err), err.Error(), nil)
+		return
+	}
+	h.importTasks(w, r, tasks, result, dryRun)
+}
+
+func parseDryRun(r *http.Request) (bool, error) {
+	s := r.URL.Query().Get("dry_run")
+	if s == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, errors.New("invalid dry_run flag")
+	}
+	return dryRun, nil
+}
+
+// importTasks creates the parsed tasks unless some rows were invalid or
+// this is a dry run, and reports the result.
+func (h *Handler) importTasks(w http.ResponseWriter, r *http.Request, tasks []*todo.Task, result *ImportResult, dryRun bool) {
+	result.DryRun = dryRun
+	logger.AddAttrs(r.Context(), slog.Int("rows", result.Rows), slog.Int("invalid_rows", len(result.Errors)))
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(result.Errors) > 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+	if dryRun {
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	if err := h.uc.ImportTasks(r.Context(), tasks); err != nil {
+		httpapi.WriteProblem(w, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	result.Imported = len(tasks)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// parseMapping parses "Header:column" pairs into a map keyed by the
+// lower-cased header.
+func parseMapping(pairs []string) (map[string]string, error) {
+	mapping := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		header, column, ok := strings.Cut(pair, ":")
+		column = strings.TrimSpace(column)
+		if !ok || strings.TrimSpace(header) == "" || !isImportColumn(column) {
+			return nil, fmt.Errorf("invalid mapping %q", pair)
+		}
+		mapping[strings.ToLower(strings.TrimSpace(header))] = column
+	}
+	return mapping, nil
+}
+
+func isImportColumn(column string) bool {
+	switch column {
+	case "title", "description", "due_date", "completed", "all_day":
+		return true
+	}
+	return false
+}
+
+// readTasksCSV parses and validates all rows. Row numbers in the result are
+// line numbers in the file, so the header is row 1. An error is returned
+// only when the file cannot be read at all.
+func readTasksCSV(body io.Reader, mapping map[string]string) ([]*todo.Task, *ImportResult, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("empty file")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if mapped, ok := mapping[name]; ok {
+			name = mapped
+		}
+		if isImportColumn(name) {
+			columns[name] = i
+		}
+	}
+	for _, required := range []string{"title", "due_date"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("missing %s column", required)
+		}
+	}
+
+	result := &ImportResult{}
+	var tasks []*todo.Task
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return nil, nil, &httpapi.TooLargeError{What: "file", Limit: maxImportSize}
+			}
+			return nil, nil, err
+		}
+
+		result.Rows++
+		if result.Rows > maxImportRows {
+			return nil, nil, fmt.Errorf("file has more than %d rows", maxImportRows)
+		}
+		if parseErr != nil {
+			result.Errors = append(result.Errors, ImportError{Row: parseErr.StartLine, Message: parseErr.Err.Error()})
+			continue
+		}
+
+		row, _ := reader.FieldPos(0)
+		task, rowErrors := parseTaskRecord(record, columns, row)
+		result.Errors = append(result.Errors, rowErrors...)
+		if len(rowErrors) == 0 {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, result, nil
+}
+
+func parseTaskRecord(record []string, columns map[string]int, row int) (*todo.Task, []ImportError) {
+	field := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var errs []ImportError
+	fail := func(column, msg string) {
+		errs = append(errs, ImportError{Row: row, Column: column, Message: msg})
+	}
+
+	task := &todo.Task{Title: field("title"), Description: field("description")}
+	// A missing due date, like the other rules of todo.Task, is reported
+	// by validateImported.
+	if s := field("due_date"); s != "" {
+		if dueDate, allDay, err := parseDueDate(s); err != nil {
+			fail("due_date", "due_date must be RFC 3339 or YYYY-MM-DD")
+		} else {
+			task.DueDate, task.AllDay = &dueDate, allDay
+		}
+	}
+
+	if s := field("completed"); s != "" {
+		completed, err := strconv.ParseBool(s)
+		if err != nil {
+			fail("completed", "completed must be true or false")
+		}
+		task.Completed = completed
+	}
+	if s := field("all_day"); s != "" {
+		allDay, err := strconv.ParseBool(s)
+		if err != nil {
+			fail("all_day", "all_day must be true or false")
+		}
+		task.AllDay = allDay
+	}
+	return task, validateImported(task, errs, row, csvColumns, nil)
+}
+
+// validateImported adds the violations of task.Validate to the errors of
+// the row it was parsed from, skipping columns that already failed to parse,
+// and sorts them by column. names maps task fields to the columns of the
+// format where they differ.
+func validateImported(task *todo.Task, errs []ImportError, row int, columns []string, names map[string]string) []ImportError {
+	var fieldErrs validation.Errors
+	errors.As(task.Validate(), &fieldErrs)
+	for _, fe := range fieldErrs {
+		column := fe.Field
+		if name, ok := names[column]; ok {
+			column = name
+		}
+		if !slices.ContainsFunc(errs, func(e ImportError) bool { return e.Column == column }) {
+			errs = append(errs, ImportError{Row: row, Column: column, Message: column + " " + fe.Message})
+		}
+	}
+	slices.SortStableFunc(errs, func(a, b ImportError) int {
+		return slices.Index(columns, a.Column) - slices.Index(columns, b.Column)
+	})
+	return errs
+}
+
+// parseDueDate reports whether s is a bare date, which makes the task
+// all-day.
+func parseDueDate(s string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	return t, true, err
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/service"
+	"sberTestTask/internal/todo/tests/mocks/serviceMock"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupCSVRouter() (*chi.Mux, *serviceMock.MockTodoUsecase) {
+	mockUsecase := new(serviceMock.MockTodoUsecase)
+	handler := NewHandler(mockUsecase)
+	router := chi.NewRouter()
+	router.Get("/tasks/export", handler.ExportTasks)
+	router.Post("/tasks/import", handler.ImportTasks)
+	return router, mockUsecase
+}
+
+func TestExportTasks(t *testing.T) {
+	router, mockUsecase := setupCSVRouter()
+
+	date := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	day := time.Date(2024, 6, 8, 0, 0, 0, 0, time.UTC)
+	completed := false
+	tasks := []*todo.Task{
+		{ID: 1, Title: "Test Task", Description: "with, comma", DueDate: &date},
+		{ID: 2, Title: "Other", DueDate: &day, AllDay: true},
+	}
+	mockUsecase.On("ExportTasks", mock.Anything, todo.TaskFilter{Completed: &completed}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(*todo.Task) error)
+			for _, task := range tasks {
+				assert.NoError(t, fn(task))
+			}
+		}).Return(nil)
+
+	req := httptest.NewRequest("GET", "/tasks/export?format=csv&completed=false", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "id,title,description,due_date,completed,all_day\n"+
+		"1,Test Task,\"with, comma\",2024-06-07T15:00:00Z,false,false\n"+
+		"2,Other,,2024-06-08,false,true\n", rr.Body.String())
+}
+
+func TestExportTasksFormulas(t *testing.T) {
+	router, mockUsecase := setupCSVRouter()
+
+	date := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	tasks := []*todo.Task{
+		{ID: 1, Title: "=HYPERLINK(\"http://evil.example\")", Description: "+1", DueDate: &date},
+		{ID: 2, Title: "-2", Description: "@SUM(A1)", DueDate: &date},
+		{ID: 3, Title: "a=b", Description: "x-y", DueDate: &date},
+	}
+	mockUsecase.On("ExportTasks", mock.Anything, todo.TaskFilter{}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(*todo.Task) error)
+			for _, task := range tasks {
+				assert.NoError(t, fn(task))
+			}
+		}).Return(nil)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/tasks/export?format=csv", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "id,title,description,due_date,completed,all_day\n"+
+		"1,\"'=HYPERLINK(\"\"http://evil.example\"\")\",'+1,2024-06-07T15:00:00Z,false,false\n"+
+		"2,'-2,'@SUM(A1),2024-06-07T15:00:00Z,false,false\n"+
+		"3,a=b,x-y,2024-06-07T15:00:00Z,false,false\n", rr.Body.String())
+}
+
+func TestExportTasksErrors(t *testing.T) {
+	router, mockUsecase := setupCSVRouter()
+	mockUsecase.On("ExportTasks", mock.Anything, todo.TaskFilter{}, mock.Anything).Return(service.ErrOnServer)
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Unsupported format", "/tasks/export?format=xlsx", http.StatusBadRequest, "unsupported format"},
+		{"Invalid filter", "/tasks/export?completed=maybe", http.StatusBadRequest, "invalid completed flag"},
+		{"Server error", "/tasks/export", http.StatusInternalServerError, "error on server"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", tt.url, nil))
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, problemDetail(t, rr))
+		})
+	}
+}
+
+// failingWriter is a response writer whose connection is gone.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestExportTasksLateErrors(t *testing.T) {
+	task := &todo.Task{ID: 1, Title: "Test Task"}
+	exportOne := func(args mock.Arguments) {
+		fn := args.Get(2).(func(*todo.Task) error)
+		assert.NoError(t, fn(task))
+	}
+
+	t.Run("Query fails before anything is sent", func(t *testing.T) {
+		router, mockUsecase := setupCSVRouter()
+		mockUsecase.On("ExportTasks", mock.Anything, todo.TaskFilter{}, mock.Anything).
+			Run(exportOne).Return(service.ErrOnServer)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/tasks/export", nil))
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Empty(t, rr.Header().Get("Content-Disposition"))
+		assert.Equal(t, "error on server", problemDetail(t, rr))
+	})
+
+	t.Run("Flush fails", func(t *testing.T) {
+		router, mockUsecase := setupCSVRouter()
+		mockUsecase.On("ExportTasks", mock.Anything, todo.TaskFilter{}, mock.Anything).
+			Run(exportOne).Return(nil)
+
+		rr := failingWriter{httptest.NewRecorder()}
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/tasks/export", nil))
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestImportTasks(t *testing.T) {
+	router, mockUsecase := setupCSVRouter()
+
+	date := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	day := time.Date(2024, 6, 8, 0, 0, 0, 0, time.UTC)
+	expected := []*todo.Task{
+		{Title: "First", Description: "desc", DueDate: &date},
+		{Title: "Second", DueDate: &day, Completed: true, AllDay: true},
+	}
+	mockUsecase.On("ImportTasks", mock.Anything, expected).Return(nil)
+
+	body := "Name,description,Deadline,completed,extra\n" +
+		"First,desc,2024-06-07T15:00:00Z,,x\n" +
+		"Second,,2024-06-08,true,y\n"
+	req := httptest.NewRequest("POST", "/tasks/import?map=Name:title&map=Deadline:due_date", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var result ImportResult
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Equal(t, ImportResult{Rows: 2, Imported: 2}, result)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestImportTasksInvalidRows(t *testing.T) {
+	router, mockUsecase := setupCSVRouter()
+
+	body := "title,due_date,completed\n" +
+		"Valid,2024-06-07,false\n" +
+		",tomorrow,maybe\n" +
+		"\"multi\nline\",2024-06-07,\n" +
+		"Bad,2024-06-07,no\n" +
+		strings.Repeat("a", 256) + ",2100-01-01,\n"
+
+	for _, url := range []string{"/tasks/import", "/tasks/import?dry_run=true"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", url, strings.NewReader(body)))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		var result ImportResult
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+		assert.Equal(t, 5, result.Rows)
+		assert.Equal(t, 0, result.Imported)
+		assert.Equal(t, []ImportError{
+			{Row: 3, Column: "title", Message: "title cannot be empty"},
+			{Row: 3, Column: "due_date", Message: "due_date must be RFC 3339 or YYYY-MM-DD"},
+			{Row: 3, Column: "completed", Message: "completed must be true or false"},
+			{Row: 6, Column: "completed", Message: "completed must be true or false"},
+			{Row: 7, Column: "title", Message: "title must be at most 255 characters"},
+			{Row: 7, Column: "due_date", Message: "due_date must be from 1970-01-01 to before 2100-01-01"},
+		}, result.Errors)
+	}
+	mockUsecase.AssertNotCalled(t, "ImportTasks", mock.Anything, mock.Anything)
+}
+
+func TestImportTasksDryRun(t *testing.T) {
+	router, mockUsecase := setupCSVRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/tasks/import?dry_run=true", strings.NewReader("title,due_date\nTask,2024-06-07\n")))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"dry_run":true,"rows":1,"imported":0}`, rr.Body.String())
+	mockUsecase.AssertNotCalled(t, "ImportTasks", mock.Anything, mock.Anything)
+}
+
+func TestImportTasksBadFile(t *testing.T) {
+	router, _ := setupCSVRouter()
+
+	tests := []struct {
+		name         string
+		url          string
+		body         string
+		expectedBody string
+	}{
+		{"Empty file", "/tasks/import", "", "empty file"},
+		{"Missing column", "/tasks/import", "title\nTask\n", "missing due_date column"},
+		{"Invalid mapping", "/tasks/import?map=Name", "title,due_date\n", "invalid mapping \"Name\""},
+		{"Invalid dry run", "/tasks/import?dry_run=maybe", "title,due_date\n", "invalid dry_run flag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("POST", tt.url, strings.NewReader(tt.body)))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Equal(t, tt.expectedBody, problemDetail(t, rr))
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"net/http"
+	"sberTestTask/internal/httpapi"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/validation"
+	"time"
+)
+
+// CreateTaskRequest is the body of POST /tasks.
+type CreateTaskRequest struct {
+	Title       string     `json:"title" example:"Buy milk"`
+	Description string     `json:"description,omitempty" example:"2 litres"`
+	DueDate     *time.Time `json:"due_date" swaggertype:"string" format:"date-time" example:"2024-06-07T15:00:00Z"`
+	Completed   bool       `json:"completed"`
+	// AllDay makes the task due on the calendar day of due_date.
+	AllDay bool `json:"all_day,omitempty"`
+}
+
+func (req *CreateTaskRequest) task() *todo.Task {
+	return &todo.Task{
+		Title:       req.Title,
+		Description: req.Description,
+		DueDate:     req.DueDate,
+		Completed:   req.Completed,
+		AllDay:      req.AllDay,
+	}
+}
+
+// UpdateTaskRequest is the body of PUT /tasks/{id}. Only the fields present
+// are changed.
+type UpdateTaskRequest struct {
+	Title       *string `json:"title,omitempty" example:"Buy milk"`
+	Description *string `json:"description,omitempty" example:"2 litres"`
+	// DueDate is RFC 3339, or YYYY-MM-DD, which makes the task all-day
+	// unless all_day is given too.
+	DueDate   *string `json:"due_date,omitempty" example:"2024-06-07T15:00:00Z"`
+	Completed *bool   `json:"completed,omitempty"`
+	AllDay    *bool   `json:"all_day,omitempty"`
+}
+
+// TaskResponse is a task as returned by the API.
+type TaskResponse struct {
+	ID          int        `json:"id,omitempty" example:"1"`
+	Title       string     `json:"title" example:"Buy milk"`
+	Description string     `json:"description,omitempty" example:"2 litres"`
+	DueDate     *time.Time `json:"due_date" swaggertype:"string" format:"date-time" example:"2024-06-07T15:00:00Z"`
+	Completed   bool       `json:"completed"`
+	AllDay      bool       `json:"all_day,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty" swaggertype:"string" format:"date-time" example:"2024-06-07T15:00:00Z"`
+	// Overdue, DueIn (seconds, negative once due) and DaysOverdue are
+	// computed in the caller's time zone.
+	Overdue     *bool  `json:"overdue,omitempty"`
+	DueIn       *int64 `json:"due_in,omitempty" example:"3600"`
+	DaysOverdue *int   `json:"days_overdue,omitempty" example:"0"`
+}
+
+func newTaskResponse(task *todo.Task) *TaskResponse {
+	return &TaskResponse{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		DueDate:     task.DueDate,
+		Completed:   task.Completed,
+		AllDay:      task.AllDay,
+		UpdatedAt:   task.UpdatedAt,
+		Overdue:     task.Overdue,
+		DueIn:       task.DueIn,
+		DaysOverdue: task.DaysOverdue,
+	}
+}
+
+// PageResponse is one page of GET /tasks.
+type PageResponse struct {
+	CountPage int             `json:"count_page" example:"3"`
+	CurPage   int             `json:"cur_page" example:"1"`
+	Tasks     []*TaskResponse `json:"tasks"`
+}
+
+func newPageResponse(pages *todo.Pages) *PageResponse {
+	resp := &PageResponse{CountPage: pages.CountPage, CurPage: pages.CurPage, Tasks: make([]*TaskResponse, len(pages.Tasks))}
+	for i, task := range pages.Tasks {
+		resp.Tasks[i] = newTaskResponse(task)
+	}
+	return resp
+}
+
+// StatsResponse is the body of GET /stats.
+type StatsResponse struct {
+	Total     int `json:"total" example:"12"`
+	Open      int `json:"open" example:"5"`
+	Completed int `json:"completed" example:"7"`
+	Overdue   int `json:"overdue" example:"2"`
+	// AvgTimeToComplete is the mean number of seconds from creation to
+	// completion, null when no completed task has both.
+	AvgTimeToComplete *float64                    `json:"avg_time_to_complete" example:"86400"`
+	Completion        []*CompletionBucketResponse `json:"completion"`
+	Upcoming          []*DayCountResponse         `json:"upcoming"`
+}
+
+// CompletionBucketResponse counts the tasks due in a day or week, named by
+// its first day, and how many of them are completed.
+type CompletionBucketResponse struct {
+	Start     string  `json:"start" example:"2024-06-03"`
+	Due       int     `json:"due" example:"4"`
+	Completed int     `json:"completed" example:"3"`
+	Rate      float64 `json:"rate" example:"0.75"`
+}
+
+// DayCountResponse counts the open tasks due on a day.
+type DayCountResponse struct {
+	Date  string `json:"date" example:"2024-06-07"`
+	Count int    `json:"count" example:"2"`
+}
+
+func newStatsResponse(stats *todo.Stats) *StatsResponse {
+	resp := &StatsResponse{
+		Total:             stats.Total,
+		Open:              stats.Open,
+		Completed:         stats.Completed,
+		Overdue:           stats.Overdue,
+		AvgTimeToComplete: stats.AvgTimeToComplete,
+		Completion:        make([]*CompletionBucketResponse, len(stats.Completion)),
+		Upcoming:          make([]*DayCountResponse, len(stats.Upcoming)),
+	}
+	for i, b := range stats.Completion {
+		resp.Completion[i] = &CompletionBucketResponse{Start: b.Start, Due: b.Due, Completed: b.Completed, Rate: b.Rate}
+	}
+	for i, c := range stats.Upcoming {
+		resp.Upcoming[i] = &DayCountResponse{Date: c.Date, Count: c.Count}
+	}
+	return resp
+}
+
+// ImportResult reports the outcome of a CSV or iCalendar import. Nothing
+// is imported when Errors is not empty.
+type ImportResult struct {
+	DryRun   bool          `json:"dry_run"`
+	Rows     int           `json:"rows" example:"2"`
+	Imported int           `json:"imported" example:"2"`
+	Errors   []ImportError `json:"errors,omitempty"`
+}
+
+// ImportError is an invalid row or item of an import. Column names the
+// CSV column or iCalendar property.
+type ImportError struct {
+	Row     int    `json:"row" example:"3"`
+	Column  string `json:"column,omitempty" example:"due_date"`
+	Message string `json:"message" example:"due_date must be a date"`
+}
+
+// FeedTokenResponse is the body of GET /tasks/feed-token.
+type FeedTokenResponse struct {
+	Token string `json:"token"`
+	URL   string `json:"url" example:"/v1/tasks.ics?token=ZGV2ZWxvcGVy.c2lnbmF0dXJl"`
+}
+
+// serverFields are task fields the server controls. A request setting one
+// is rejected rather than silently ignored.
+var serverFields = []string{"id", "updated_at", "overdue", "due_in", "days_overdue"}
+
+// decode reads the request DTO v with httpapi.Decode, rejecting the task
+// fields the server controls.
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, v any) (validation.Errors, bool) {
+	return httpapi.Decode(w, r, v, h.maxBodySize, serverFields...)
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sberTestTask/internal/httpapi"
+	"sberTestTask/internal/todo"
+	serviceMock "sberTestTask/internal/todo/tests/mocks/serviceMock"
+	"sberTestTask/internal/validation"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestServerFieldsRejected(t *testing.T) {
+	router, mockUsecase := setupRouterWithMock()
+	router.Put("/tasks/{id}", NewHandler(mockUsecase).UpdateTask)
+	due := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	mockUsecase.On("GetTask", mock.Anything, 1).Return(&todo.Task{ID: 1, Title: "Task", DueDate: &due}, nil)
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		expectedStatus int
+		expectedErrors validation.Errors
+	}{
+		{"create with id", "POST", "/tasks", `{"id":7,"title":"Task","due_date":"2024-06-07T15:00:00Z"}`, http.StatusUnprocessableEntity,
+			validation.Errors{{Field: "id", Message: "is set by the server"}}},
+		{"create with other case", "POST", "/tasks", `{"ID":7,"title":"Task","due_date":"2024-06-07T15:00:00Z"}`, http.StatusUnprocessableEntity,
+			validation.Errors{{Field: "ID", Message: "is not a known field"}}},
+		{"update with updated_at", "PUT", "/tasks/1", `{"updated_at":"2024-06-07T15:00:00Z"}`, http.StatusUnprocessableEntity,
+			validation.Errors{{Field: "updated_at", Message: "is set by the server"}}},
+		{"update with computed field", "PUT", "/tasks/1", `{"title":"New","overdue":false}`, http.StatusUnprocessableEntity,
+			validation.Errors{{Field: "overdue", Message: "is set by the server"}}},
+		{"update with wrong type", "PUT", "/tasks/1", `{"completed":"yes"}`, http.StatusUnprocessableEntity,
+			validation.Errors{{Field: "completed", Message: "must be true or false"}}},
+		{"update with invalid date", "PUT", "/tasks/1", `{"due_date":"tomorrow"}`, http.StatusUnprocessableEntity,
+			validation.Errors{{Field: "due_date", Message: "must be RFC 3339 or YYYY-MM-DD"}}},
+		{"not an object", "POST", "/tasks", `[]`, http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body)))
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+			var problem httpapi.Problem
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+			assert.Equal(t, tt.expectedErrors, problem.Errors)
+		})
+	}
+	mockUsecase.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything)
+	mockUsecase.AssertNotCalled(t, "UpdateTask", mock.Anything, mock.Anything)
+}
+
+func TestValidation(t *testing.T) {
+	mockUsecase := new(serviceMock.MockTodoUsecase)
+	router := chi.NewRouter()
+	handler := NewHandler(mockUsecase, WithMaxBodySize(1024))
+	router.Post("/tasks", handler.CreateTask)
+	router.Put("/tasks/{id}", handler.UpdateTask)
+	due := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	mockUsecase.On("GetTask", mock.Anything, 1).Return(&todo.Task{ID: 1, Title: "Task", DueDate: &due}, nil)
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		expectedStatus int
+		expectedErrors validation.Errors
+	}{
+		{"all violations at once", "POST", "/tasks", `{"title":" ","color":"red","due_date":"2200-01-01T00:00:00Z","completed":1}`, http.StatusUnprocessableEntity, validation.Errors{
+			{Field: "color", Message: "is not a known field"},
+			{Field: "completed", Message: "must be true or false"},
+			{Field: "title", Message: "cannot be empty"},
+			{Field: "due_date", Message: "must be from 1970-01-01 to before 2100-01-01"},
+		}},
+		{"task rules", "POST", "/tasks", `{"title":"` + strings.Repeat("ж", 256) + `","due_date":"1969-12-31T00:00:00Z"}`, http.StatusUnprocessableEntity, validation.Errors{
+			{Field: "title", Message: "must be at most 255 characters"},
+			{Field: "due_date", Message: "must be from 1970-01-01 to before 2100-01-01"},
+		}},
+		{"missing due date", "POST", "/tasks", `{"title":"Task"}`, http.StatusUnprocessableEntity, validation.Errors{
+			{Field: "due_date", Message: "is required"},
+		}},
+		{"update merged with stored task", "PUT", "/tasks/1", `{"title":"","due_date":"2100-01-01"}`, http.StatusUnprocessableEntity, validation.Errors{
+			{Field: "title", Message: "cannot be empty"},
+			{Field: "due_date", Message: "must be from 1970-01-01 to before 2100-01-01"},
+		}},
+		{"body too large", "POST", "/tasks", `{"title":"` + strings.Repeat("a", 1024) + `"}`, http.StatusRequestEntityTooLarge, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body)))
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			var problem httpapi.Problem
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+			assert.Equal(t, tt.expectedStatus, problem.Status)
+			assert.Equal(t, tt.expectedErrors, problem.Errors)
+		})
+	}
+	mockUsecase.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything)
+	mockUsecase.AssertNotCalled(t, "UpdateTask", mock.Anything, mock.Anything)
+}
+
+func TestTaskResponse(t *testing.T) {
+	due := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	overdue, dueIn, days := true, int64(-90000), 2
+	task := &todo.Task{ID: 1, Title: "Task", Description: "Text", DueDate: &due, AllDay: true, UpdatedAt: &updated, Overdue: &overdue, DueIn: &dueIn, DaysOverdue: &days}
+
+	// The response mirrors the task field by field.
+	assert.Equal(t, &TaskResponse{ID: 1, Title: "Task", Description: "Text", DueDate: &due, AllDay: true, UpdatedAt: &updated, Overdue: &overdue, DueIn: &dueIn, DaysOverdue: &days}, newTaskResponse(task))
+
+	page := newPageResponse(&todo.Pages{CountPage: 2, CurPage: 1, Tasks: []*todo.Task{}})
+	assert.Equal(t, &PageResponse{CountPage: 2, CurPage: 1, Tasks: []*TaskResponse{}}, page)
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sberTestTask/internal/httpapi"
+	"sberTestTask/internal/logger"
+	"sberTestTask/internal/todo/events"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+type EventsHandler struct {
+	bus      *events.Bus
+	done     chan struct{}
+	shutdown sync.Once
+}
+
+func NewEventsHandler(bus *events.Bus) *EventsHandler {
+	return &EventsHandler{bus: bus, done: make(chan struct{})}
+}
+
+// Shutdown ends all open streams so that the HTTP server can finish its
+// graceful shutdown. Clients are expected to reconnect to another replica.
+func (h *EventsHandler) Shutdown() {
+	h.shutdown.Do(func() { close(h.done) })
+}
+
+// @Summary Stream task changes
+// @Description Server-Sent Events feed of created, updated and deleted tasks. Send Last-Event-ID to resume after a reconnect.
+// @Tags tasks
+// @Produce text/event-stream
+// @Param Last-Event-ID header int false "Id of the last event received"
+// @Success 200 {object} events.Event "Event stream"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Router /tasks/events [get]
+func (h *EventsHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	var lastEventID uint64
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			httpapi.WriteProblem(w, http.StatusBadRequest, "invalid Last-Event-ID", nil)
+			return
+		}
+		lastEventID = id
+	}
+
+	// The feed is long-lived, so the server write timeout must not apply.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logger.FromContext(r.Context()).WarnContext(r.Context(), "disable write deadline", slog.String("error", err.Error()))
+	}
+
+	sub, missed := h.bus.Subscribe(lastEventID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range missed {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event events.Event) error {
+	data, err := json.Marshal(event.Task)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/events"
+)
+
+func TestEventsStream(t *testing.T) {
+	bus := events.NewBus(10)
+	handler := NewEventsHandler(bus)
+	srv := httptest.NewServer(http.HandlerFunc(handler.Stream))
+	defer srv.Close()
+	defer handler.Shutdown()
+
+	assert.NoError(t, bus.Publish(context.Background(), events.TaskCreated, &todo.Task{ID: 1, Title: "Old"}))
+	assert.NoError(t, bus.Publish(context.Background(), events.TaskUpdated, &todo.Task{ID: 1, Title: "Resumed"}))
+
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			assert.NoError(t, err)
+			if line == "\n" {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	assert.Equal(t, "id: 2\nevent: updated\ndata: {\"id\":1,\"title\":\"Resumed\",\"due_date\":null,\"completed\":false}\n", readEvent())
+
+	assert.NoError(t, bus.Publish(context.Background(), events.TaskDeleted, &todo.Task{ID: 1}))
+	assert.Equal(t, "id: 3\nevent: deleted\ndata: {\"id\":1,\"title\":\"\",\"due_date\":null,\"completed\":false}\n", readEvent())
+}
+
+func TestEventsInvalidLastEventID(t *testing.T) {
+	handler := NewEventsHandler(events.NewBus(10))
+
+	req := httptest.NewRequest("GET", "/tasks/events", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	rr := httptest.NewRecorder()
+	handler.Stream(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -4,9 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/go-chi/chi/v5"
+	"log/slog"
 	"net/http"
+	"sberTestTask/internal/httpapi"
+	"sberTestTask/internal/logger"
 	"sberTestTask/internal/todo"
 	"sberTestTask/internal/todo/service"
+	"sberTestTask/internal/validation"
 	"strconv"
 	"time"
 )
@@ -14,14 +18,32 @@ import (
 const (
 	defaultPage  = 1
 	defaultLimit = 10
+
+	// DefaultMaxBodySize limits JSON request bodies.
+	DefaultMaxBodySize = httpapi.DefaultMaxBodySize
 )
 
 type Handler struct {
-	uc service.TodoUsecase
+	uc          service.TodoUsecase
+	maxBodySize int64
 }
 
-func NewHandler(uc service.TodoUsecase) *Handler {
-	return &Handler{uc: uc}
+type HandlerOption func(*Handler)
+
+// WithMaxBodySize limits JSON request bodies to n bytes; larger requests
+// get 413. Imports have their own limit.
+func WithMaxBodySize(n int64) HandlerOption {
+	return func(h *Handler) {
+		h.maxBodySize = n
+	}
+}
+
+func NewHandler(uc service.TodoUsecase, opts ...HandlerOption) *Handler {
+	h := &Handler{uc: uc, maxBodySize: DefaultMaxBodySize}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // @Summary Create a new task
@@ -29,27 +51,31 @@ func NewHandler(uc service.TodoUsecase) *Handler {
 // @Tags tasks
 // @Accept  json
 // @Produce  json
-// @Param task body todo.Task true "Task to create"
-// @Success 201 {object} todo.Task "Task created successfully"
-// @Failure 400 {object} todo.ErrorResponse "Bad Request"
-// @Failure 500 {object} todo.ErrorResponse "Internal Server Error"
+// @Param task body CreateTaskRequest true "Task to create"
+// @Success 201 {object} TaskResponse "Task created successfully"
+// @Failure 400 {object} httpapi.Problem "Malformed JSON"
+// @Failure 413 {object} httpapi.Problem "Body too large"
+// @Failure 422 {object} httpapi.Problem "Invalid fields"
+// @Failure 500 {object} httpapi.Problem "Internal Server Error"
 // @Router /tasks [post]
 func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
-	var task todo.Task
-	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var req CreateTaskRequest
+	errs, ok := h.decode(w, r, &req)
+	if !ok {
 		return
 	}
-	if err := validateTask(&task); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	task := req.task()
+	if err := withFieldErrors(errs, task.Validate()); err != nil {
+		httpapi.WriteInvalid(w, err)
 		return
 	}
-	if err := h.uc.CreateTask(r.Context(), &task); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.uc.CreateTask(r.Context(), task); err != nil {
+		httpapi.WriteProblem(w, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
+	logger.AddAttrs(r.Context(), slog.Int("task_id", task.ID))
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(task)
+	json.NewEncoder(w).Encode(newTaskResponse(task))
 }
 
 // @Summary Get a task by ID
@@ -57,22 +83,33 @@ func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
 // @Tags tasks
 // @Produce  json
 // @Param id path int true "Task ID"
-// @Success 200 {object} todo.Task "Task found"
-// @Failure 400 {object} todo.ErrorResponse "Bad Request"
-// @Failure 404 {object} todo.ErrorResponse "Not Found"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Param If-Modified-Since header string false "Last-Modified of a cached copy; ignored while computed fields follow the clock"
+// @Success 200 {object} TaskResponse "Task found"
+// @Success 304 "Not Modified"
+// @Header 200,304 {string} ETag "Weak validator of the task"
+// @Header 200,304 {string} Last-Modified "Time of the last write"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 404 {object} httpapi.Problem "Not Found"
 // @Router /tasks/{id} [get]
 func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
+	logger.AddAttrs(r.Context(), slog.Int("task_id", id))
 	task, err := h.uc.GetTask(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		httpapi.WriteProblem(w, http.StatusNotFound, err.Error(), nil)
 		return
 	}
-	json.NewEncoder(w).Encode(task)
+	resp := newTaskResponse(task)
+	// Without due_in the representation only changes with the stored task.
+	if notModified(w, r, taskETag(resp), lastModified(resp), resp.DueIn == nil) {
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
 }
 
 // @Summary Update a task
@@ -82,44 +119,47 @@ func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Task ID"
-// @Param task body map[string]interface{} true "Task updates"
-// @Success 200 {object} todo.Task "Task updated successfully"
-// @Failure 400 {object} todo.ErrorResponse "Bad Request"
-// @Failure 404 {object} todo.ErrorResponse "Not Found"
-// @Failure 500 {object} todo.ErrorResponse "Internal Server Error"
+// @Param task body UpdateTaskRequest true "Task updates"
+// @Success 200 {object} TaskResponse "Task updated successfully"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 404 {object} httpapi.Problem "Not Found"
+// @Failure 413 {object} httpapi.Problem "Body too large"
+// @Failure 422 {object} httpapi.Problem "Invalid fields"
+// @Failure 500 {object} httpapi.Problem "Internal Server Error"
 // @Router /tasks/{id} [put]
 func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
+	logger.AddAttrs(r.Context(), slog.Int("task_id", id))
 
 	existingTask, err := h.uc.GetTask(r.Context(), id)
 	if err != nil {
-		http.Error(w, "task not found", http.StatusNotFound)
+		httpapi.WriteProblem(w, http.StatusNotFound, "task not found", nil)
 		return
 	}
 
-	var updates map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var req UpdateTaskRequest
+	errs, ok := h.decode(w, r, &req)
+	if !ok {
 		return
 	}
 
-	updatedTask, err := applyUpdates(*existingTask, updates)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	updatedTask, err := applyUpdates(*existingTask, req)
+	if err = withFieldErrors(errs, err); err != nil {
+		httpapi.WriteInvalid(w, err)
 		return
 	}
 
 	if err := h.uc.UpdateTask(r.Context(), &updatedTask); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		httpapi.WriteProblem(w, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(updatedTask)
+	json.NewEncoder(w).Encode(newTaskResponse(&updatedTask))
 }
 
 // @Summary Delete a task
@@ -127,23 +167,24 @@ func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 // @Tags tasks
 // @Param id path int true "Task ID"
 // @Success 200 {string} string "OK"
-// @Failure 400 {object} todo.ErrorResponse "Bad Request"
-// @Failure 404 {object} todo.ErrorResponse "Not Found"
-// @Failure 500 {object} todo.ErrorResponse "Internal Server Error"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 404 {object} httpapi.Problem "Not Found"
+// @Failure 500 {object} httpapi.Problem "Internal Server Error"
 // @Router /tasks/{id} [delete]
 func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
+	logger.AddAttrs(r.Context(), slog.Int("task_id", id))
 	if _, err := h.uc.GetTask(r.Context(), id); err != nil {
-		http.Error(w, service.ErrIdNotFound.Error(), http.StatusNotFound)
+		httpapi.WriteProblem(w, http.StatusNotFound, service.ErrIdNotFound.Error(), nil)
 		return
 	}
 	err = h.uc.DeleteTask(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		httpapi.WriteProblem(w, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -155,32 +196,24 @@ func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 // @Produce  json
 // @Param completed query bool false "Filter by completion status"
 // @Param date query string false "Filter by due date" Format(date) example(2024-06-07) name(2024-06-07)
+// @Param overdue query bool false "Filter by overdue status"
+// @Param tz query string false "IANA time zone of the date filter and computed fields" example(Europe/Moscow)
 // @Param limit query int false "Number of tasks per page"
 // @Param page query int false "Page number"
-// @Success 200 {object} todo.Pages "List of tasks"
-// @Failure 400 {object} todo.ErrorResponse "Bad Request"
-// @Failure 500 {object} todo.ErrorResponse "Internal Server Error"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} PageResponse "List of tasks"
+// @Success 304 "Not Modified"
+// @Header 200,304 {string} ETag "Weak validator of the page"
+// @Header 200,304 {string} Last-Modified "Latest write among the listed tasks"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 500 {object} httpapi.Problem "Internal Server Error"
 // @Router /tasks [get]
 func (h *Handler) ListTasks(w http.ResponseWriter, r *http.Request) {
 
-	var completed *bool
-	if completedStr := r.URL.Query().Get("completed"); completedStr != "" {
-		completedVal, err := strconv.ParseBool(completedStr)
-		if err != nil {
-			http.Error(w, "invalid completed flag", http.StatusBadRequest)
-			return
-		}
-		completed = &completedVal
-	}
-
-	var dueDate *time.Time
-	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
-		parsedDate, err := time.Parse(time.DateOnly, dateStr)
-		if err != nil {
-			http.Error(w, "invalid date format", http.StatusBadRequest)
-			return
-		}
-		dueDate = &parsedDate
+	filter, err := parseFilters(r)
+	if err != nil {
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
+		return
 	}
 
 	limitStr := r.URL.Query().Get("limit")
@@ -195,46 +228,82 @@ func (h *Handler) ListTasks(w http.ResponseWriter, r *http.Request) {
 		page = defaultPage
 	}
 
-	pages, err := h.uc.ListTasks(r.Context(), completed, dueDate, limit, page)
+	pages, err := h.uc.ListTasks(r.Context(), filter, limit, page)
 	if err != nil {
-		http.Error(w, "error retrieving tasks", http.StatusInternalServerError)
+		httpapi.WriteProblem(w, http.StatusInternalServerError, "error retrieving tasks", nil)
+		return
+	}
+	resp := newPageResponse(pages)
+	if notModified(w, r, pagesETag(resp), lastModified(resp.Tasks...), false) {
 		return
 	}
-	json.NewEncoder(w).Encode(pages)
+	json.NewEncoder(w).Encode(resp)
 }
-func applyUpdates(task todo.Task, updates map[string]interface{}) (todo.Task, error) {
-	for key, value := range updates {
-		switch key {
-		case "title":
-			if v, ok := value.(string); ok {
-				task.Title = v
-			}
-		case "description":
-			if v, ok := value.(string); ok {
-				task.Description = v
-			}
-		case "due_date":
-			if v, ok := value.(string); ok {
-				dueDate, err := time.Parse(time.RFC3339, v)
-				if err != nil {
-					return task, err
-				}
-				task.DueDate = &dueDate
-			}
-		case "completed":
-			if v, ok := value.(bool); ok {
-				task.Completed = v
-			}
+
+// parseFilters reads the completed, date and overdue query parameters
+// shared by ListTasks and the exports.
+func parseFilters(r *http.Request) (todo.TaskFilter, error) {
+	var filter todo.TaskFilter
+	if completedStr := r.URL.Query().Get("completed"); completedStr != "" {
+		completedVal, err := strconv.ParseBool(completedStr)
+		if err != nil {
+			return filter, errors.New("invalid completed flag")
 		}
+		filter.Completed = &completedVal
 	}
-	return task, nil
+
+	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+		parsedDate, err := time.Parse(time.DateOnly, dateStr)
+		if err != nil {
+			return filter, errors.New("invalid date format")
+		}
+		filter.DueDate = &parsedDate
+	}
+
+	if overdueStr := r.URL.Query().Get("overdue"); overdueStr != "" {
+		overdue, err := strconv.ParseBool(overdueStr)
+		if err != nil {
+			return filter, errors.New("invalid overdue flag")
+		}
+		filter.Overdue = &overdue
+	}
+	return filter, nil
 }
-func validateTask(task *todo.Task) error {
-	if task.Title == "" {
-		return errors.New("title cannot be empty")
+
+// applyUpdates returns task with the fields present in req changed, or
+// validation.Errors for every invalid field of the result.
+func applyUpdates(task todo.Task, req UpdateTaskRequest) (todo.Task, error) {
+	var errs validation.Errors
+	if req.Title != nil {
+		task.Title = *req.Title
+	}
+	if req.Description != nil {
+		task.Description = *req.Description
+	}
+	if req.AllDay != nil {
+		task.AllDay = *req.AllDay
+	}
+	if req.DueDate != nil {
+		dueDate, err := time.Parse(time.RFC3339, *req.DueDate)
+		if err != nil {
+			// A bare date makes the task all-day unless all_day says
+			// otherwise.
+			dueDate, err = time.Parse(time.DateOnly, *req.DueDate)
+			if err == nil && req.AllDay == nil {
+				task.AllDay = true
+			}
+		}
+		if err != nil {
+			errs.Add("due_date", "must be RFC 3339 or YYYY-MM-DD")
+		} else {
+			task.DueDate = &dueDate
+		}
+	}
+	if req.Completed != nil {
+		task.Completed = *req.Completed
 	}
-	if task.DueDate == nil {
-		return errors.New("missed data field")
+	if err := task.Validate(); err != nil {
+		errs = append(errs, err.(validation.Errors)...)
 	}
-	return nil
+	return task, errs.Err()
 }
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +10,8 @@ import (
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
+	"sberTestTask/internal/auth"
+	"sberTestTask/internal/httpapi"
 	"sberTestTask/internal/todo"
 	"sberTestTask/internal/todo/service"
 	"sberTestTask/internal/todo/tests/mocks/serviceMock"
@@ -27,6 +30,17 @@ func setupRouterWithMock() (*chi.Mux, *serviceMock.MockTodoUsecase) {
 
 	return router, mockUsecase
 }
+
+// problemDetail returns the detail of the problem+json body of rr.
+func problemDetail(t *testing.T, rr *httptest.ResponseRecorder) string {
+	t.Helper()
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+	var problem httpapi.Problem
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+	assert.Equal(t, rr.Code, problem.Status)
+	return problem.Detail
+}
+
 func TestCreateTaskServerError(t *testing.T) {
 	router, mockUsecase := setupRouterWithMock()
 
@@ -49,7 +63,7 @@ func TestCreateTaskServerError(t *testing.T) {
 			},
 			mockReturn:     service.ErrOnServer,
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   "error on server\n",
+			expectedBody:   "error on server",
 		},
 	}
 
@@ -102,7 +116,7 @@ func TestCreateTask(t *testing.T) {
 			body:           []byte("Invalid JSON"),
 			mockReturn:     nil,
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   "invalid character 'I' looking for beginning of value\n",
+			expectedBody:   "invalid character 'I' looking for beginning of value",
 		},
 		{
 			name: "Validation Error",
@@ -112,8 +126,8 @@ func TestCreateTask(t *testing.T) {
 				DueDate:     &date,
 			},
 			mockReturn:     nil,
-			expectedStatus: http.StatusBadRequest,
-			expectedBody:   "title cannot be empty\n",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"errors":[{"field":"title","message":"cannot be empty"}]`,
 		},
 	}
 
@@ -228,6 +242,16 @@ func TestUpdateTask(t *testing.T) {
 			expectedStatus:   http.StatusOK,
 			expectedBody:     `{"id":1,"title":"Updated Task","description":"This is a sample task.","due_date":"` + date.Format(time.RFC3339) + `","completed":false}` + "\n",
 		},
+		{
+			name:             "Date Makes Task All-Day",
+			taskID:           "1",
+			existingTask:     mockTask,
+			updates:          map[string]interface{}{"due_date": "2024-06-08"},
+			mockGetReturn:    nil,
+			mockUpdateReturn: nil,
+			expectedStatus:   http.StatusOK,
+			expectedBody:     `{"id":1,"title":"Sample Task","description":"This is a sample task.","due_date":"2024-06-08T00:00:00Z","completed":false,"all_day":true}` + "\n",
+		},
 		{
 			name:             "Task Not Found",
 			taskID:           "2",
@@ -236,7 +260,7 @@ func TestUpdateTask(t *testing.T) {
 			mockGetReturn:    service.ErrIdNotFound,
 			mockUpdateReturn: nil,
 			expectedStatus:   http.StatusNotFound,
-			expectedBody:     "task not found\n",
+			expectedBody:     "task not found",
 		},
 		{
 			name:             "Invalid JSON",
@@ -246,7 +270,7 @@ func TestUpdateTask(t *testing.T) {
 			mockGetReturn:    nil,
 			mockUpdateReturn: nil,
 			expectedStatus:   http.StatusBadRequest,
-			expectedBody:     "invalid character 'I' looking for beginning of value\n",
+			expectedBody:     "invalid character 'I' looking for beginning of value",
 		},
 		{
 			name:             "Internal Server Error",
@@ -256,7 +280,7 @@ func TestUpdateTask(t *testing.T) {
 			mockGetReturn:    nil,
 			mockUpdateReturn: errors.New("internal error"),
 			expectedStatus:   http.StatusInternalServerError,
-			expectedBody:     "internal error\n",
+			expectedBody:     "internal error",
 		},
 	}
 
@@ -271,7 +295,10 @@ func TestUpdateTask(t *testing.T) {
 				mockUsecase.On("GetTask", mock.Anything, mock.AnythingOfType("int")).Return(nil, tt.mockGetReturn)
 			}
 			if tt.updates != nil {
-				updatedTask, _ := applyUpdates(*mockTask, tt.updates)
+				var req UpdateTaskRequest
+				body, _ := json.Marshal(tt.updates)
+				assert.NoError(t, json.Unmarshal(body, &req))
+				updatedTask, _ := applyUpdates(*mockTask, req)
 				mockUsecase.On("UpdateTask", mock.Anything, &updatedTask).Return(tt.mockUpdateReturn)
 			}
 
@@ -324,7 +351,7 @@ func TestDeleteTask(t *testing.T) {
 			mockGetReturn:    nil,
 			mockDeleteReturn: nil,
 			expectedStatus:   http.StatusBadRequest,
-			expectedBody:     "strconv.Atoi: parsing \"abc\": invalid syntax\n",
+			expectedBody:     "invalid syntax",
 		},
 		{
 			name:             "Task Not Found",
@@ -332,7 +359,7 @@ func TestDeleteTask(t *testing.T) {
 			mockGetReturn:    service.ErrIdNotFound,
 			mockDeleteReturn: nil,
 			expectedStatus:   http.StatusNotFound,
-			expectedBody:     "id not found\n",
+			expectedBody:     "id not found",
 		},
 		{
 			name:             "Internal Server Error",
@@ -340,7 +367,7 @@ func TestDeleteTask(t *testing.T) {
 			mockGetReturn:    nil,
 			mockDeleteReturn: service.ErrOnServer,
 			expectedStatus:   http.StatusInternalServerError,
-			expectedBody:     "error on server\n",
+			expectedBody:     "error on server",
 		},
 	}
 
@@ -426,7 +453,7 @@ func TestListTasks(t *testing.T) {
 			mockReturn:      nil,
 			mockReturnError: nil,
 			expectedStatus:  http.StatusBadRequest,
-			expectedBody:    "invalid completed flag\n",
+			expectedBody:    "invalid completed flag",
 			isJson:          false,
 		},
 		{
@@ -435,7 +462,16 @@ func TestListTasks(t *testing.T) {
 			mockReturn:      nil,
 			mockReturnError: nil,
 			expectedStatus:  http.StatusBadRequest,
-			expectedBody:    "invalid date format\n",
+			expectedBody:    "invalid date format",
+			isJson:          false,
+		},
+		{
+			name:            "Invalid Overdue Flag",
+			queryParams:     "?overdue=maybe",
+			mockReturn:      nil,
+			mockReturnError: nil,
+			expectedStatus:  http.StatusBadRequest,
+			expectedBody:    "invalid overdue flag",
 			isJson:          false,
 		},
 		{
@@ -444,7 +480,7 @@ func TestListTasks(t *testing.T) {
 			mockReturn:      nil,
 			mockReturnError: service.ErrOnServer,
 			expectedStatus:  http.StatusInternalServerError,
-			expectedBody:    "error retrieving tasks\n",
+			expectedBody:    "error retrieving tasks",
 			isJson:          false,
 		},
 	}
@@ -454,7 +490,7 @@ func TestListTasks(t *testing.T) {
 			mockUsecase.Calls = nil
 			mockUsecase.ExpectedCalls = nil
 
-			mockUsecase.On("ListTasks", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tt.mockReturn, tt.mockReturnError)
+			mockUsecase.On("ListTasks", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tt.mockReturn, tt.mockReturnError)
 
 			req := httptest.NewRequest("GET", "/tasks"+tt.queryParams, nil)
 			rr := httptest.NewRecorder()
@@ -471,3 +507,70 @@ func TestListTasks(t *testing.T) {
 		})
 	}
 }
+
+func TestListTasksOverdueFilter(t *testing.T) {
+	mockUsecase := new(serviceMock.MockTodoUsecase)
+	router := chi.NewRouter()
+	router.Get("/tasks", NewHandler(mockUsecase).ListTasks)
+
+	overdue, days, dueIn := true, 2, int64(-180000)
+	date := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	task := &todo.Task{ID: 1, Title: "Late", DueDate: &date, Overdue: &overdue, DueIn: &dueIn, DaysOverdue: &days}
+	mockUsecase.On("ListTasks", mock.Anything, todo.TaskFilter{Overdue: &overdue}, 10, 1).
+		Return(&todo.Pages{CountPage: 1, CurPage: 1, Tasks: []*todo.Task{task}}, nil)
+
+	req := httptest.NewRequest("GET", "/tasks?overdue=true", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"count_page":1,"cur_page":1,"tasks":[{"id":1,"title":"Late","due_date":"2024-06-07T15:00:00Z","completed":false,"overdue":true,"due_in":-180000,"days_overdue":2}]}`, rr.Body.String())
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestListTasksTimeZone(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+
+	mockUsecase := new(serviceMock.MockTodoUsecase)
+	router := chi.NewRouter()
+	router.Use(auth.NewAuthenticator(map[string]string{"key": "alice"}, false).Middleware)
+	router.Use(timeZoneMiddleware(map[string]*time.Location{"alice": tokyo}))
+	router.Get("/tasks", NewHandler(mockUsecase).ListTasks)
+
+	inZone := func(want *time.Location) interface{} {
+		return mock.MatchedBy(func(ctx context.Context) bool {
+			loc, ok := todo.LocationFromContext(ctx)
+			return ok && loc.String() == want.String()
+		})
+	}
+	page := &todo.Pages{CountPage: 0, CurPage: 0, Tasks: []*todo.Task{}}
+	date := time.Date(2024, 6, 7, 0, 0, 0, 0, time.UTC)
+	mockUsecase.On("ListTasks", inZone(moscow), todo.TaskFilter{DueDate: &date}, 10, 1).Return(page, nil).Once()
+	mockUsecase.On("ListTasks", inZone(tokyo), todo.TaskFilter{}, 10, 1).Return(page, nil).Once()
+
+	tests := []struct {
+		name   string
+		url    string
+		apiKey string
+		status int
+	}{
+		{"tz parameter", "/tasks?date=2024-06-07&tz=Europe/Moscow", "key", http.StatusOK},
+		{"user preference", "/tasks", "key", http.StatusOK},
+		{"invalid zone", "/tasks?tz=Mars/Olympus", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			if tt.apiKey != "" {
+				req.Header.Set(auth.APIKeyHeader, tt.apiKey)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, tt.status, rr.Code)
+		})
+	}
+	mockUsecase.AssertExpectations(t)
+}
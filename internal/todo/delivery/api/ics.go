@@ -0,0 +1,280 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sberTestTask/internal/auth"
+	"sberTestTask/internal/httpapi"
+	"sberTestTask/internal/ical"
+	"sberTestTask/internal/logger"
+	"sberTestTask/internal/todo"
+	"strconv"
+	"time"
+)
+
+// FeedPath is the calendar feed, which also accepts feed tokens.
+const FeedPath = "/tasks.ics"
+
+const (
+	icsProductID = "-//sberTestTask//Todo//EN"
+	icsUIDDomain = "todo-service"
+	// Recurring tasks are expanded into one task per occurrence. Rules
+	// without COUNT or UNTIL are expanded for a year.
+	maxOccurrences    = 500
+	recurrenceHorizon = 365 * 24 * time.Hour
+)
+
+// @Summary Calendar feed
+// @Description iCalendar feed of tasks matching the list filters, as VTODO (default) or VEVENT components. Calendar apps can authenticate with a feed token from /tasks/feed-token in the token query parameter.
+// @Tags tasks
+// @Produce text/calendar
+// @Param token query string false "Feed token"
+// @Param component query string false "vtodo or vevent"
+// @Param completed query bool false "Filter by completion status"
+// @Param date query string false "Filter by due date" Format(date) example(2024-06-07)
+// @Param tz query string false "IANA time zone of the date filter" example(Europe/Moscow)
+// @Success 200 {string} string "iCalendar file"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 401 {object} httpapi.Problem "Invalid feed token or API key"
+// @Failure 500 {object} httpapi.Problem "Internal Server Error"
+// @Router /tasks.ics [get]
+func (h *Handler) TasksFeed(w http.ResponseWriter, r *http.Request) {
+	component := "VTODO"
+	switch r.URL.Query().Get("component") {
+	case "", "vtodo":
+	case "vevent":
+		component = "VEVENT"
+	default:
+		httpapi.WriteProblem(w, http.StatusBadRequest, "component must be vtodo or vevent", nil)
+		return
+	}
+	filter, err := parseFilters(r)
+	if err != nil {
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// As with CSV, nothing is written before the first task so that a
+	// failing query still gets an error status.
+	iw := ical.NewWriter(w)
+	now := time.Now()
+	started := false
+	start := func() {
+		started = true
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="tasks.ics"`)
+		iw.Begin("VCALENDAR")
+		iw.Raw("VERSION", "2.0")
+		iw.Raw("PRODID", icsProductID)
+		iw.Raw("CALSCALE", "GREGORIAN")
+		iw.Text("X-WR-CALNAME", "Tasks")
+	}
+
+	err = h.uc.ExportTasks(r.Context(), filter, func(task *todo.Task) error {
+		if !started {
+			start()
+		}
+		writeTaskComponent(iw, component, task, now)
+		return iw.Flush()
+	})
+	if err != nil {
+		if !started {
+			httpapi.WriteProblem(w, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+		logger.FromContext(r.Context()).ErrorContext(r.Context(), "calendar feed interrupted", slog.String("error", err.Error()))
+		return
+	}
+	if !started {
+		start()
+	}
+	iw.End("VCALENDAR")
+	iw.Flush()
+}
+
+func writeTaskComponent(iw *ical.Writer, component string, task *todo.Task, now time.Time) {
+	// VEVENT requires a start; tasks without a due date only fit a VTODO.
+	if component == "VEVENT" && task.DueDate == nil {
+		return
+	}
+
+	iw.Begin(component)
+	iw.Raw("UID", fmt.Sprintf("task-%d@%s", task.ID, icsUIDDomain))
+	iw.Time("DTSTAMP", now)
+	iw.Text("SUMMARY", task.Title)
+	if task.Description != "" {
+		iw.Text("DESCRIPTION", task.Description)
+	}
+	switch component {
+	case "VTODO":
+		if task.DueDate != nil {
+			writeDue(iw, "DUE", task)
+		}
+		if task.Completed {
+			iw.Raw("STATUS", "COMPLETED")
+		} else {
+			iw.Raw("STATUS", "NEEDS-ACTION")
+		}
+	case "VEVENT":
+		writeDue(iw, "DTSTART", task)
+	}
+	iw.End(component)
+}
+
+// writeDue writes the due date of all-day tasks as a DATE.
+func writeDue(iw *ical.Writer, name string, task *todo.Task) {
+	if task.AllDay {
+		iw.Date(name, task.DueDate.UTC())
+	} else {
+		iw.Time(name, *task.DueDate)
+	}
+}
+
+// @Summary Import tasks from iCalendar
+// @Description Create tasks from the VTODO components of an iCalendar file, using SUMMARY, DESCRIPTION, DUE and STATUS. Recurring items (RRULE with FREQ, INTERVAL, COUNT, UNTIL and weekly BYDAY) become one task per occurrence. If any item is invalid nothing is imported; rows in the result are the lines where the items begin.
+// @Tags tasks
+// @Accept text/calendar
+// @Produce  json
+// @Param file body string true "iCalendar file"
+// @Param dry_run query bool false "Only validate the file"
+// @Success 200 {object} ImportResult "Dry run result"
+// @Success 201 {object} ImportResult "Tasks imported"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 413 {object} httpapi.Problem "File too large"
+// @Failure 422 {object} ImportResult "Invalid items"
+// @Failure 500 {object} httpapi.Problem "Internal Server Error"
+// @Router /tasks/import/ics [post]
+func (h *Handler) ImportICS(w http.ResponseWriter, r *http.Request) {
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	cal, err := ical.Parse(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			err = &httpapi.TooLargeError{What: "file", Limit: maxImportSize}
+		}
+		httpapi.WriteProblem(w, httpapi.BodyErrorStatus(err), err.Error(), nil)
+		return
+	}
+	if cal.Name != "VCALENDAR" {
+		httpapi.WriteProblem(w, http.StatusBadRequest, "expected a VCALENDAR", nil)
+		return
+	}
+
+	result := &ImportResult{}
+	var tasks []*todo.Task
+	for _, c := range cal.Components {
+		if c.Name != "VTODO" {
+			continue
+		}
+		result.Rows++
+		items, errs := parseVTODO(c)
+		result.Errors = append(result.Errors, errs...)
+		tasks = append(tasks, items...)
+		if len(tasks) > maxImportRows {
+			httpapi.WriteProblem(w, http.StatusBadRequest, "file has more than "+strconv.Itoa(maxImportRows)+" tasks", nil)
+			return
+		}
+	}
+	h.importTasks(w, r, tasks, result, dryRun)
+}
+
+// vtodoProps are the VTODO properties a task is read from, in the order
+// their errors are reported; vtodoFields names the task fields among them.
+var (
+	vtodoProps  = []string{"SUMMARY", "DESCRIPTION", "DUE", "RRULE"}
+	vtodoFields = map[string]string{"title": "SUMMARY", "description": "DESCRIPTION", "due_date": "DUE"}
+)
+
+// parseVTODO returns one task per occurrence of the item.
+func parseVTODO(c *ical.Component) ([]*todo.Task, []ImportError) {
+	var errs []ImportError
+	fail := func(prop, msg string) {
+		errs = append(errs, ImportError{Row: c.Line, Column: prop, Message: msg})
+	}
+
+	task := &todo.Task{}
+	if p, ok := c.Prop("SUMMARY"); ok {
+		task.Title = ical.UnescapeText(p.Value)
+	}
+	if p, ok := c.Prop("DESCRIPTION"); ok {
+		task.Description = ical.UnescapeText(p.Value)
+	}
+
+	if p, ok := c.Prop("DUE"); ok {
+		if due, err := p.Time(); err != nil {
+			fail("DUE", "invalid DUE: "+err.Error())
+		} else {
+			task.DueDate, task.AllDay = &due, p.IsDate()
+		}
+	}
+
+	if p, ok := c.Prop("STATUS"); ok {
+		task.Completed = p.Value == "COMPLETED"
+	}
+	if _, ok := c.Prop("COMPLETED"); ok {
+		task.Completed = true
+	}
+
+	p, recurring := c.Prop("RRULE")
+	var rule ical.Recurrence
+	if recurring {
+		var err error
+		if rule, err = ical.ParseRecurrence(p.Value); err != nil {
+			fail("RRULE", err.Error())
+		}
+	}
+	if errs = validateImported(task, errs, c.Line, vtodoProps, vtodoFields); len(errs) > 0 {
+		return nil, errs
+	}
+	if !recurring {
+		return []*todo.Task{task}, nil
+	}
+
+	dates, err := rule.Occurrences(*task.DueDate, task.DueDate.Add(recurrenceHorizon), maxOccurrences)
+	if err != nil {
+		fail("RRULE", err.Error())
+		return nil, errs
+	}
+	tasks := make([]*todo.Task, 0, len(dates))
+	for _, due := range dates {
+		occurrence := *task
+		occurrence.DueDate = &due
+		tasks = append(tasks, &occurrence)
+	}
+	return tasks, nil
+}
+
+// feedTokenHandler issues a feed token for the calling user together with
+// the URL of the feed at feedPath.
+//
+// @Summary Get a calendar feed token
+// @Description Token for subscribing to /tasks.ics from a calendar app. Requires an API key; the token stops working when that key is removed.
+// @Tags tasks
+// @Produce  json
+// @Success 200 {object} FeedTokenResponse "Feed token"
+// @Failure 401 {object} httpapi.Problem "Unauthorized"
+// @Router /tasks/feed-token [get]
+func feedTokenHandler(tokens *auth.FeedTokens, feedPath string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.FromContext(r.Context())
+		if !ok || principal.APIKey == "" {
+			httpapi.WriteProblem(w, http.StatusUnauthorized, "api key required", nil)
+			return
+		}
+		token := tokens.Issue(principal)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(FeedTokenResponse{
+			Token: token,
+			URL:   feedPath + "?" + url.Values{auth.FeedTokenParam: {token}}.Encode(),
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sberTestTask/internal/auth"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/tests/mocks/serviceMock"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupICSRouter(tokens *auth.FeedTokens) (*chi.Mux, *serviceMock.MockTodoUsecase) {
+	mockUsecase := new(serviceMock.MockTodoUsecase)
+	handler := NewHandler(mockUsecase)
+	router := chi.NewRouter()
+	router.Use(auth.NewAuthenticator(map[string]string{"key": "alice"}, true, auth.WithFeedTokens(tokens, FeedPath)).Middleware)
+	router.Get(FeedPath, handler.TasksFeed)
+	router.Post("/tasks/import/ics", handler.ImportICS)
+	router.Get("/tasks/feed-token", feedTokenHandler(tokens, FeedPath))
+	return router, mockUsecase
+}
+
+func TestTasksFeed(t *testing.T) {
+	tokens := auth.NewFeedTokens("secret")
+	router, mockUsecase := setupICSRouter(tokens)
+
+	date := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	day := time.Date(2024, 6, 12, 0, 0, 0, 0, time.UTC)
+	mockUsecase.On("ExportTasks", mock.Anything, todo.TaskFilter{}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(*todo.Task) error)
+			assert.NoError(t, fn(&todo.Task{ID: 1, Title: "Call Bob, Alice", DueDate: &date}))
+			assert.NoError(t, fn(&todo.Task{ID: 2, Title: "Done", DueDate: &date, Completed: true}))
+			assert.NoError(t, fn(&todo.Task{ID: 3, Title: "Holiday", DueDate: &day, AllDay: true}))
+		}).Return(nil)
+
+	// Get a token with the API key, then fetch the feed with the token only.
+	req := httptest.NewRequest("GET", "/tasks/feed-token", nil)
+	req.Header.Set(auth.APIKeyHeader, "key")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var feedToken FeedTokenResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &feedToken))
+	assert.Equal(t, FeedPath+"?token="+feedToken.Token, feedToken.URL)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", feedToken.URL, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", rr.Header().Get("Content-Type"))
+
+	body := rr.Body.String()
+	assert.True(t, strings.HasPrefix(body, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.Contains(t, body, "BEGIN:VTODO\r\nUID:task-1@todo-service\r\n")
+	assert.Contains(t, body, "SUMMARY:Call Bob\\, Alice\r\nDUE:20240607T150000Z\r\nSTATUS:NEEDS-ACTION\r\nEND:VTODO\r\n")
+	assert.Contains(t, body, "SUMMARY:Done\r\nDUE:20240607T150000Z\r\nSTATUS:COMPLETED\r\n")
+	assert.Contains(t, body, "SUMMARY:Holiday\r\nDUE;VALUE=DATE:20240612\r\n")
+	assert.True(t, strings.HasSuffix(body, "END:VCALENDAR\r\n"))
+}
+
+func TestTasksFeedAuth(t *testing.T) {
+	router, _ := setupICSRouter(auth.NewFeedTokens("secret"))
+	forged := auth.NewFeedTokens("other").Issue(auth.Principal{User: "alice", APIKey: "key"})
+	// Issued with a key that has since been removed.
+	revoked := auth.NewFeedTokens("secret").Issue(auth.Principal{User: "alice", APIKey: "old"})
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"No token", FeedPath, http.StatusUnauthorized, "missing api key"},
+		{"Forged token", FeedPath + "?token=" + forged, http.StatusUnauthorized, "invalid feed token"},
+		{"Revoked key", FeedPath + "?token=" + revoked, http.StatusUnauthorized, "invalid feed token"},
+		// Feed tokens are not accepted outside of the feed.
+		{"Token on other route", "/tasks/feed-token?token=" + forged, http.StatusUnauthorized, "missing api key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", tt.url, nil))
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedBody, problemDetail(t, rr))
+		})
+	}
+}
+
+func TestImportICS(t *testing.T) {
+	router, mockUsecase := setupICSRouter(auth.NewFeedTokens("secret"))
+
+	due := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	weekLater := due.AddDate(0, 0, 7)
+	day := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	expected := []*todo.Task{
+		{Title: "Weekly report", Description: "Send to team\nand boss", DueDate: &due},
+		{Title: "Weekly report", Description: "Send to team\nand boss", DueDate: &weekLater},
+		{Title: "Finished", DueDate: &day, Completed: true, AllDay: true},
+	}
+	mockUsecase.On("ImportTasks", mock.Anything, expected).Return(nil)
+
+	body := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		"BEGIN:VTODO\r\nSUMMARY:Weekly report\r\nDESCRIPTION:Send to team\\nand boss\r\n" +
+		"DUE:20240607T150000Z\r\nRRULE:FREQ=WEEKLY;COUNT=2\r\nEND:VTODO\r\n" +
+		"BEGIN:VEVENT\r\nSUMMARY:Ignored\r\nDTSTART:20240607T150000Z\r\nEND:VEVENT\r\n" +
+		"BEGIN:VTODO\r\nSUMMARY:Finished\r\nDUE;VALUE=DATE:20240610\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+	req := httptest.NewRequest("POST", "/tasks/import/ics", strings.NewReader(body))
+	req.Header.Set(auth.APIKeyHeader, "key")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.JSONEq(t, `{"dry_run":false,"rows":2,"imported":3}`, rr.Body.String())
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestImportICSInvalidItems(t *testing.T) {
+	router, mockUsecase := setupICSRouter(auth.NewFeedTokens("secret"))
+
+	body := "BEGIN:VCALENDAR\n" +
+		"BEGIN:VTODO\nSUMMARY:No due date\nEND:VTODO\n" +
+		"BEGIN:VTODO\nDUE:20240607T150000Z\nRRULE:FREQ=HOURLY\nEND:VTODO\n" +
+		"END:VCALENDAR\n"
+	req := httptest.NewRequest("POST", "/tasks/import/ics?dry_run=true", strings.NewReader(body))
+	req.Header.Set(auth.APIKeyHeader, "key")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	var result ImportResult
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.Equal(t, []ImportError{
+		{Row: 2, Column: "DUE", Message: "DUE is required"},
+		{Row: 5, Column: "SUMMARY", Message: "SUMMARY cannot be empty"},
+		{Row: 5, Column: "RRULE", Message: "unsupported FREQ HOURLY"},
+	}, result.Errors)
+	mockUsecase.AssertNotCalled(t, "ImportTasks", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"errors"
+	"sberTestTask/internal/validation"
+	"slices"
+)
+
+// withFieldErrors adds the field errors of err, a validation.Errors or nil,
+// to errs, skipping fields that already have one.
+func withFieldErrors(errs validation.Errors, err error) error {
+	var more validation.Errors
+	errors.As(err, &more)
+	for _, fe := range more {
+		if !slices.ContainsFunc(errs, func(e validation.FieldError) bool { return e.Field == fe.Field }) {
+			errs = append(errs, fe)
+		}
+	}
+	return errs.Err()
+}
@@ -4,20 +4,127 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"net/http"
+	"sberTestTask/internal/auth"
+	"sberTestTask/internal/logger"
+	"sberTestTask/internal/ratelimit"
+	"time"
 )
 
-func RegisterRoutes(r *chi.Mux, handler *Handler) {
-	r.Use(middleware.Logger)
+// RouteOptions holds the optional middlewares applied to every API route and
+// optional endpoints.
+type RouteOptions struct {
+	Auth      *auth.Authenticator
+	RateLimit *ratelimit.Limiter
+	Events    *EventsHandler
+	WebSocket http.Handler
+	Webhooks  http.Handler
+	Reminders http.Handler
+	// GraphQL is served at /graphql, outside the versioned routes.
+	GraphQL http.Handler
+	// FeedTokens enables /tasks/feed-token. The authenticator must accept
+	// them on FeedPaths.
+	FeedTokens *auth.FeedTokens
+	// TimeZones are the users' preferred time zones, used when a request
+	// has no tz parameter.
+	TimeZones map[string]*time.Location
+	// CacheControl sets Cache-Control on successful responses.
+	CacheControl CacheControl
+	// Versions are mounted next to /v1.
+	Versions []Version
+	// Deprecations by version name, including Unversioned.
+	Deprecations   map[string]Deprecation
+	VersionMetrics *VersionMetrics
+}
+
+// RegisterRoutes serves the API under /v1, any further opts.Versions next
+// to it, and the same routes as /v1 without a prefix for older clients.
+// Authentication and rate limiting apply to these routes only, so that
+// health checks and metrics the caller registers on r stay open.
+func RegisterRoutes(r *chi.Mux, handler *Handler, opts RouteOptions) {
+	r.Use(middleware.RequestID)
+	r.Use(logger.Middleware)
+
+	r.Group(func(r chi.Router) {
+		if opts.Auth != nil {
+			r.Use(opts.Auth.Middleware)
+		}
+		if opts.RateLimit != nil {
+			r.Use(opts.RateLimit.Middleware)
+		}
+		r.Use(timeZoneMiddleware(opts.TimeZones))
+		r.Use(cacheControlMiddleware(opts.CacheControl))
+
+		r.Route("/"+CurrentVersion, func(r chi.Router) {
+			r.Use(versionMiddleware(CurrentVersion, opts))
+			registerV1(r, handler, opts, "/"+CurrentVersion)
+		})
+
+		for _, version := range opts.Versions {
+			r.Route("/"+version.Name, func(r chi.Router) {
+				r.Use(versionMiddleware(version.Name, opts))
+				version.Routes(r)
+			})
+		}
+
+		r.Group(func(r chi.Router) {
+			r.Use(versionMiddleware(Unversioned, opts))
+			registerV1(r, handler, opts, "")
+		})
+
+		if opts.GraphQL != nil {
+			r.Handle("/graphql", opts.GraphQL)
+		}
+
+		r.Get("/swagger/*", httpSwagger.WrapHandler)
+	})
+}
+
+// FeedPaths returns the calendar feed of every version that serves it, for
+// auth.WithFeedTokens.
+func FeedPaths() []string {
+	return []string{"/" + CurrentVersion + FeedPath, FeedPath}
+}
 
+// registerV1 registers the v1 routes on r, which is mounted under prefix.
+func registerV1(r chi.Router, handler *Handler, opts RouteOptions, prefix string) {
 	r.Post("/tasks", handler.CreateTask)
 
 	r.Get("/tasks", handler.ListTasks)
 
+	r.Get("/tasks/export", handler.ExportTasks)
+
+	r.Post("/tasks/import", handler.ImportTasks)
+
+	r.Get(FeedPath, handler.TasksFeed)
+
+	r.Post("/tasks/import/ics", handler.ImportICS)
+
+	r.Get("/stats", handler.Stats)
+
+	if opts.FeedTokens != nil {
+		r.Get("/tasks/feed-token", feedTokenHandler(opts.FeedTokens, prefix+FeedPath))
+	}
+
+	if opts.Events != nil {
+		r.Get("/tasks/events", opts.Events.Stream)
+	}
+
 	r.Get("/tasks/{id}", handler.GetTask)
 
 	r.Put("/tasks/{id}", handler.UpdateTask)
 
 	r.Delete("/tasks/{id}", handler.DeleteTask)
 
-	r.Get("/swagger/*", httpSwagger.WrapHandler)
+	if opts.Reminders != nil {
+		r.Mount("/tasks/{id}/reminders", opts.Reminders)
+	}
+
+	if opts.WebSocket != nil {
+		r.Get("/ws", opts.WebSocket.ServeHTTP)
+	}
+
+	if opts.Webhooks != nil {
+		r.Mount("/webhooks", opts.Webhooks)
+	}
 }
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sberTestTask/internal/auth"
+	"sberTestTask/internal/ratelimit"
+	"sberTestTask/internal/todo/tests/mocks/serviceMock"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationalRoutesSkipAuthAndRateLimit(t *testing.T) {
+	key, err := ratelimit.NewKeyFunc(ratelimit.KeyByIP)
+	require.NoError(t, err)
+	router := chi.NewRouter()
+	RegisterRoutes(router, NewHandler(new(serviceMock.MockTodoUsecase)), RouteOptions{
+		Auth:      auth.NewAuthenticator(map[string]string{"key": "alice"}, true),
+		RateLimit: ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Limit{Rate: 0.001, Burst: 1}, nil, key),
+		GraphQL:   http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	})
+	router.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/metrics", func(w http.ResponseWriter, r *http.Request) {})
+
+	get := func(url string) int {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", url, nil))
+		return rr.Code
+	}
+
+	// Probes and scrapes need no key and never run out of tokens.
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, get("/healthz"))
+		assert.Equal(t, http.StatusOK, get("/metrics"))
+	}
+	assert.Equal(t, http.StatusUnauthorized, get("/v1/tasks"))
+	assert.Equal(t, http.StatusUnauthorized, get("/tasks"))
+	assert.Equal(t, http.StatusUnauthorized, get("/graphql"))
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sberTestTask/internal/httpapi"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/service"
+	"strconv"
+	"time"
+)
+
+const maxUpcomingDays = 90
+
+// @Summary Task statistics
+// @Description Counts by status, overdue tasks, the mean time to complete, the completion rate of tasks due in each day or week from from to to, and open tasks due on each of the next upcoming_days days. Days are calendar days in the tz zone. The list filters narrow every figure. Results are cached for a short time.
+// @Tags tasks
+// @Produce  json
+// @Param completed query bool false "Filter by completion status"
+// @Param date query string false "Filter by due date" Format(date) example(2024-06-07)
+// @Param overdue query bool false "Filter by overdue status"
+// @Param tz query string false "IANA time zone of the days" example(Europe/Moscow)
+// @Param bucket query string false "day (default) or week"
+// @Param from query string false "First day of the completion series, by default 30 days or 12 weeks before to" Format(date)
+// @Param to query string false "Last day of the completion series, by default today" Format(date)
+// @Param upcoming_days query int false "Number of upcoming days, 7 by default, at most 90"
+// @Success 200 {object} StatsResponse "Statistics"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 500 {object} httpapi.Problem "Internal Server Error"
+// @Router /stats [get]
+func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilters(r)
+	if err != nil {
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	opts, err := parseStatsOptions(r)
+	if err != nil {
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	stats, err := h.uc.Stats(r.Context(), filter, opts)
+	if errors.Is(err, service.ErrInvalidData) {
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		httpapi.WriteProblem(w, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newStatsResponse(stats))
+}
+
+// parseStatsOptions leaves unset values to the service defaults. The
+// service checks the range once they are applied.
+func parseStatsOptions(r *http.Request) (todo.StatsOptions, error) {
+	var opts todo.StatsOptions
+	query := r.URL.Query()
+
+	switch bucket := query.Get("bucket"); bucket {
+	case "", todo.BucketDay, todo.BucketWeek:
+		opts.Bucket = bucket
+	default:
+		return opts, errors.New("bucket must be day or week")
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &opts.From}, {"to", &opts.To}} {
+		if s := query.Get(p.name); s != "" {
+			d, err := time.Parse(time.DateOnly, s)
+			if err != nil {
+				return opts, fmt.Errorf("invalid %s date", p.name)
+			}
+			*p.dst = d
+		}
+	}
+	if s := query.Get("upcoming_days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxUpcomingDays {
+			return opts, fmt.Errorf("upcoming_days must be between 1 and %d", maxUpcomingDays)
+		}
+		opts.UpcomingDays = n
+	}
+	return opts, nil
+}
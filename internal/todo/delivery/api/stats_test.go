@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/service"
+	"sberTestTask/internal/todo/tests/mocks/serviceMock"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupStatsRouter() (*chi.Mux, *serviceMock.MockTodoUsecase) {
+	mockUsecase := new(serviceMock.MockTodoUsecase)
+	router := chi.NewRouter()
+	router.Get("/stats", NewHandler(mockUsecase).Stats)
+	return router, mockUsecase
+}
+
+func TestStats(t *testing.T) {
+	router, mockUsecase := setupStatsRouter()
+
+	avg := 3600.0
+	overdue := true
+	opts := todo.StatsOptions{
+		Bucket:       todo.BucketWeek,
+		From:         time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC),
+		To:           time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC),
+		UpcomingDays: 2,
+	}
+	mockUsecase.On("Stats", mock.Anything, todo.TaskFilter{Overdue: &overdue}, opts).Return(&todo.Stats{
+		Total: 3, Open: 2, Completed: 1, Overdue: 2, AvgTimeToComplete: &avg,
+		Completion: []todo.CompletionBucket{{Start: "2024-06-03", Due: 2, Completed: 1, Rate: 0.5}, {Start: "2024-06-10"}},
+		Upcoming:   []todo.DayCount{{Date: "2024-06-10", Count: 1}, {Date: "2024-06-11"}},
+	}, nil)
+
+	req := httptest.NewRequest("GET", "/stats?overdue=true&bucket=week&from=2024-06-03&to=2024-06-10&upcoming_days=2", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"total":3,"open":2,"completed":1,"overdue":2,"avg_time_to_complete":3600,
+		"completion":[{"start":"2024-06-03","due":2,"completed":1,"rate":0.5},{"start":"2024-06-10","due":0,"completed":0,"rate":0}],
+		"upcoming":[{"date":"2024-06-10","count":1},{"date":"2024-06-11","count":0}]}`, rr.Body.String())
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestStatsInvalidParams(t *testing.T) {
+	router, mockUsecase := setupStatsRouter()
+
+	tests := []struct {
+		query string
+		body  string
+	}{
+		{"bucket=month", "bucket must be day or week"},
+		{"from=June", "invalid from date"},
+		{"upcoming_days=0", "upcoming_days must be between 1 and 90"},
+		{"completed=maybe", "invalid completed flag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/stats?"+tt.query, nil))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Equal(t, tt.body, problemDetail(t, rr))
+		})
+	}
+	mockUsecase.AssertNotCalled(t, "Stats", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestStatsInvalidRange(t *testing.T) {
+	router, mockUsecase := setupStatsRouter()
+	opts := todo.StatsOptions{From: time.Date(1, 1, 2, 0, 0, 0, 0, time.UTC)}
+	mockUsecase.On("Stats", mock.Anything, todo.TaskFilter{}, opts).
+		Return((*todo.Stats)(nil), fmt.Errorf("%w: more than 366 buckets between from and to", service.ErrInvalidData))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/stats?from=0001-01-02", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "invalid data: more than 366 buckets between from and to", problemDetail(t, rr))
+}
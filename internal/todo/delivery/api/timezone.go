@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+	"sberTestTask/internal/auth"
+	"sberTestTask/internal/httpapi"
+	"sberTestTask/internal/todo"
+	"time"
+)
+
+// TimeZoneParam selects the time zone of date filters and the computed
+// task fields for one request.
+const TimeZoneParam = "tz"
+
+// timeZoneMiddleware stores the caller's time zone in the request context:
+// the tz query parameter, else the authenticated user's preference from
+// users. Without either the service default applies.
+func timeZoneMiddleware(users map[string]*time.Location) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var loc *time.Location
+			if name := r.URL.Query().Get(TimeZoneParam); name != "" {
+				var err error
+				if loc, err = time.LoadLocation(name); err != nil {
+					httpapi.WriteProblem(w, http.StatusBadRequest, "invalid time zone", nil)
+					return
+				}
+			} else if principal, ok := auth.FromContext(r.Context()); ok {
+				loc = users[principal.User]
+			}
+			if loc != nil {
+				r = r.WithContext(todo.WithLocation(r.Context(), loc))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"log/slog"
+	"net/http"
+	"sberTestTask/internal/auth"
+	"sberTestTask/internal/httpapi"
+	"sberTestTask/internal/logger"
+	"strconv"
+	"time"
+)
+
+const (
+	// CurrentVersion prefixes the routes registered by RegisterRoutes.
+	CurrentVersion = "v1"
+	// Unversioned names the same routes without a prefix, kept for clients
+	// written before /v1.
+	Unversioned = "unversioned"
+)
+
+// Version is a route set mounted under /<Name>, e.g. a v2 with its own
+// request and response types built on the same service.TodoUsecase.
+type Version struct {
+	Name   string
+	Routes func(r chi.Router)
+}
+
+// Deprecation announces that a version is going away through the
+// Deprecation (RFC 9745), Sunset (RFC 8594) and Link headers of its
+// responses. Once Sunset has passed the version answers 410 Gone.
+type Deprecation struct {
+	Since time.Time
+	// Sunset is optional.
+	Sunset time.Time
+	// Link points to migration notes, optional.
+	Link string
+}
+
+// VersionMetrics counts requests per API version, and per user for
+// deprecated versions so that their remaining clients can be found.
+type VersionMetrics struct {
+	requests   *prometheus.CounterVec
+	deprecated *prometheus.CounterVec
+}
+
+func NewVersionMetrics(reg prometheus.Registerer) *VersionMetrics {
+	m := &VersionMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "todo_api_requests_total",
+			Help: "Number of REST API requests by API version.",
+		}, []string{"version"}),
+		deprecated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "todo_api_deprecated_requests_total",
+			Help: "Number of requests to deprecated API versions by version and user.",
+		}, []string{"version", "user"}),
+	}
+	reg.MustRegister(m.requests, m.deprecated)
+	return m
+}
+
+func (m *VersionMetrics) observe(r *http.Request, version string, deprecated bool) {
+	if m == nil {
+		return
+	}
+	m.requests.WithLabelValues(version).Inc()
+	if deprecated {
+		user := "anonymous"
+		if principal, ok := auth.FromContext(r.Context()); ok {
+			user = principal.User
+		}
+		m.deprecated.WithLabelValues(version, user).Inc()
+	}
+}
+
+// versionMiddleware tags the requests of one version in logs and metrics
+// and announces its deprecation, if any.
+func versionMiddleware(version string, opts RouteOptions) func(http.Handler) http.Handler {
+	deprecation, deprecated := opts.Deprecations[version]
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger.AddAttrs(r.Context(), slog.String("api_version", version))
+			opts.VersionMetrics.observe(r, version, deprecated)
+			if !deprecated {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("Deprecation", "@"+strconv.FormatInt(deprecation.Since.Unix(), 10))
+			if deprecation.Link != "" {
+				h.Add("Link", "<"+deprecation.Link+`>; rel="deprecation"; type="text/html"`)
+			}
+			if !deprecation.Sunset.IsZero() {
+				h.Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+				if !time.Now().Before(deprecation.Sunset) {
+					httpapi.WriteProblem(w, http.StatusGone, "API version "+version+" was sunset on "+deprecation.Sunset.UTC().Format(time.DateOnly), nil)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
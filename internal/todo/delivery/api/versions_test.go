@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sberTestTask/internal/auth"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/tests/mocks/serviceMock"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestVersions(t *testing.T) {
+	mockUsecase := new(serviceMock.MockTodoUsecase)
+	mockUsecase.On("GetTask", mock.Anything, 1).Return(&todo.Task{ID: 1, Title: "Task"}, nil)
+
+	since := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	sunset := time.Now().Add(24 * time.Hour)
+	metrics := NewVersionMetrics(prometheus.NewRegistry())
+	router := chi.NewRouter()
+	RegisterRoutes(router, NewHandler(mockUsecase), RouteOptions{
+		Auth: auth.NewAuthenticator(map[string]string{"key": "alice"}, false),
+		Versions: []Version{
+			{Name: "v2", Routes: func(r chi.Router) {
+				r.Get("/tasks/{id}", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("v2")) })
+			}},
+			{Name: "v0", Routes: func(r chi.Router) {
+				r.Get("/tasks", func(w http.ResponseWriter, r *http.Request) {})
+			}},
+		},
+		Deprecations: map[string]Deprecation{
+			Unversioned: {Since: since, Sunset: sunset, Link: "/docs/migration"},
+			"v0":        {Since: since, Sunset: since},
+		},
+		VersionMetrics: metrics,
+	})
+
+	get := func(url, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		if apiKey != "" {
+			req.Header.Set(auth.APIKeyHeader, apiKey)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/v1/tasks/1", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"title":"Task"`)
+	assert.Empty(t, rr.Header().Get("Deprecation"))
+	assert.Empty(t, rr.Header().Get("Sunset"))
+
+	rr = get("/tasks/1", "key")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"title":"Task"`)
+	assert.Equal(t, "@1792368000", rr.Header().Get("Deprecation"))
+	assert.Equal(t, sunset.UTC().Format(http.TimeFormat), rr.Header().Get("Sunset"))
+	assert.Equal(t, `</docs/migration>; rel="deprecation"; type="text/html"`, rr.Header().Get("Link"))
+
+	rr = get("/v2/tasks/1", "")
+	assert.Equal(t, "v2", rr.Body.String())
+
+	rr = get("/v0/tasks", "")
+	assert.Equal(t, http.StatusGone, rr.Code)
+	assert.Equal(t, "API version v0 was sunset on 2026-10-19", problemDetail(t, rr))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues(CurrentVersion)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues(Unversioned)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("v2")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.deprecated.WithLabelValues(Unversioned, "alice")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.deprecated.WithLabelValues("v0", "anonymous")))
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.deprecated))
+}
+
+func TestFeedTokenURLPerVersion(t *testing.T) {
+	router := chi.NewRouter()
+	RegisterRoutes(router, NewHandler(new(serviceMock.MockTodoUsecase)), RouteOptions{
+		Auth:       auth.NewAuthenticator(map[string]string{"key": "alice"}, true),
+		FeedTokens: auth.NewFeedTokens("secret"),
+	})
+
+	for prefix, feed := range map[string]string{"/v1": "/v1/tasks.ics?token=", "": "/tasks.ics?token="} {
+		req := httptest.NewRequest("GET", prefix+"/tasks/feed-token", nil)
+		req.Header.Set(auth.APIKeyHeader, "key")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var token FeedTokenResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &token))
+		assert.True(t, strings.HasPrefix(token.URL, feed), token.URL)
+	}
+}
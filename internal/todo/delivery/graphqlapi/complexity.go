@@ -0,0 +1,80 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// listFields are fields whose cost is multiplied by their limit argument.
+var listFields = map[string]bool{
+	"TaskPage.tasks": true,
+}
+
+// analyze validates the query and estimates the cost of the selected
+// operation: every field costs one, and fields returning a page cost as much
+// as the page size times the cost of their selection.
+func analyze(schema *ast.Schema, query, operationName string, variables map[string]interface{}) (*ast.OperationDefinition, int, error) {
+	doc, errs := gqlparser.LoadQuery(schema, query)
+	if len(errs) > 0 {
+		return nil, 0, errs
+	}
+	op := doc.Operations.ForName(operationName)
+	if op == nil {
+		return nil, 0, fmt.Errorf("operation %q not found", operationName)
+	}
+	return op, selectionCost(op.SelectionSet, variables, defaultLimit), nil
+}
+
+func selectionCost(set ast.SelectionSet, variables map[string]interface{}, pageSize int) int {
+	cost := 0
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			childCost := selectionCost(sel.SelectionSet, variables, fieldLimit(sel, variables, pageSize))
+			if sel.ObjectDefinition != nil && listFields[sel.ObjectDefinition.Name+"."+sel.Name] {
+				childCost *= pageSize
+			}
+			cost += 1 + childCost
+		case *ast.InlineFragment:
+			cost += selectionCost(sel.SelectionSet, variables, pageSize)
+		case *ast.FragmentSpread:
+			cost += selectionCost(sel.Definition.SelectionSet, variables, pageSize)
+		}
+	}
+	return cost
+}
+
+// fieldLimit returns the page size requested by a field's limit argument,
+// which applies to the list fields in its selection.
+func fieldLimit(field *ast.Field, variables map[string]interface{}, inherited int) int {
+	arg := field.Arguments.ForName("limit")
+	if arg == nil {
+		if field.Definition != nil && field.Definition.Arguments.ForName("limit") != nil {
+			return defaultLimit
+		}
+		return inherited
+	}
+	value, err := arg.Value.Value(variables)
+	if err != nil {
+		return defaultLimit
+	}
+	var limit int
+	switch v := value.(type) {
+	case int64:
+		limit = int(v)
+	case float64:
+		limit = int(v)
+	case int:
+		limit = v
+	default:
+		return defaultLimit
+	}
+	if limit <= 0 {
+		return defaultLimit
+	}
+	if limit > maxLimit {
+		return maxLimit
+	}
+	return limit
+}
@@ -0,0 +1,103 @@
+package graphqlapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"net/http"
+	"sberTestTask/internal/todo/service"
+)
+
+//go:embed schema.graphql
+var schemaString string
+
+type Handler struct {
+	uc              service.TodoUsecase
+	schema          *graphql.Schema
+	validation      *ast.Schema
+	complexityLimit int
+}
+
+// NewHandler serves GraphQL queries against uc. Queries whose estimated
+// complexity exceeds complexityLimit are rejected before execution.
+func NewHandler(uc service.TodoUsecase, complexityLimit, maxDepth int) (*Handler, error) {
+	schema, err := graphql.ParseSchema(schemaString, &resolver{uc: uc},
+		graphql.UseFieldResolvers(),
+		graphql.MaxDepth(maxDepth),
+	)
+	if err != nil {
+		return nil, err
+	}
+	validation, gqlErr := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: schemaString})
+	if gqlErr != nil {
+		return nil, gqlErr
+	}
+	return &Handler{uc: uc, schema: schema, validation: validation, complexityLimit: complexityLimit}, nil
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type errorResponse struct {
+	Errors []errorMessage `json:"errors"`
+}
+
+type errorMessage struct {
+	Message string `json:"message"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid variables")
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	op, cost, err := analyze(h.validation, req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if r.Method == http.MethodGet && op.Operation != ast.Query {
+		w.Header().Set("Allow", "POST")
+		writeError(w, http.StatusMethodNotAllowed, "mutations are only allowed over POST")
+		return
+	}
+	if cost > h.complexityLimit {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("query complexity %d exceeds limit %d", cost, h.complexityLimit))
+		return
+	}
+
+	ctx := withLoader(r.Context(), newTaskLoader(h.uc))
+	resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Errors: []errorMessage{{Message: msg}}})
+}
@@ -0,0 +1,82 @@
+package graphqlapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/tests/mocks/serviceMock"
+)
+
+func setupHandlerWithMock(t *testing.T, complexityLimit int) (*Handler, *serviceMock.MockTodoUsecase) {
+	mockUsecase := new(serviceMock.MockTodoUsecase)
+	handler, err := NewHandler(mockUsecase, complexityLimit, 10)
+	assert.NoError(t, err)
+	return handler, mockUsecase
+}
+
+func doQuery(handler http.Handler, query string, variables map[string]interface{}) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(request{Query: query, Variables: variables})
+	req := httptest.NewRequest("POST", "/graphql", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestTaskBatching(t *testing.T) {
+	handler, mockUsecase := setupHandlerWithMock(t, 100)
+	date := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+
+	mockUsecase.On("GetTasks", mock.Anything, mock.MatchedBy(func(ids []int) bool {
+		sorted := append([]int(nil), ids...)
+		sort.Ints(sorted)
+		return assert.ObjectsAreEqual([]int{1, 2, 3}, sorted)
+	})).Return([]*todo.Task{
+		{ID: 1, Title: "First", DueDate: &date},
+		{ID: 2, Title: "Second", DueDate: &date},
+	}, nil).Once()
+
+	rr := doQuery(handler, `{
+		a: task(id: "1") { id title }
+		b: task(id: "2") { title dueDate }
+		c: task(id: "3") { title }
+	}`, nil)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"data":{
+		"a":{"id":"1","title":"First"},
+		"b":{"title":"Second","dueDate":"2024-06-07T15:00:00Z"},
+		"c":null
+	}}`, rr.Body.String())
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestCreateTaskMutation(t *testing.T) {
+	handler, mockUsecase := setupHandlerWithMock(t, 100)
+
+	mockUsecase.On("CreateTask", mock.Anything, mock.AnythingOfType("*todo.Task")).Run(func(args mock.Arguments) {
+		args.Get(1).(*todo.Task).ID = 5
+	}).Return(nil)
+
+	rr := doQuery(handler, `mutation($input: CreateTaskInput!) { createTask(input: $input) { id title completed } }`,
+		map[string]interface{}{"input": map[string]interface{}{"title": "Test Task", "dueDate": "2024-06-07T15:00:00Z"}})
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"data":{"createTask":{"id":"5","title":"Test Task","completed":false}}}`, rr.Body.String())
+}
+
+func TestComplexityLimit(t *testing.T) {
+	handler, _ := setupHandlerWithMock(t, 50)
+
+	rr := doQuery(handler, `{ tasks(limit: 100) { countPage tasks { id title description } } }`, nil)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Contains(t, rr.Body.String(), "query complexity 303 exceeds limit 50")
+}
@@ -0,0 +1,101 @@
+package graphqlapi
+
+import (
+	"context"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/service"
+	"sync"
+	"time"
+)
+
+// batchWait is how long the loader collects ids before querying the usecase.
+// Sibling fields are resolved concurrently, so a short window is enough.
+const batchWait = 2 * time.Millisecond
+
+// taskLoader batches task lookups made while resolving one request into a
+// single GetTasks call and caches the results for the rest of the request.
+type taskLoader struct {
+	uc service.TodoUsecase
+
+	mu    sync.Mutex
+	cache map[int]*todo.Task
+	batch *taskBatch
+}
+
+type taskBatch struct {
+	ids   []int
+	done  chan struct{}
+	tasks map[int]*todo.Task
+	err   error
+}
+
+func newTaskLoader(uc service.TodoUsecase) *taskLoader {
+	return &taskLoader{uc: uc, cache: make(map[int]*todo.Task)}
+}
+
+// Load returns the task with the given id, or nil if it does not exist.
+func (l *taskLoader) Load(ctx context.Context, id int) (*todo.Task, error) {
+	l.mu.Lock()
+	if task, ok := l.cache[id]; ok {
+		l.mu.Unlock()
+		return task, nil
+	}
+	b := l.batch
+	if b == nil {
+		b = &taskBatch{done: make(chan struct{})}
+		l.batch = b
+		time.AfterFunc(batchWait, func() { l.dispatch(ctx, b) })
+	}
+	b.ids = append(b.ids, id)
+	l.mu.Unlock()
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.tasks[id], nil
+}
+
+func (l *taskLoader) dispatch(ctx context.Context, b *taskBatch) {
+	l.mu.Lock()
+	l.batch = nil
+	ids := b.ids
+	l.mu.Unlock()
+
+	tasks, err := l.uc.GetTasks(ctx, ids)
+	b.err = err
+	b.tasks = make(map[int]*todo.Task, len(tasks))
+	for _, task := range tasks {
+		b.tasks[task.ID] = task
+	}
+	if err == nil {
+		l.Prime(tasks...)
+	}
+	close(b.done)
+}
+
+// Prime stores tasks that were already fetched, e.g. by a list query.
+func (l *taskLoader) Prime(tasks ...*todo.Task) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, task := range tasks {
+		l.cache[task.ID] = task
+	}
+}
+
+type loaderKey struct{}
+
+func withLoader(ctx context.Context, l *taskLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, l)
+}
+
+func loaderFromContext(ctx context.Context, uc service.TodoUsecase) *taskLoader {
+	if l, ok := ctx.Value(loaderKey{}).(*taskLoader); ok {
+		return l
+	}
+	return newTaskLoader(uc)
+}
@@ -0,0 +1,215 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"github.com/graph-gophers/graphql-go"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/service"
+	"sberTestTask/internal/validation"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultLimit = 10
+	maxLimit     = 100
+)
+
+var errInvalidID = errors.New("invalid id")
+
+// validationError lists the violated fields of an input in the "fields"
+// extension of the GraphQL error.
+type validationError struct {
+	errs validation.Errors
+}
+
+func (e *validationError) Error() string {
+	return e.errs.Error()
+}
+
+func (e *validationError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": "INVALID_INPUT", "fields": e.errs}
+}
+
+func invalidInput(err error) error {
+	var errs validation.Errors
+	if errors.As(err, &errs) {
+		return &validationError{errs: errs}
+	}
+	return err
+}
+
+// resolver is the root resolver for both queries and mutations.
+type resolver struct {
+	uc service.TodoUsecase
+}
+
+type taskFilterInput struct {
+	Completed *bool
+	Date      *string
+	Overdue   *bool
+}
+
+func (f *taskFilterInput) parse() (todo.TaskFilter, error) {
+	if f == nil {
+		return todo.TaskFilter{}, nil
+	}
+	filter := todo.TaskFilter{Completed: f.Completed, Overdue: f.Overdue}
+	if f.Date != nil {
+		parsed, err := time.Parse(time.DateOnly, *f.Date)
+		if err != nil {
+			return todo.TaskFilter{}, errors.New("invalid date format")
+		}
+		filter.DueDate = &parsed
+	}
+	return filter, nil
+}
+
+func parseID(id graphql.ID) (int, error) {
+	v, err := strconv.Atoi(string(id))
+	if err != nil {
+		return 0, errInvalidID
+	}
+	return v, nil
+}
+
+func (r *resolver) Task(ctx context.Context, args struct{ ID graphql.ID }) (*taskResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	task, err := loaderFromContext(ctx, r.uc).Load(ctx, id)
+	if err != nil || task == nil {
+		return nil, err
+	}
+	return &taskResolver{task: task}, nil
+}
+
+func (r *resolver) Tasks(ctx context.Context, args struct {
+	Filter *taskFilterInput
+	Limit  int32
+	Page   int32
+}) (*pageResolver, error) {
+	filter, err := args.Filter.parse()
+	if err != nil {
+		return nil, err
+	}
+	limit := int(args.Limit)
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	page := int(args.Page)
+	if page <= 0 {
+		page = 1
+	}
+
+	pages, err := r.uc.ListTasks(ctx, filter, limit, page)
+	if err != nil {
+		return nil, err
+	}
+	loaderFromContext(ctx, r.uc).Prime(pages.Tasks...)
+	return &pageResolver{pages: pages}, nil
+}
+
+func (r *resolver) TaskCount(ctx context.Context, args struct{ Filter *taskFilterInput }) (int32, error) {
+	filter, err := args.Filter.parse()
+	if err != nil {
+		return 0, err
+	}
+	count, err := r.uc.CountTasks(ctx, filter)
+	return int32(count), err
+}
+
+type createTaskInput struct {
+	Title       string
+	Description *string
+	DueDate     graphql.Time
+	Completed   *bool
+	AllDay      *bool
+}
+
+type updateTaskInput struct {
+	Title       *string
+	Description *string
+	DueDate     *graphql.Time
+	Completed   *bool
+	AllDay      *bool
+}
+
+func (r *resolver) CreateTask(ctx context.Context, args struct{ Input createTaskInput }) (*taskResolver, error) {
+	dueDate := args.Input.DueDate.Time
+	task := &todo.Task{Title: args.Input.Title, DueDate: &dueDate}
+	if args.Input.Description != nil {
+		task.Description = *args.Input.Description
+	}
+	if args.Input.Completed != nil {
+		task.Completed = *args.Input.Completed
+	}
+	if args.Input.AllDay != nil {
+		task.AllDay = *args.Input.AllDay
+	}
+	if err := task.Validate(); err != nil {
+		return nil, invalidInput(err)
+	}
+	if err := r.uc.CreateTask(ctx, task); err != nil {
+		return nil, err
+	}
+	return &taskResolver{task: task}, nil
+}
+
+func (r *resolver) UpdateTask(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input updateTaskInput
+}) (*taskResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	task, err := r.uc.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	in := args.Input
+	if in.Title != nil {
+		task.Title = *in.Title
+	}
+	if in.Description != nil {
+		task.Description = *in.Description
+	}
+	if in.DueDate != nil {
+		dueDate := in.DueDate.Time
+		task.DueDate = &dueDate
+	}
+	if in.Completed != nil {
+		task.Completed = *in.Completed
+	}
+	if in.AllDay != nil {
+		task.AllDay = *in.AllDay
+	}
+	if err := task.Validate(); err != nil {
+		return nil, invalidInput(err)
+	}
+	if err := r.uc.UpdateTask(ctx, task); err != nil {
+		return nil, err
+	}
+	return &taskResolver{task: task}, nil
+}
+
+func (r *resolver) DeleteTask(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return false, err
+	}
+	if _, err := r.uc.GetTask(ctx, id); err != nil {
+		return false, err
+	}
+	if err := r.uc.DeleteTask(ctx, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
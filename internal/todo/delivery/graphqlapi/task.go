@@ -0,0 +1,78 @@
+package graphqlapi
+
+import (
+	"github.com/graph-gophers/graphql-go"
+	"sberTestTask/internal/todo"
+	"strconv"
+)
+
+type taskResolver struct {
+	task *todo.Task
+}
+
+func (t *taskResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(t.task.ID))
+}
+
+func (t *taskResolver) Title() string {
+	return t.task.Title
+}
+
+func (t *taskResolver) Description() string {
+	return t.task.Description
+}
+
+func (t *taskResolver) DueDate() graphql.Time {
+	if t.task.DueDate == nil {
+		return graphql.Time{}
+	}
+	return graphql.Time{Time: *t.task.DueDate}
+}
+
+func (t *taskResolver) Completed() bool {
+	return t.task.Completed
+}
+
+func (t *taskResolver) AllDay() bool {
+	return t.task.AllDay
+}
+
+func (t *taskResolver) Overdue() bool {
+	return t.task.Overdue != nil && *t.task.Overdue
+}
+
+// DueIn is a Float because GraphQL Int has 32 bits.
+func (t *taskResolver) DueIn() *float64 {
+	if t.task.DueIn == nil {
+		return nil
+	}
+	v := float64(*t.task.DueIn)
+	return &v
+}
+
+func (t *taskResolver) DaysOverdue() int32 {
+	if t.task.DaysOverdue == nil {
+		return 0
+	}
+	return int32(*t.task.DaysOverdue)
+}
+
+type pageResolver struct {
+	pages *todo.Pages
+}
+
+func (p *pageResolver) CountPage() int32 {
+	return int32(p.pages.CountPage)
+}
+
+func (p *pageResolver) CurPage() int32 {
+	return int32(p.pages.CurPage)
+}
+
+func (p *pageResolver) Tasks() []*taskResolver {
+	tasks := make([]*taskResolver, 0, len(p.pages.Tasks))
+	for _, task := range p.pages.Tasks {
+		tasks = append(tasks, &taskResolver{task: task})
+	}
+	return tasks
+}
@@ -0,0 +1,67 @@
+package grpcapi
+
+import (
+	"errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"sberTestTask/internal/todo"
+	todov1 "sberTestTask/pkg/pb/todo/v1"
+	"time"
+)
+
+func toProtoTask(task *todo.Task) *todov1.Task {
+	pb := &todov1.Task{
+		Id:          int64(task.ID),
+		Title:       task.Title,
+		Description: task.Description,
+		Completed:   task.Completed,
+		AllDay:      task.AllDay,
+	}
+	if task.DueDate != nil {
+		pb.DueDate = timestamppb.New(*task.DueDate)
+	}
+	return pb
+}
+
+func toProtoPages(pages *todo.Pages) *todov1.Pages {
+	pb := &todov1.Pages{
+		CountPage: int32(pages.CountPage),
+		CurPage:   int32(pages.CurPage),
+		Tasks:     make([]*todov1.Task, 0, len(pages.Tasks)),
+	}
+	for _, task := range pages.Tasks {
+		pb.Tasks = append(pb.Tasks, toProtoTask(task))
+	}
+	return pb
+}
+
+func dueDate(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+// parseFilter converts the protobuf filter into the filter of TodoUsecase.
+// The location stays unset without tz, for the service default.
+func parseFilter(filter *todov1.TaskFilter) (todo.TaskFilter, error) {
+	if filter == nil {
+		return todo.TaskFilter{}, nil
+	}
+	f := todo.TaskFilter{Completed: filter.Completed, Overdue: filter.Overdue}
+	if filter.GetDate() != "" {
+		parsed, err := time.Parse(time.DateOnly, filter.GetDate())
+		if err != nil {
+			return todo.TaskFilter{}, errors.New("invalid date format")
+		}
+		f.DueDate = &parsed
+	}
+	if filter.GetTz() != "" {
+		loc, err := time.LoadLocation(filter.GetTz())
+		if err != nil {
+			return todo.TaskFilter{}, errors.New("invalid time zone")
+		}
+		f.Location = loc
+	}
+	return f, nil
+}
@@ -0,0 +1,43 @@
+package grpcapi
+
+import (
+	"errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sberTestTask/internal/todo/service"
+	"sberTestTask/internal/validation"
+)
+
+// toStatus maps the domain errors of service.TodoUsecase onto gRPC status codes.
+// Validation errors become InvalidArgument with a BadRequest detail listing
+// the violated fields.
+func toStatus(err error) error {
+	var fieldErrs validation.Errors
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &fieldErrs):
+		return invalidArgument(fieldErrs)
+	case errors.Is(err, service.ErrIdNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidData):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrOnServer):
+		return status.Error(codes.Internal, err.Error())
+	default:
+		return status.Error(codes.Unknown, err.Error())
+	}
+}
+
+func invalidArgument(errs validation.Errors) error {
+	badRequest := &errdetails.BadRequest{}
+	for _, fe := range errs {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: fe.Field, Description: fe.Message})
+	}
+	st, err := status.New(codes.InvalidArgument, errs.Error()).WithDetails(badRequest)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, errs.Error())
+	}
+	return st.Err()
+}
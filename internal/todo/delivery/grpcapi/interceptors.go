@@ -0,0 +1,182 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"sberTestTask/internal/auth"
+	"sberTestTask/internal/logger"
+	"sberTestTask/internal/ratelimit"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitMethod is the method of rate limit rules for RPCs; their pattern
+// is the full method name, such as /todo.v1.TodoService/CreateTask.
+const RateLimitMethod = "GRPC"
+
+// ServerOptions give RPCs the tracing, access log, authentication and rate
+// limiting of the HTTP API, in that order. authn and limiter may be nil.
+func ServerOptions(authn *auth.Authenticator, limiter *ratelimit.Limiter) []grpc.ServerOption {
+	steps := []step{logRPC}
+	if authn != nil {
+		steps = append(steps, authenticate(authn))
+	}
+	if limiter != nil {
+		steps = append(steps, rateLimit(limiter))
+	}
+	return []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unaryInterceptor(steps)),
+		grpc.ChainStreamInterceptor(streamInterceptor(steps)),
+	}
+}
+
+// step runs before an RPC and may replace its context or reject it. done,
+// if not nil, is called with the result of the RPC.
+type step func(ctx context.Context, method string) (_ context.Context, done func(error), _ error)
+
+func unaryInterceptor(steps []step) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		ctx, done, err := runSteps(ctx, info.FullMethod, steps)
+		defer func() { done(err) }()
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamInterceptor(steps []step) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx, done, err := runSteps(ss.Context(), info.FullMethod, steps)
+		defer func() { done(err) }()
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// runSteps stops at the first step that rejects the RPC. The returned done
+// reports the result to the steps that ran, last first.
+func runSteps(ctx context.Context, method string, steps []step) (context.Context, func(error), error) {
+	var dones []func(error)
+	done := func(err error) {
+		for i := len(dones) - 1; i >= 0; i-- {
+			dones[i](err)
+		}
+	}
+	for _, s := range steps {
+		next, stepDone, err := s(ctx, method)
+		if stepDone != nil {
+			dones = append(dones, stepDone)
+		}
+		if err != nil {
+			return ctx, done, err
+		}
+		ctx = next
+	}
+	return ctx, done, nil
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// logRPC attaches a request-scoped logger, as logger.Middleware does for
+// HTTP, and writes one access log line per RPC.
+func logRPC(ctx context.Context, method string) (context.Context, func(error), error) {
+	start := time.Now()
+	requestID := firstValue(ctx, "x-request-id")
+	if requestID == "" {
+		requestID = fmt.Sprintf("grpc-%06d", middleware.NextRequestID())
+	}
+	grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestID))
+
+	ctx = logger.WithContext(ctx, slog.Default().With(
+		slog.String("request_id", requestID),
+		slog.String("rpc", method),
+	))
+	return ctx, func(err error) {
+		code := status.Code(err)
+		level := slog.LevelInfo
+		switch code {
+		case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss:
+			level = slog.LevelError
+		}
+		logger.FromContext(ctx).LogAttrs(ctx, level, "rpc completed",
+			slog.String("code", code.String()),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_addr", remoteAddr(ctx)),
+		)
+	}, nil
+}
+
+// authenticate accepts the API key in x-api-key or authorization metadata,
+// the same headers as the HTTP API. Feed tokens are for HTTP feeds only.
+func authenticate(authn *auth.Authenticator) step {
+	return func(ctx context.Context, _ string) (context.Context, func(error), error) {
+		key := firstValue(ctx, strings.ToLower(auth.APIKeyHeader))
+		if key == "" {
+			if token, ok := strings.CutPrefix(firstValue(ctx, "authorization"), "Bearer "); ok {
+				key = strings.TrimSpace(token)
+			}
+		}
+		principal, ok, err := authn.Authenticate(key)
+		if err != nil {
+			return ctx, nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if !ok {
+			return ctx, nil, nil
+		}
+		logger.AddAttrs(ctx, slog.String("user", principal.User))
+		return auth.WithPrincipal(ctx, principal), nil, nil
+	}
+}
+
+// rateLimit takes a token from the client's bucket, failing open like the
+// HTTP middleware. Rejected RPCs carry retry-after in the header metadata.
+func rateLimit(limiter *ratelimit.Limiter) step {
+	return func(ctx context.Context, method string) (context.Context, func(error), error) {
+		res, err := limiter.Take(ctx, remoteAddr(ctx), RateLimitMethod, method)
+		if err != nil {
+			logger.FromContext(ctx).ErrorContext(ctx, "rate limit store", slog.String("error", err.Error()))
+			return ctx, nil, nil
+		}
+		if !res.Allowed {
+			retryAfter := ratelimit.CeilSeconds(res.RetryAfter)
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+			return ctx, nil, status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry in %d seconds", retryAfter)
+		}
+		return ctx, nil, nil
+	}
+}
+
+func firstValue(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func remoteAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package grpcapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"sberTestTask/internal/auth"
+	"sberTestTask/internal/ratelimit"
+	"sberTestTask/internal/todo"
+	todov1 "sberTestTask/pkg/pb/todo/v1"
+)
+
+func TestServerOptions(t *testing.T) {
+	key, err := ratelimit.NewKeyFunc(ratelimit.KeyByUser)
+	require.NoError(t, err)
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Limit{Rate: 100, Burst: 100}, []ratelimit.Route{
+		{Method: RateLimitMethod, Pattern: todov1.TodoService_GetTask_FullMethodName, Limit: ratelimit.Limit{Rate: 0.001, Burst: 2}},
+	}, key)
+	authn := auth.NewAuthenticator(map[string]string{"secret": "alice"}, true)
+	client, mockUsecase, _ := setupServer(t, ServerOptions(authn, limiter)...)
+
+	mockUsecase.On("GetTask", mock.MatchedBy(func(ctx context.Context) bool {
+		p, ok := auth.FromContext(ctx)
+		return ok && p.User == "alice"
+	}), 1).Return(&todo.Task{ID: 1, Title: "Task"}, nil)
+
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+	}
+
+	_, err = client.GetTask(context.Background(), &todov1.GetTaskRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.GetTask(withKey("wrong"), &todov1.GetTaskRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Streams are authenticated too.
+	stream, err := client.WatchTasks(context.Background(), &todov1.WatchTasksRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	bearer := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	task, err := client.GetTask(bearer, &todov1.GetTaskRequest{Id: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Task", task.GetTitle())
+	_, err = client.GetTask(withKey("secret"), &todov1.GetTaskRequest{Id: 1})
+	require.NoError(t, err)
+
+	var header metadata.MD
+	_, err = client.GetTask(withKey("secret"), &todov1.GetTaskRequest{Id: 1}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.NotEmpty(t, header.Get("retry-after"))
+}
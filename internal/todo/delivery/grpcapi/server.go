@@ -0,0 +1,128 @@
+package grpcapi
+
+import (
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/events"
+	"sberTestTask/internal/todo/service"
+	todov1 "sberTestTask/pkg/pb/todo/v1"
+	"time"
+)
+
+const (
+	defaultPage  = 1
+	defaultLimit = 10
+)
+
+type Server struct {
+	todov1.UnimplementedTodoServiceServer
+	uc       service.TodoUsecase
+	bus      *events.Bus
+	location *time.Location
+}
+
+// NewServer serves TodoService on top of the same TodoUsecase as the REST API.
+// WatchTasks follows the changes published on bus; date filters are in loc.
+func NewServer(uc service.TodoUsecase, bus *events.Bus, loc *time.Location) *Server {
+	return &Server{uc: uc, bus: bus, location: loc}
+}
+
+func (s *Server) CreateTask(ctx context.Context, req *todov1.CreateTaskRequest) (*todov1.Task, error) {
+	task := &todo.Task{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		DueDate:     dueDate(req.GetDueDate()),
+		Completed:   req.GetCompleted(),
+		AllDay:      req.GetAllDay(),
+	}
+	if err := task.Validate(); err != nil {
+		return nil, toStatus(err)
+	}
+	if err := s.uc.CreateTask(ctx, task); err != nil {
+		return nil, toStatus(err)
+	}
+	return toProtoTask(task), nil
+}
+
+func (s *Server) GetTask(ctx context.Context, req *todov1.GetTaskRequest) (*todov1.Task, error) {
+	task, err := s.uc.GetTask(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProtoTask(task), nil
+}
+
+func (s *Server) UpdateTask(ctx context.Context, req *todov1.UpdateTaskRequest) (*todov1.Task, error) {
+	task, err := s.uc.GetTask(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if req.Title != nil {
+		task.Title = req.GetTitle()
+	}
+	if req.Description != nil {
+		task.Description = req.GetDescription()
+	}
+	if req.DueDate != nil {
+		task.DueDate = dueDate(req.GetDueDate())
+	}
+	if req.Completed != nil {
+		task.Completed = req.GetCompleted()
+	}
+	if req.AllDay != nil {
+		task.AllDay = req.GetAllDay()
+	}
+	if err := task.Validate(); err != nil {
+		return nil, toStatus(err)
+	}
+	if err := s.uc.UpdateTask(ctx, task); err != nil {
+		return nil, toStatus(err)
+	}
+	return toProtoTask(task), nil
+}
+
+func (s *Server) DeleteTask(ctx context.Context, req *todov1.DeleteTaskRequest) (*emptypb.Empty, error) {
+	if _, err := s.uc.GetTask(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus(err)
+	}
+	if err := s.uc.DeleteTask(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) ListTasks(ctx context.Context, req *todov1.ListTasksRequest) (*todov1.Pages, error) {
+	filter, err := parseFilter(req.GetFilter())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	page := int(req.GetPage())
+	if page <= 0 {
+		page = defaultPage
+	}
+
+	pages, err := s.uc.ListTasks(ctx, filter, limit, page)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProtoPages(pages), nil
+}
+
+func (s *Server) CountTasks(ctx context.Context, req *todov1.CountTasksRequest) (*todov1.CountTasksResponse, error) {
+	filter, err := parseFilter(req.GetFilter())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	count, err := s.uc.CountTasks(ctx, filter)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &todov1.CountTasksResponse{Count: int32(count)}, nil
+}
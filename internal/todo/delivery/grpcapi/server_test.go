@@ -0,0 +1,164 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/events"
+	"sberTestTask/internal/todo/service"
+	"sberTestTask/internal/todo/tests/mocks/serviceMock"
+	todov1 "sberTestTask/pkg/pb/todo/v1"
+)
+
+func setupClientWithMock(t *testing.T) (todov1.TodoServiceClient, *serviceMock.MockTodoUsecase) {
+	client, mockUsecase, _ := setupServer(t)
+	return client, mockUsecase
+}
+
+func setupServer(t *testing.T, opts ...grpc.ServerOption) (todov1.TodoServiceClient, *serviceMock.MockTodoUsecase, *events.Bus) {
+	mockUsecase := new(serviceMock.MockTodoUsecase)
+	bus := events.NewBus(16)
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(opts...)
+	todov1.RegisterTodoServiceServer(srv, NewServer(mockUsecase, bus, time.UTC))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return todov1.NewTodoServiceClient(conn), mockUsecase, bus
+}
+
+func TestCreateTask(t *testing.T) {
+	client, mockUsecase := setupClientWithMock(t)
+	date := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+
+	mockUsecase.On("CreateTask", mock.Anything, mock.AnythingOfType("*todo.Task")).Run(func(args mock.Arguments) {
+		args.Get(1).(*todo.Task).ID = 1
+	}).Return(nil)
+
+	task, err := client.CreateTask(context.Background(), &todov1.CreateTaskRequest{Title: "Test Task", DueDate: timestamppb.New(date)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), task.GetId())
+	assert.Equal(t, date, task.GetDueDate().AsTime())
+
+	_, err = client.CreateTask(context.Background(), &todov1.CreateTaskRequest{DueDate: timestamppb.New(date)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	details := status.Convert(err).Details()
+	if assert.Len(t, details, 1) {
+		violations := details[0].(*errdetails.BadRequest).GetFieldViolations()
+		assert.Equal(t, "title", violations[0].GetField())
+		assert.Equal(t, "cannot be empty", violations[0].GetDescription())
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	client, mockUsecase := setupClientWithMock(t)
+
+	tests := []struct {
+		name         string
+		id           int
+		mockErr      error
+		expectedCode codes.Code
+	}{
+		{name: "Not Found", id: 1, mockErr: service.ErrIdNotFound, expectedCode: codes.NotFound},
+		{name: "Server Error", id: 2, mockErr: service.ErrOnServer, expectedCode: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsecase.On("GetTask", mock.Anything, tt.id).Return((*todo.Task)(nil), tt.mockErr)
+
+			_, err := client.GetTask(context.Background(), &todov1.GetTaskRequest{Id: int64(tt.id)})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestWatchTasks(t *testing.T) {
+	client, mockUsecase, bus := setupServer(t)
+	date := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	open := false
+	filter := todo.TaskFilter{Completed: &open, Location: time.UTC}
+	seen := &todo.Task{ID: 1, Title: "Seen", DueDate: &date}
+
+	read := make(chan struct{})
+	mockUsecase.On("CountTasks", mock.Anything, filter).Return(1, nil)
+	mockUsecase.On("ListTasks", mock.Anything, filter, 1, 1).Run(func(mock.Arguments) { close(read) }).
+		Return(&todo.Pages{CountPage: 1, CurPage: 1, Tasks: []*todo.Task{seen}}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stream, err := client.WatchTasks(ctx, &todov1.WatchTasksRequest{Filter: &todov1.TaskFilter{Completed: &open}})
+	assert.NoError(t, err)
+	<-read
+
+	bus.Publish(ctx, events.TaskCreated, &todo.Task{ID: 2, Title: "New", DueDate: &date})
+	bus.Publish(ctx, events.TaskUpdated, &todo.Task{ID: 3, Title: "Unseen", DueDate: &date, Completed: true})
+	bus.Publish(ctx, events.TaskUpdated, &todo.Task{ID: 1, Title: "Seen", DueDate: &date, Completed: true})
+	bus.Publish(ctx, events.TaskUpdated, &todo.Task{ID: 2, Title: "Renamed", DueDate: &date})
+
+	for _, want := range []struct {
+		typ todov1.TaskEvent_Type
+		id  int64
+	}{
+		{todov1.TaskEvent_TYPE_CREATED, 2},
+		// Completing task 1 moves it out of the filter; task 3 never was in.
+		{todov1.TaskEvent_TYPE_DELETED, 1},
+		{todov1.TaskEvent_TYPE_UPDATED, 2},
+	} {
+		event, err := stream.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, want.typ, event.GetType())
+		assert.Equal(t, want.id, event.GetTask().GetId())
+	}
+}
+
+func TestAllDay(t *testing.T) {
+	client, mockUsecase := setupClientWithMock(t)
+	day := time.Date(2024, 6, 7, 0, 0, 0, 0, time.UTC)
+
+	mockUsecase.On("CreateTask", mock.Anything, mock.MatchedBy(func(task *todo.Task) bool { return task.AllDay })).Return(nil)
+	task, err := client.CreateTask(context.Background(), &todov1.CreateTaskRequest{Title: "Day", DueDate: timestamppb.New(day), AllDay: true})
+	assert.NoError(t, err)
+	assert.True(t, task.GetAllDay())
+
+	mockUsecase.On("GetTask", mock.Anything, 1).Return(&todo.Task{ID: 1, Title: "Day", DueDate: &day, AllDay: true}, nil)
+	mockUsecase.On("UpdateTask", mock.Anything, mock.MatchedBy(func(task *todo.Task) bool { return !task.AllDay })).Return(nil)
+	timed := false
+	task, err = client.UpdateTask(context.Background(), &todov1.UpdateTaskRequest{Id: 1, AllDay: &timed})
+	assert.NoError(t, err)
+	assert.False(t, task.GetAllDay())
+}
+
+func TestListTasksFilter(t *testing.T) {
+	client, mockUsecase := setupClientWithMock(t)
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+	overdue := true
+	filter := todo.TaskFilter{Overdue: &overdue, Location: moscow}
+	mockUsecase.On("ListTasks", mock.Anything, filter, defaultLimit, defaultPage).Return(&todo.Pages{CountPage: 1, CurPage: 1}, nil)
+
+	_, err = client.ListTasks(context.Background(), &todov1.ListTasksRequest{Filter: &todov1.TaskFilter{Overdue: &overdue, Tz: "Europe/Moscow"}})
+	assert.NoError(t, err)
+
+	_, err = client.ListTasks(context.Background(), &todov1.ListTasksRequest{Filter: &todov1.TaskFilter{Tz: "Mars/Olympus"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "invalid time zone", status.Convert(err).Message())
+}
@@ -0,0 +1,109 @@
+package grpcapi
+
+import (
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/events"
+	todov1 "sberTestTask/pkg/pb/todo/v1"
+	"time"
+)
+
+// WatchTasks streams the changes of tasks matching the filter as they are
+// published on the event bus. The matching tasks are read once, so that a
+// task that leaves the filter is reported as deleted.
+func (s *Server) WatchTasks(req *todov1.WatchTasksRequest, stream todov1.TodoService_WatchTasksServer) error {
+	ctx := stream.Context()
+	filter, err := parseFilter(req.GetFilter())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	// The snapshot and the changes must agree on calendar days.
+	if filter.Location == nil {
+		filter.Location = s.location
+	}
+
+	// Subscribe before reading, so that no change in between is missed.
+	sub, _ := s.bus.Subscribe(0)
+	defer func() { sub.Close() }()
+	matching, err := s.matching(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	var lastID uint64
+	send := func(event events.Event) error {
+		lastID = event.ID
+		// Overdue is judged when a task changes; a task passing its due
+		// date without a change is not reported.
+		if filter.Overdue != nil {
+			filter.Now = time.Now()
+		}
+		if msg := watchEvent(filter, matching, event); msg != nil {
+			return stream.Send(msg)
+		}
+		return nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-sub.C:
+			if ok {
+				if err := send(event); err != nil {
+					return err
+				}
+				continue
+			}
+			// The stream fell behind and was dropped by the bus; catch up
+			// from its history.
+			var missed []events.Event
+			sub, missed = s.bus.Subscribe(lastID)
+			for _, event := range missed {
+				if err := send(event); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// matching returns the ids of the tasks matching filter.
+func (s *Server) matching(ctx context.Context, filter todo.TaskFilter) (map[int]bool, error) {
+	count, err := s.uc.CountTasks(ctx, filter)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	ids := make(map[int]bool, count)
+	if count == 0 {
+		return ids, nil
+	}
+
+	pages, err := s.uc.ListTasks(ctx, filter, count, 1)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	for _, task := range pages.Tasks {
+		ids[task.ID] = true
+	}
+	return ids, nil
+}
+
+// watchEvent turns a change into the message for a stream that has seen the
+// matching tasks, and updates them. Changes of other tasks return nil.
+func watchEvent(filter todo.TaskFilter, matching map[int]bool, event events.Event) *todov1.TaskEvent {
+	id := event.Task.ID
+	match := event.Type != events.TaskDeleted && filter.Match(event.Task)
+	switch {
+	case match && matching[id]:
+		return &todov1.TaskEvent{Type: todov1.TaskEvent_TYPE_UPDATED, Task: toProtoTask(event.Task)}
+	case match:
+		matching[id] = true
+		return &todov1.TaskEvent{Type: todov1.TaskEvent_TYPE_CREATED, Task: toProtoTask(event.Task)}
+	case matching[id]:
+		delete(matching, id)
+		return &todov1.TaskEvent{Type: todov1.TaskEvent_TYPE_DELETED, Task: toProtoTask(event.Task)}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package wsapi
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait * 9 / 10
+	// sendBuffer is the number of messages queued for a client before it is
+	// considered too slow and disconnected.
+	sendBuffer   = 256
+	maxReadBytes = 4096
+)
+
+type client struct {
+	hub  *Hub
+	conn *websocket.Conn
+	log  *slog.Logger
+
+	// room is the key of the room the client is in, guarded by hub.mu.
+	room string
+
+	out       chan serverMessage
+	closeOnce sync.Once
+	closed    chan struct{}
+	// closeCode is sent in the close frame when the client is disconnected by the server.
+	closeCode int
+	closeText string
+}
+
+func newClient(hub *Hub, conn *websocket.Conn, log *slog.Logger) *client {
+	return &client{
+		hub:    hub,
+		conn:   conn,
+		log:    log,
+		out:    make(chan serverMessage, sendBuffer),
+		closed: make(chan struct{}),
+	}
+}
+
+// send queues msg without blocking the hub. A client whose queue is full is
+// disconnected; it is expected to reconnect and refetch its tasks.
+func (c *client) send(msg serverMessage) {
+	select {
+	case c.out <- msg:
+	default:
+		c.log.Warn("websocket client too slow, disconnecting")
+		c.disconnect(websocket.CloseTryAgainLater, "client too slow")
+	}
+}
+
+func (c *client) disconnect(code int, text string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeText = text
+		close(c.closed)
+	})
+}
+
+func (c *client) shutdown() {
+	c.disconnect(websocket.CloseGoingAway, "server shutting down")
+}
+
+// readPump handles subscription messages until the connection fails.
+func (c *client) readPump() {
+	defer c.disconnect(websocket.CloseNormalClosure, "")
+
+	c.conn.SetReadLimit(maxReadBytes)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				c.log.Debug("websocket read", slog.String("error", err.Error()))
+			}
+			return
+		}
+
+		var msg clientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.send(serverMessage{Type: msgError, Message: "invalid message"})
+			continue
+		}
+		switch msg.Type {
+		case msgSubscribe:
+			filter := Filter{}
+			if msg.Filter != nil {
+				filter = *msg.Filter
+			}
+			c.hub.subscribe(c, filter)
+			c.send(serverMessage{Type: msgSubscribed, Filter: &filter})
+		case msgUnsubscribe:
+			c.hub.unsubscribe(c)
+			c.send(serverMessage{Type: msgUnsubscribed})
+		default:
+			c.send(serverMessage{Type: msgError, Message: "unknown message type"})
+		}
+	}
+}
+
+// writePump writes queued messages and pings until the client is disconnected.
+func (c *client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-c.out:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				c.disconnect(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.disconnect(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-c.closed:
+			if c.closeCode != websocket.CloseAbnormalClosure {
+				msg := websocket.FormatCloseMessage(c.closeCode, c.closeText)
+				c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package wsapi
+
+import (
+	"encoding/json"
+	"sberTestTask/internal/todo"
+	"time"
+)
+
+// Filter selects the tasks a client is interested in. All fields are optional.
+type Filter struct {
+	Completed *bool      `json:"completed,omitempty"`
+	DueFrom   *time.Time `json:"due_from,omitempty"`
+	DueTo     *time.Time `json:"due_to,omitempty"`
+}
+
+// key identifies the room of all clients subscribed to the same filter.
+func (f Filter) key() string {
+	b, _ := json.Marshal(f)
+	return string(b)
+}
+
+func (f Filter) Match(task *todo.Task) bool {
+	if f.Completed != nil && task.Completed != *f.Completed {
+		return false
+	}
+	if f.DueFrom != nil || f.DueTo != nil {
+		if task.DueDate == nil {
+			return false
+		}
+		if f.DueFrom != nil && task.DueDate.Before(*f.DueFrom) {
+			return false
+		}
+		if f.DueTo != nil && !task.DueDate.Before(*f.DueTo) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package wsapi
+
+import (
+	"github.com/gorilla/websocket"
+	"log/slog"
+	"net/http"
+	"sberTestTask/internal/auth"
+	"sberTestTask/internal/logger"
+)
+
+type Handler struct {
+	hub      *Hub
+	upgrader websocket.Upgrader
+}
+
+// NewHandler upgrades requests to WebSocket connections served by hub.
+// Authentication happens before the upgrade, in the regular HTTP middlewares.
+func NewHandler(hub *Hub, allowedOrigins []string) *Handler {
+	h := &Handler{hub: hub}
+	if len(allowedOrigins) > 0 {
+		h.upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			for _, allowed := range allowedOrigins {
+				if origin == allowed {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written the error response.
+		return
+	}
+
+	log := logger.FromContext(r.Context())
+	if p, ok := auth.FromContext(r.Context()); ok {
+		log = log.With(slog.String("user", p.User))
+	}
+
+	c := newClient(h.hub, conn, log)
+	h.hub.register(c)
+	log.Info("websocket connected")
+
+	go c.readPump()
+	c.writePump()
+
+	h.hub.unregister(c)
+	conn.Close()
+	log.Info("websocket disconnected")
+}
@@ -0,0 +1,85 @@
+package wsapi
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/events"
+)
+
+func TestSubscription(t *testing.T) {
+	bus := events.NewBus(0)
+	hub := NewHub(bus)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	srv := httptest.NewServer(NewHandler(hub, nil))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	assert.NoError(t, conn.WriteJSON(clientMessage{Type: msgSubscribe, Filter: &Filter{Completed: new(bool)}}))
+	var msg serverMessage
+	assert.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, msgSubscribed, msg.Type)
+
+	date := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	bus.Publish(ctx, events.TaskCreated, &todo.Task{ID: 1, Title: "Open", DueDate: &date})
+	assert.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, msgUpsert, msg.Type)
+	assert.Equal(t, "created", msg.Event)
+	assert.Equal(t, "Open", msg.Task.Title)
+
+	// Completing the task moves it out of the filter.
+	bus.Publish(ctx, events.TaskUpdated, &todo.Task{ID: 1, Title: "Open", DueDate: &date, Completed: true})
+	msg = serverMessage{}
+	assert.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, msgRemove, msg.Type)
+	assert.Equal(t, 1, msg.TaskID)
+}
+
+func TestFilterMatch(t *testing.T) {
+	from := time.Date(2024, 6, 7, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	inside := from.Add(15 * time.Hour)
+	outside := to.Add(time.Hour)
+	filter := Filter{DueFrom: &from, DueTo: &to}
+
+	assert.True(t, filter.Match(&todo.Task{DueDate: &inside}))
+	assert.False(t, filter.Match(&todo.Task{DueDate: &outside}))
+	assert.False(t, filter.Match(&todo.Task{DueDate: &to}))
+	assert.False(t, filter.Match(&todo.Task{}))
+}
+
+func TestSlowClientDisconnected(t *testing.T) {
+	hub := NewHub(events.NewBus(0))
+	c := newClient(hub, nil, nil)
+	c.log = slogDiscard()
+
+	for i := 0; i <= sendBuffer; i++ {
+		c.send(serverMessage{Type: msgUpsert})
+	}
+
+	select {
+	case <-c.closed:
+		assert.Equal(t, websocket.CloseTryAgainLater, c.closeCode)
+	default:
+		t.Fatal("slow client was not disconnected")
+	}
+}
+
+func slogDiscard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
@@ -0,0 +1,132 @@
+package wsapi
+
+import (
+	"context"
+	"sberTestTask/internal/todo/events"
+	"sync"
+)
+
+// room groups the clients subscribed to the same filter, so that every event
+// is matched once per filter rather than once per client.
+type room struct {
+	filter  Filter
+	clients map[*client]struct{}
+}
+
+// Hub receives task events from the bus and sends diffs to the subscribed clients.
+type Hub struct {
+	bus *events.Bus
+	sub *events.Subscription
+
+	mu      sync.Mutex
+	rooms   map[string]*room
+	clients map[*client]struct{}
+}
+
+func NewHub(bus *events.Bus) *Hub {
+	sub, _ := bus.Subscribe(0)
+	return &Hub{bus: bus, sub: sub, rooms: make(map[string]*room), clients: make(map[*client]struct{})}
+}
+
+// Run forwards events to rooms until ctx is cancelled, then disconnects all clients.
+func (h *Hub) Run(ctx context.Context) error {
+	for {
+		if done := h.forward(ctx, h.sub); done {
+			h.sub.Close()
+			h.closeAll()
+			return nil
+		}
+		// The hub fell behind and was dropped by the bus; subscribe again.
+		h.sub, _ = h.bus.Subscribe(0)
+	}
+}
+
+func (h *Hub) forward(ctx context.Context, sub *events.Subscription) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return true
+		case event, ok := <-sub.C:
+			if !ok {
+				return false
+			}
+			h.broadcast(event)
+		}
+	}
+}
+
+func (h *Hub) broadcast(event events.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, r := range h.rooms {
+		msg := diff(r.filter, event)
+		for c := range r.clients {
+			c.send(msg)
+		}
+	}
+}
+
+// diff turns an event into the message for clients of filter.
+func diff(filter Filter, event events.Event) serverMessage {
+	if event.Type != events.TaskDeleted && filter.Match(event.Task) {
+		return serverMessage{Type: msgUpsert, EventID: event.ID, Event: string(event.Type), Task: event.Task}
+	}
+	return serverMessage{Type: msgRemove, EventID: event.ID, Event: string(event.Type), TaskID: event.Task.ID}
+}
+
+func (h *Hub) register(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[c] = struct{}{}
+}
+
+func (h *Hub) unregister(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(c)
+	delete(h.clients, c)
+}
+
+func (h *Hub) subscribe(c *client, filter Filter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(c)
+
+	key := filter.key()
+	r, ok := h.rooms[key]
+	if !ok {
+		r = &room{filter: filter, clients: make(map[*client]struct{})}
+		h.rooms[key] = r
+	}
+	r.clients[c] = struct{}{}
+	c.room = key
+}
+
+func (h *Hub) unsubscribe(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(c)
+}
+
+// leave removes c from its room. h.mu must be held.
+func (h *Hub) leave(c *client) {
+	if c.room == "" {
+		return
+	}
+	if r, ok := h.rooms[c.room]; ok {
+		delete(r.clients, c)
+		if len(r.clients) == 0 {
+			delete(h.rooms, c.room)
+		}
+	}
+	c.room = ""
+}
+
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		c.shutdown()
+	}
+}
@@ -0,0 +1,36 @@
+package wsapi
+
+import "sberTestTask/internal/todo"
+
+// Messages sent by the client.
+const (
+	msgSubscribe   = "subscribe"
+	msgUnsubscribe = "unsubscribe"
+)
+
+// Messages sent by the server.
+const (
+	msgSubscribed   = "subscribed"
+	msgUnsubscribed = "unsubscribed"
+	msgError        = "error"
+	// msgUpsert carries a task that was created or changed and matches the filter.
+	msgUpsert = "upsert"
+	// msgRemove tells the client to drop a task: it was deleted or no longer
+	// matches the filter.
+	msgRemove = "remove"
+)
+
+type clientMessage struct {
+	Type   string  `json:"type"`
+	Filter *Filter `json:"filter,omitempty"`
+}
+
+type serverMessage struct {
+	Type    string     `json:"type"`
+	EventID uint64     `json:"event_id,omitempty"`
+	Event   string     `json:"event,omitempty"`
+	Task    *todo.Task `json:"task,omitempty"`
+	TaskID  int        `json:"task_id,omitempty"`
+	Filter  *Filter    `json:"filter,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
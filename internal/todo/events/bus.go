@@ -0,0 +1,104 @@
+package events
+
+import (
+	"context"
+	"sberTestTask/internal/todo"
+	"sync"
+	"time"
+)
+
+const subscriberBuffer = 64
+
+// Bus fans events out to in-process subscribers and keeps the most recent
+// ones so that subscribers can catch up after reconnecting.
+type Bus struct {
+	mu      sync.Mutex
+	lastID  uint64
+	history []Event
+	size    int
+	subs    map[*Subscription]struct{}
+}
+
+func NewBus(historySize int) *Bus {
+	return &Bus{size: historySize, subs: make(map[*Subscription]struct{})}
+}
+
+// Publish assigns the next local id to the event and dispatches it.
+func (b *Bus) Publish(_ context.Context, typ Type, task *todo.Task) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dispatch(Event{ID: b.lastID + 1, Type: typ, Task: task, Time: time.Now()})
+	return nil
+}
+
+// Dispatch delivers an event whose id was assigned elsewhere, e.g. by another
+// replica.
+func (b *Bus) Dispatch(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dispatch(event)
+}
+
+func (b *Bus) dispatch(event Event) {
+	if event.ID > b.lastID {
+		b.lastID = event.ID
+	}
+	if b.size > 0 {
+		if len(b.history) == b.size {
+			copy(b.history, b.history[1:])
+			b.history = b.history[:b.size-1]
+		}
+		b.history = append(b.history, event)
+	}
+
+	for sub := range b.subs {
+		select {
+		case sub.c <- event:
+		default:
+			// The subscriber is too slow; drop it so it can resume later.
+			b.unsubscribe(sub)
+		}
+	}
+}
+
+// Subscription receives events until it is closed. C is closed when the
+// subscription is dropped because the consumer could not keep up.
+type Subscription struct {
+	C   <-chan Event
+	c   chan Event
+	bus *Bus
+}
+
+// Subscribe returns a subscription and the buffered events newer than
+// lastEventID. Pass 0 to receive new events only.
+func (b *Bus) Subscribe(lastEventID uint64) (*Subscription, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := make(chan Event, subscriberBuffer)
+	sub := &Subscription{C: c, c: c, bus: b}
+	b.subs[sub] = struct{}{}
+
+	var missed []Event
+	if lastEventID > 0 {
+		for _, event := range b.history {
+			if event.ID > lastEventID {
+				missed = append(missed, event)
+			}
+		}
+	}
+	return sub, missed
+}
+
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.unsubscribe(s)
+}
+
+func (b *Bus) unsubscribe(sub *Subscription) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.c)
+	}
+}
@@ -0,0 +1,46 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sberTestTask/internal/todo"
+)
+
+func TestBusResume(t *testing.T) {
+	bus := NewBus(2)
+	for id := 1; id <= 3; id++ {
+		assert.NoError(t, bus.Publish(context.Background(), TaskCreated, &todo.Task{ID: id}))
+	}
+
+	sub, missed := bus.Subscribe(1)
+	defer sub.Close()
+
+	// Only the two most recent events are kept.
+	if assert.Len(t, missed, 2) {
+		assert.Equal(t, uint64(2), missed[0].ID)
+		assert.Equal(t, uint64(3), missed[1].ID)
+	}
+
+	assert.NoError(t, bus.Publish(context.Background(), TaskDeleted, &todo.Task{ID: 1}))
+	event := <-sub.C
+	assert.Equal(t, uint64(4), event.ID)
+	assert.Equal(t, TaskDeleted, event.Type)
+}
+
+func TestBusDropsSlowSubscriber(t *testing.T) {
+	bus := NewBus(0)
+	sub, _ := bus.Subscribe(0)
+
+	for id := 0; id <= subscriberBuffer; id++ {
+		bus.Dispatch(Event{ID: uint64(id + 1), Type: TaskUpdated, Task: &todo.Task{ID: id}})
+	}
+
+	received := 0
+	for range sub.C {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+	sub.Close()
+}
@@ -0,0 +1,42 @@
+package events
+
+import (
+	"context"
+	"sberTestTask/internal/todo"
+	"time"
+)
+
+type Type string
+
+const (
+	TaskCreated Type = "created"
+	TaskUpdated Type = "updated"
+	TaskDeleted Type = "deleted"
+
+	// TaskCompleted is recorded in the outbox when an update marks a task
+	// as completed. The bus reports such changes as TaskUpdated.
+	TaskCompleted Type = "completed"
+)
+
+// Event describes a change of a task. IDs increase monotonically so that
+// clients can resume a feed from the last event they have seen.
+type Event struct {
+	ID   uint64     `json:"id"`
+	Type Type       `json:"type"`
+	Task *todo.Task `json:"task"`
+	Time time.Time  `json:"time"`
+}
+
+// Publisher is notified by the service after a task mutation succeeded.
+type Publisher interface {
+	Publish(ctx context.Context, typ Type, task *todo.Task) error
+}
+
+type nopPublisher struct{}
+
+// NopPublisher discards all events.
+func NopPublisher() Publisher {
+	return nopPublisher{}
+}
+
+func (nopPublisher) Publish(context.Context, Type, *todo.Task) error { return nil }
@@ -0,0 +1,117 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/lib/pq"
+	"log/slog"
+	"sberTestTask/internal/todo"
+	"time"
+)
+
+const notifyChannel = "task_events"
+
+// notification is the payload of a NOTIFY. It stays small, as payloads are
+// limited to 8000 bytes; listeners load the task itself.
+type notification struct {
+	ID     uint64 `json:"id"`
+	Type   Type   `json:"type"`
+	TaskID int    `json:"task_id"`
+}
+
+// TaskGetter loads the current version of a task.
+type TaskGetter interface {
+	GetTask(ctx context.Context, id int) (*todo.Task, error)
+}
+
+type notifyPublisher struct {
+	db *sql.DB
+}
+
+// NewNotifyPublisher publishes events through Postgres NOTIFY so that every
+// replica running a Listener receives them. Event ids come from a sequence
+// and are therefore the same on all replicas.
+func NewNotifyPublisher(db *sql.DB) Publisher {
+	return &notifyPublisher{db: db}
+}
+
+func (p *notifyPublisher) Publish(ctx context.Context, typ Type, task *todo.Task) error {
+	var id uint64
+	if err := p.db.QueryRowContext(ctx, "SELECT nextval('task_events_id_seq')").Scan(&id); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(notification{ID: id, Type: typ, TaskID: task.ID})
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", notifyChannel, string(payload))
+	return err
+}
+
+// Listener forwards notifications from Postgres into the local bus.
+type Listener struct {
+	url   string
+	bus   *Bus
+	tasks TaskGetter
+}
+
+// NewListener dispatches an event for every notification, with the task
+// loaded from tasks. Deleted tasks are passed on with only their ID.
+func NewListener(url string, bus *Bus, tasks TaskGetter) *Listener {
+	return &Listener{url: url, bus: bus, tasks: tasks}
+}
+
+func (l *Listener) Run(ctx context.Context) error {
+	listener := pq.NewListener(l.url, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Error("task events listener", slog.String("error", err.Error()))
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(notifyChannel); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-listener.Notify:
+			// A nil notification means the connection was re-established
+			// and notifications may have been lost.
+			if n == nil {
+				slog.Warn("task events listener reconnected")
+				continue
+			}
+			l.handle(ctx, n.Extra)
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
+
+// handle dispatches the event of a notification payload.
+func (l *Listener) handle(ctx context.Context, payload string) {
+	var n notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		slog.Error("decode task event", slog.String("error", err.Error()))
+		return
+	}
+	event := Event{ID: n.ID, Type: n.Type, Task: &todo.Task{ID: n.TaskID}, Time: time.Now()}
+	if n.Type != TaskDeleted {
+		task, err := l.tasks.GetTask(ctx, n.TaskID)
+		if errors.Is(err, sql.ErrNoRows) {
+			// The task was deleted since; its deleted event follows.
+			return
+		}
+		if err != nil {
+			slog.Error("load task for event", slog.Uint64("event_id", n.ID), slog.Int("task_id", n.TaskID), slog.String("error", err.Error()))
+			return
+		}
+		event.Task = task
+	}
+	l.bus.Dispatch(event)
+}
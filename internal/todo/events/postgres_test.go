@@ -0,0 +1,44 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sberTestTask/internal/todo"
+)
+
+type taskMap map[int]*todo.Task
+
+func (m taskMap) GetTask(ctx context.Context, id int) (*todo.Task, error) {
+	if task, ok := m[id]; ok {
+		return task, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func TestListenerLoadsTask(t *testing.T) {
+	bus := NewBus(10)
+	sub, _ := bus.Subscribe(0)
+	defer sub.Close()
+	l := NewListener("", bus, taskMap{1: {ID: 1, Title: "Stored"}})
+
+	l.handle(context.Background(), `{"id":5,"type":"updated","task_id":1}`)
+	event := <-sub.C
+	assert.Equal(t, uint64(5), event.ID)
+	assert.Equal(t, TaskUpdated, event.Type)
+	assert.Equal(t, &todo.Task{ID: 1, Title: "Stored"}, event.Task)
+
+	// A deleted task is not loaded.
+	l.handle(context.Background(), `{"id":6,"type":"deleted","task_id":2}`)
+	event = <-sub.C
+	assert.Equal(t, TaskDeleted, event.Type)
+	assert.Equal(t, &todo.Task{ID: 2}, event.Task)
+
+	// A task deleted before it could be loaded waits for its deleted event.
+	l.handle(context.Background(), `{"id":7,"type":"updated","task_id":3}`)
+	l.handle(context.Background(), `{"id":8,"type":"deleted","task_id":3}`)
+	event = <-sub.C
+	assert.Equal(t, uint64(8), event.ID)
+}
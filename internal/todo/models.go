@@ -1,19 +1,206 @@
 package todo
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type Task struct {
 	ID          int        `json:"id,omitempty"`
 	Title       string     `json:"title"`
 	Description string     `json:"description,omitempty"`
-	DueDate     *time.Time `json:"due_date" swaggertype:"string" example:"2024-06-07T15:00:00Z"`
+	DueDate     *time.Time `json:"due_date"`
 	Completed   bool       `json:"completed"`
+	// AllDay tasks are due on a calendar day rather than at an instant.
+	// Their DueDate is midnight UTC of that day and they become overdue
+	// once the day has passed in the caller's time zone.
+	AllDay bool `json:"all_day,omitempty"`
+	// UpdatedAt is set by the database on every write and backs the
+	// Last-Modified header; it is ignored on input.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+
+	// Computed when the task is read, relative to the service clock and
+	// time zone; never stored.
+	Overdue *bool `json:"overdue,omitempty"`
+	// DueIn is the number of seconds until the due date, negative once it
+	// has passed. Not set for completed tasks.
+	DueIn *int64 `json:"due_in,omitempty"`
+	// DaysOverdue counts calendar days since the due date, 0 unless overdue.
+	DaysOverdue *int `json:"days_overdue,omitempty"`
+}
+
+// Normalize moves the due date of an all-day task to midnight UTC of its
+// calendar day, as given in the due date's own offset.
+func (t *Task) Normalize() {
+	if t.AllDay && t.DueDate != nil {
+		y, m, d := t.DueDate.Date()
+		day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		t.DueDate = &day
+	}
+}
+
+// DueAt returns the instant the task falls due in loc: the due date of a
+// timed task, or the end of the due day of an all-day task.
+func (t *Task) DueAt(loc *time.Location) time.Time {
+	if !t.AllDay {
+		return *t.DueDate
+	}
+	y, m, d := t.DueDate.UTC().Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+}
+
+// SetComputed fills the computed fields as of now. Calendar days are
+// counted in loc.
+func (t *Task) SetComputed(now time.Time, loc *time.Location) {
+	t.ClearComputed()
+	if t.DueDate == nil {
+		return
+	}
+	dueAt := t.DueAt(loc)
+	overdue := !t.Completed && dueAt.Before(now)
+	daysOverdue := 0
+	if overdue {
+		due := t.DueDate.In(loc)
+		if t.AllDay {
+			due = dueAt.AddDate(0, 0, -1)
+		}
+		daysOverdue = calendarDays(due, now.In(loc))
+	}
+	t.Overdue, t.DaysOverdue = &overdue, &daysOverdue
+	if !t.Completed {
+		dueIn := int64(dueAt.Sub(now) / time.Second)
+		t.DueIn = &dueIn
+	}
+}
+
+// ClearComputed resets the computed fields, e.g. before a task is stored
+// or published.
+func (t *Task) ClearComputed() {
+	t.Overdue, t.DueIn, t.DaysOverdue = nil, nil, nil
+}
+
+// calendarDays returns the number of midnights between from and to, which
+// must be in the same location.
+func calendarDays(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a) / (24 * time.Hour))
+}
+
+// TaskFilter selects tasks for listing, counting and exporting. Nil fields
+// match every task.
+type TaskFilter struct {
+	Completed *bool
+	// DueDate matches tasks due on its calendar day, which runs from
+	// midnight to midnight in Location for timed tasks.
+	DueDate *time.Time
+	// Overdue matches open tasks due before Now, or all other tasks.
+	Overdue *bool
+	// Now is the reference time of Overdue, set by the service from its
+	// clock.
+	Now time.Time
+	// Location is the caller's time zone, set by the service. UTC when
+	// nil.
+	Location *time.Location
+}
+
+// Match reports whether the query of f selects task, for filtering changes
+// as they happen.
+func (f TaskFilter) Match(task *Task) bool {
+	loc := f.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	if f.Completed != nil && task.Completed != *f.Completed {
+		return false
+	}
+	if f.DueDate != nil {
+		if task.DueDate == nil {
+			return false
+		}
+		y, m, d := f.DueDate.Date()
+		if task.AllDay {
+			ty, tm, td := task.DueDate.UTC().Date()
+			if ty != y || tm != m || td != d {
+				return false
+			}
+		} else {
+			start := time.Date(y, m, d, 0, 0, 0, 0, loc)
+			if task.DueDate.Before(start) || !task.DueDate.Before(start.AddDate(0, 0, 1)) {
+				return false
+			}
+		}
+	}
+	if f.Overdue != nil {
+		overdue := !task.Completed && task.DueDate != nil && task.DueAt(loc).Before(f.Now)
+		if overdue != *f.Overdue {
+			return false
+		}
+	}
+	return true
 }
+
+type locationKey struct{}
+
+// WithLocation returns a context carrying the caller's time zone, used
+// for date filters and the computed task fields.
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+// LocationFromContext returns the time zone set by WithLocation.
+func LocationFromContext(ctx context.Context) (*time.Location, bool) {
+	loc, ok := ctx.Value(locationKey{}).(*time.Location)
+	return loc, ok && loc != nil
+}
+
+// Stats buckets group the completion series by calendar day or by ISO
+// week starting on Monday.
+const (
+	BucketDay  = "day"
+	BucketWeek = "week"
+)
+
+// StatsOptions shape the time series of Stats. Days are calendar days in
+// the filter's Location.
+type StatsOptions struct {
+	Bucket string
+	// From and To bound the completion series by due day, inclusive.
+	From, To time.Time
+	// UpcomingDays is the number of days, starting today, in Upcoming.
+	UpcomingDays int
+}
+
+type Stats struct {
+	Total     int `json:"total"`
+	Open      int `json:"open"`
+	Completed int `json:"completed"`
+	Overdue   int `json:"overdue"`
+	// AvgTimeToComplete is the mean number of seconds from creation to
+	// completion, null when no completed task has both.
+	AvgTimeToComplete *float64 `json:"avg_time_to_complete"`
+	// Completion has one bucket per day or week from From to To.
+	Completion []CompletionBucket `json:"completion"`
+	// Upcoming counts open tasks due on each of the next days.
+	Upcoming []DayCount `json:"upcoming"`
+}
+
+// CompletionBucket counts the tasks due in a period and how many of them
+// are completed.
+type CompletionBucket struct {
+	Start     string  `json:"start"`
+	Due       int     `json:"due"`
+	Completed int     `json:"completed"`
+	Rate      float64 `json:"rate"`
+}
+
+type DayCount struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
 type Pages struct {
 	CountPage int     `json:"count_page"`
 	CurPage   int     `json:"cur_page"`
 	Tasks     []*Task `json:"tasks"`
 }
-type ErrorResponse struct {
-	Message string `swaggertype:"string" example:"Error"`
-}
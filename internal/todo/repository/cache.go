@@ -0,0 +1,351 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sberTestTask/internal/cache"
+	"sberTestTask/internal/logger"
+	"sberTestTask/internal/todo"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	DefaultCacheTTL         = 30 * time.Second
+	DefaultNegativeCacheTTL = 5 * time.Second
+
+	generationKey = "todo:generation"
+)
+
+type cachingRepository struct {
+	next        TodoRepository
+	backend     cache.Backend
+	ttl         time.Duration
+	negativeTTL time.Duration
+	requests    *prometheus.CounterVec
+	errors      *prometheus.CounterVec
+}
+
+type CacheOption func(*cachingRepository)
+
+// WithCacheTTL sets how long tasks, pages, counts and stats are cached.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *cachingRepository) {
+		c.ttl = ttl
+	}
+}
+
+// WithNegativeCacheTTL sets how long a missing task is remembered.
+func WithNegativeCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *cachingRepository) {
+		c.negativeTTL = ttl
+	}
+}
+
+// NewCachingRepository wraps repo with a read-through cache of GetTask,
+// GetTasks, ListTasks, CountTasks and TaskStats, including tasks that were
+// not found (sql.ErrNoRows). Every key carries a generation and writes
+// through the wrapper start a new one, dropping all cached tasks, pages,
+// counts and stats at once. A read that raced a write stores its stale
+// result under the old generation, where no later read looks. Writes made
+// elsewhere, e.g. by another replica with its own cache, show up once the
+// entries expire. Overdue filters depend on the current time and are never
+// cached.
+func NewCachingRepository(repo TodoRepository, backend cache.Backend, reg prometheus.Registerer, opts ...CacheOption) TodoRepository {
+	c := &cachingRepository{
+		next:        repo,
+		backend:     backend,
+		ttl:         DefaultCacheTTL,
+		negativeTTL: DefaultNegativeCacheTTL,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "todo_cache_requests_total",
+			Help: "Number of task cache lookups by method and result (hit or miss).",
+		}, []string{"method", "result"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "todo_cache_errors_total",
+			Help: "Number of failed task cache backend calls by operation.",
+		}, []string{"operation"}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	reg.MustRegister(c.requests, c.errors)
+	return c
+}
+
+// cachedTask is the stored result of a task lookup.
+type cachedTask struct {
+	Task     *todo.Task `json:"task,omitempty"`
+	NotFound bool       `json:"not_found,omitempty"`
+}
+
+func taskKey(generation string, id int) string {
+	return "todo:task:" + generation + ":" + strconv.Itoa(id)
+}
+
+// load decodes the entry under key into v and records a hit or miss.
+// Backend failures count as misses.
+func (c *cachingRepository) load(ctx context.Context, method, key string, v any) bool {
+	data, ok, err := c.backend.Get(ctx, key)
+	if err != nil {
+		c.fail(ctx, "get", err)
+	}
+	if ok && err == nil {
+		if err := json.Unmarshal(data, v); err == nil {
+			c.requests.WithLabelValues(method, "hit").Inc()
+			return true
+		}
+	}
+	c.requests.WithLabelValues(method, "miss").Inc()
+	return false
+}
+
+func (c *cachingRepository) store(ctx context.Context, key string, v any, ttl time.Duration) {
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = c.backend.Set(ctx, key, data, ttl)
+	}
+	if err != nil {
+		c.fail(ctx, "set", err)
+	}
+}
+
+func (c *cachingRepository) fail(ctx context.Context, operation string, err error) {
+	c.errors.WithLabelValues(operation).Inc()
+	logger.FromContext(ctx).WarnContext(ctx, "task cache "+operation, slog.String("error", err.Error()))
+}
+
+// invalidate starts a new generation, leaving the old entries to expire.
+// It runs after the write, so a read that sees the new generation also
+// sees the write.
+func (c *cachingRepository) invalidate(ctx context.Context) {
+	if err := c.backend.Set(ctx, generationKey, []byte(newGeneration()), 0); err != nil {
+		c.fail(ctx, "set", err)
+	}
+}
+
+// generation returns the current generation, or false when the backend
+// fails and nothing must be cached. Reads fetch it before the repository
+// so that a concurrent write moves their result out of sight.
+func (c *cachingRepository) generation(ctx context.Context) (string, bool) {
+	generation, ok, err := c.backend.Get(ctx, generationKey)
+	if err != nil {
+		c.fail(ctx, "get", err)
+		return "", false
+	}
+	if !ok {
+		generation = []byte(newGeneration())
+		if err := c.backend.Set(ctx, generationKey, generation, 0); err != nil {
+			c.fail(ctx, "set", err)
+			return "", false
+		}
+	}
+	return string(generation), true
+}
+
+// listKey returns the key of a list result, or false when the result must
+// not be cached.
+func (c *cachingRepository) listKey(ctx context.Context, kind string, filter todo.TaskFilter) (string, bool) {
+	if filter.Overdue != nil {
+		return "", false
+	}
+	generation, ok := c.generation(ctx)
+	if !ok {
+		return "", false
+	}
+
+	key := "todo:" + kind + ":" + generation
+	if filter.Completed != nil {
+		key += fmt.Sprintf(":completed=%t", *filter.Completed)
+	}
+	if filter.DueDate != nil {
+		key += ":date=" + filter.DueDate.Format(time.DateOnly)
+	}
+	if filter.Location != nil {
+		key += ":tz=" + filter.Location.String()
+	}
+	return key, true
+}
+
+func newGeneration() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (c *cachingRepository) CreateTask(ctx context.Context, task *todo.Task) error {
+	err := c.next.CreateTask(ctx, task)
+	// The new ID may have been looked up, and cached as missing, before.
+	c.invalidate(ctx)
+	return err
+}
+
+func (c *cachingRepository) GetTask(ctx context.Context, id int) (*todo.Task, error) {
+	generation, ok := c.generation(ctx)
+	if !ok {
+		return c.next.GetTask(ctx, id)
+	}
+	key := taskKey(generation, id)
+	var entry cachedTask
+	if c.load(ctx, "get_task", key, &entry) {
+		if entry.NotFound {
+			return nil, sql.ErrNoRows
+		}
+		return entry.Task, nil
+	}
+
+	task, err := c.next.GetTask(ctx, id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.store(ctx, key, cachedTask{NotFound: true}, c.negativeTTL)
+	case err == nil:
+		c.store(ctx, key, cachedTask{Task: task}, c.ttl)
+	}
+	return task, err
+}
+
+// GetTasks returns the found tasks once each, in the order of ids, whether
+// they came from the cache or the repository.
+func (c *cachingRepository) GetTasks(ctx context.Context, ids []int) ([]*todo.Task, error) {
+	generation, ok := c.generation(ctx)
+	if !ok {
+		return c.next.GetTasks(ctx, ids)
+	}
+	found := make(map[int]*todo.Task, len(ids))
+	var missing []int
+	for _, id := range ids {
+		var entry cachedTask
+		switch {
+		case !c.load(ctx, "get_tasks", taskKey(generation, id), &entry):
+			missing = append(missing, id)
+		case !entry.NotFound:
+			found[id] = entry.Task
+		}
+	}
+
+	if len(missing) > 0 {
+		fetched, err := c.next.GetTasks(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range fetched {
+			found[task.ID] = task
+			c.store(ctx, taskKey(generation, task.ID), cachedTask{Task: task}, c.ttl)
+		}
+		for _, id := range missing {
+			if found[id] == nil {
+				c.store(ctx, taskKey(generation, id), cachedTask{NotFound: true}, c.negativeTTL)
+			}
+		}
+	}
+
+	tasks := make([]*todo.Task, 0, len(found))
+	for _, id := range ids {
+		if task := found[id]; task != nil {
+			tasks = append(tasks, task)
+			delete(found, id)
+		}
+	}
+	return tasks, nil
+}
+
+func (c *cachingRepository) UpdateTask(ctx context.Context, task *todo.Task) error {
+	err := c.next.UpdateTask(ctx, task)
+	c.invalidate(ctx)
+	return err
+}
+
+func (c *cachingRepository) DeleteTask(ctx context.Context, id int) error {
+	err := c.next.DeleteTask(ctx, id)
+	c.invalidate(ctx)
+	return err
+}
+
+func (c *cachingRepository) ListTasks(ctx context.Context, filter todo.TaskFilter, limit, offset int) ([]*todo.Task, error) {
+	key, ok := c.listKey(ctx, "list", filter)
+	if !ok {
+		return c.next.ListTasks(ctx, filter, limit, offset)
+	}
+	key += fmt.Sprintf(":limit=%d:offset=%d", limit, offset)
+	var tasks []*todo.Task
+	if c.load(ctx, "list_tasks", key, &tasks) {
+		return tasks, nil
+	}
+
+	tasks, err := c.next.ListTasks(ctx, filter, limit, offset)
+	if err == nil {
+		c.store(ctx, key, tasks, c.ttl)
+	}
+	return tasks, err
+}
+
+func (c *cachingRepository) CountTasks(ctx context.Context, filter todo.TaskFilter) (int, error) {
+	key, ok := c.listKey(ctx, "count", filter)
+	if !ok {
+		return c.next.CountTasks(ctx, filter)
+	}
+	var count int
+	if c.load(ctx, "count_tasks", key, &count) {
+		return count, nil
+	}
+
+	count, err := c.next.CountTasks(ctx, filter)
+	if err == nil {
+		c.store(ctx, key, count, c.ttl)
+	}
+	return count, err
+}
+
+func (c *cachingRepository) CountOverdueTasks(ctx context.Context, now time.Time) (int, error) {
+	return c.next.CountOverdueTasks(ctx, now)
+}
+
+func (c *cachingRepository) CreateTasks(ctx context.Context, tasks []*todo.Task) error {
+	err := c.next.CreateTasks(ctx, tasks)
+	c.invalidate(ctx)
+	return err
+}
+
+func (c *cachingRepository) SaveTasks(ctx context.Context, tasks []*todo.Task) error {
+	err := c.next.SaveTasks(ctx, tasks)
+	c.invalidate(ctx)
+	return err
+}
+
+func (c *cachingRepository) StreamTasks(ctx context.Context, filter todo.TaskFilter, fn func(*todo.Task) error) error {
+	return c.next.StreamTasks(ctx, filter, fn)
+}
+
+// TaskStats results are list results: writes through the wrapper start a
+// new generation. They also depend on the current day, which is part of
+// the key; the overdue count is up to one TTL old.
+func (c *cachingRepository) TaskStats(ctx context.Context, filter todo.TaskFilter, opts todo.StatsOptions) (*todo.Stats, error) {
+	key, ok := c.listKey(ctx, "stats", filter)
+	if !ok {
+		return c.next.TaskStats(ctx, filter, opts)
+	}
+	loc := filter.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	key += fmt.Sprintf(":today=%s:bucket=%s:from=%s:to=%s:upcoming=%d", filter.Now.In(loc).Format(time.DateOnly),
+		opts.Bucket, opts.From.Format(time.DateOnly), opts.To.Format(time.DateOnly), opts.UpcomingDays)
+	var stats todo.Stats
+	if c.load(ctx, "task_stats", key, &stats) {
+		return &stats, nil
+	}
+
+	result, err := c.next.TaskStats(ctx, filter, opts)
+	if err == nil {
+		c.store(ctx, key, result, c.ttl)
+	}
+	return result, err
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sberTestTask/internal/cache"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/tests/mocks/repositoryMock"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupCache() (*cachingRepository, *repositoryMock.MockTodoRepository) {
+	mockRepo := new(repositoryMock.MockTodoRepository)
+	repo := NewCachingRepository(mockRepo, cache.NewLRU(100), prometheus.NewRegistry())
+	return repo.(*cachingRepository), mockRepo
+}
+
+func TestCacheGetTask(t *testing.T) {
+	repo, mockRepo := setupCache()
+	ctx := context.Background()
+	due := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	mockRepo.On("GetTask", mock.Anything, 1).Return(&todo.Task{ID: 1, Title: "Cached", DueDate: &due}, nil).Once()
+
+	for i := 0; i < 2; i++ {
+		task, err := repo.GetTask(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "Cached", task.Title)
+		assert.True(t, due.Equal(*task.DueDate))
+	}
+	mockRepo.AssertExpectations(t)
+	assert.Equal(t, 1.0, testutil.ToFloat64(repo.requests.WithLabelValues("get_task", "hit")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(repo.requests.WithLabelValues("get_task", "miss")))
+}
+
+func TestCacheNegativeEntry(t *testing.T) {
+	repo, mockRepo := setupCache()
+	ctx := context.Background()
+	mockRepo.On("GetTask", mock.Anything, 7).Return((*todo.Task)(nil), sql.ErrNoRows).Once()
+
+	for i := 0; i < 2; i++ {
+		_, err := repo.GetTask(ctx, 7)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	}
+
+	// Creating the task with that ID drops the negative entry.
+	mockRepo.On("CreateTask", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*todo.Task).ID = 7
+	}).Return(nil)
+	mockRepo.On("GetTask", mock.Anything, 7).Return(&todo.Task{ID: 7, Title: "New"}, nil).Once()
+	assert.NoError(t, repo.CreateTask(ctx, &todo.Task{Title: "New"}))
+	task, err := repo.GetTask(ctx, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, "New", task.Title)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCacheListInvalidatedByUpdate(t *testing.T) {
+	repo, mockRepo := setupCache()
+	ctx := context.Background()
+	completed := false
+	filter := todo.TaskFilter{Completed: &completed}
+	mockRepo.On("ListTasks", mock.Anything, filter, 10, 0).Return([]*todo.Task{{ID: 1, Title: "Old"}}, nil).Once()
+	mockRepo.On("CountTasks", mock.Anything, filter).Return(1, nil).Once()
+
+	for i := 0; i < 2; i++ {
+		tasks, err := repo.ListTasks(ctx, filter, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "Old", tasks[0].Title)
+		count, err := repo.CountTasks(ctx, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+	}
+
+	// Another page is a different key.
+	mockRepo.On("ListTasks", mock.Anything, filter, 10, 10).Return([]*todo.Task{}, nil).Once()
+	_, err := repo.ListTasks(ctx, filter, 10, 10)
+	assert.NoError(t, err)
+
+	mockRepo.On("UpdateTask", mock.Anything, mock.Anything).Return(nil)
+	assert.NoError(t, repo.UpdateTask(ctx, &todo.Task{ID: 1, Title: "New"}))
+
+	mockRepo.On("ListTasks", mock.Anything, filter, 10, 0).Return([]*todo.Task{{ID: 1, Title: "New"}}, nil).Once()
+	tasks, err := repo.ListTasks(ctx, filter, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "New", tasks[0].Title)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCacheSkipsOverdueFilter(t *testing.T) {
+	repo, mockRepo := setupCache()
+	ctx := context.Background()
+	overdue := true
+	filter := todo.TaskFilter{Overdue: &overdue, Now: time.Now()}
+	mockRepo.On("CountTasks", mock.Anything, filter).Return(3, nil).Twice()
+
+	for i := 0; i < 2; i++ {
+		count, err := repo.CountTasks(ctx, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, count)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCacheGetTasks(t *testing.T) {
+	repo, mockRepo := setupCache()
+	ctx := context.Background()
+	mockRepo.On("GetTask", mock.Anything, 1).Return(&todo.Task{ID: 1, Title: "One"}, nil).Once()
+	_, err := repo.GetTask(ctx, 1)
+	assert.NoError(t, err)
+
+	// Only the IDs not cached yet reach the repository; 3 does not exist.
+	// The repository answers in its own order.
+	mockRepo.On("GetTasks", mock.Anything, []int{4, 3, 2}).Return([]*todo.Task{{ID: 2, Title: "Two"}, {ID: 4, Title: "Four"}}, nil).Once()
+	for i := 0; i < 2; i++ {
+		tasks, err := repo.GetTasks(ctx, []int{4, 1, 3, 2})
+		assert.NoError(t, err)
+		var titles []string
+		for _, task := range tasks {
+			titles = append(titles, task.Title)
+		}
+		assert.Equal(t, []string{"Four", "One", "Two"}, titles)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCacheStaleReadAfterWrite(t *testing.T) {
+	repo, mockRepo := setupCache()
+	ctx := context.Background()
+
+	// The update lands while the read is in flight, so the read returns the
+	// old row and caches it.
+	mockRepo.On("UpdateTask", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("GetTask", mock.Anything, 1).Run(func(mock.Arguments) {
+		assert.NoError(t, repo.UpdateTask(ctx, &todo.Task{ID: 1, Title: "New"}))
+	}).Return(&todo.Task{ID: 1, Title: "Old"}, nil).Once()
+	task, err := repo.GetTask(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Old", task.Title)
+
+	// The stale entry belongs to the previous generation.
+	mockRepo.On("GetTask", mock.Anything, 1).Return(&todo.Task{ID: 1, Title: "New"}, nil).Once()
+	task, err = repo.GetTask(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "New", task.Title)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCacheTaskStats(t *testing.T) {
+	repo, mockRepo := setupCache()
+	ctx := context.Background()
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	filter := todo.TaskFilter{Now: now, Location: time.UTC}
+	opts := todo.StatsOptions{Bucket: todo.BucketDay, From: now.AddDate(0, 0, -6), To: now, UpcomingDays: 7}
+	mockRepo.On("TaskStats", mock.Anything, filter, opts).Return(&todo.Stats{Total: 3}, nil).Once()
+
+	for i := 0; i < 2; i++ {
+		stats, err := repo.TaskStats(ctx, filter, opts)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, stats.Total)
+	}
+
+	// A write starts a new generation.
+	mockRepo.On("DeleteTask", mock.Anything, 1).Return(nil)
+	mockRepo.On("TaskStats", mock.Anything, filter, opts).Return(&todo.Stats{Total: 2}, nil).Once()
+	assert.NoError(t, repo.DeleteTask(ctx, 1))
+	stats, err := repo.TaskStats(ctx, filter, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Total)
+
+	// So does the next day.
+	tomorrow := todo.TaskFilter{Now: now.AddDate(0, 0, 1), Location: time.UTC}
+	mockRepo.On("TaskStats", mock.Anything, tomorrow, opts).Return(&todo.Stats{Total: 2}, nil).Once()
+	_, err = repo.TaskStats(ctx, tomorrow, opts)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	assert.Equal(t, 1.0, testutil.ToFloat64(repo.requests.WithLabelValues("task_stats", "hit")))
+}
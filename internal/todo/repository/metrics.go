@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"sberTestTask/internal/todo"
+	"time"
+)
+
+type metricsRepository struct {
+	next     TodoRepository
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// NewMetricsRepository wraps repo and records query latency and errors per method.
+func NewMetricsRepository(repo TodoRepository, reg prometheus.Registerer) TodoRepository {
+	m := &metricsRepository{
+		next: repo,
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "todo_repository_query_duration_seconds",
+			Help:    "TodoRepository call latency by method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "todo_repository_errors_total",
+			Help: "Number of failed TodoRepository calls by method.",
+		}, []string{"method"}),
+	}
+	reg.MustRegister(m.duration, m.errors)
+	return m
+}
+
+func (m *metricsRepository) observe(method string, start time.Time, err error) {
+	m.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(method).Inc()
+	}
+}
+
+func (m *metricsRepository) CreateTask(ctx context.Context, task *todo.Task) error {
+	start := time.Now()
+	err := m.next.CreateTask(ctx, task)
+	m.observe("create_task", start, err)
+	return err
+}
+
+func (m *metricsRepository) GetTask(ctx context.Context, id int) (*todo.Task, error) {
+	start := time.Now()
+	task, err := m.next.GetTask(ctx, id)
+	m.observe("get_task", start, err)
+	return task, err
+}
+
+func (m *metricsRepository) GetTasks(ctx context.Context, ids []int) ([]*todo.Task, error) {
+	start := time.Now()
+	tasks, err := m.next.GetTasks(ctx, ids)
+	m.observe("get_tasks", start, err)
+	return tasks, err
+}
+
+func (m *metricsRepository) UpdateTask(ctx context.Context, task *todo.Task) error {
+	start := time.Now()
+	err := m.next.UpdateTask(ctx, task)
+	m.observe("update_task", start, err)
+	return err
+}
+
+func (m *metricsRepository) DeleteTask(ctx context.Context, id int) error {
+	start := time.Now()
+	err := m.next.DeleteTask(ctx, id)
+	m.observe("delete_task", start, err)
+	return err
+}
+
+func (m *metricsRepository) ListTasks(ctx context.Context, filter todo.TaskFilter, limit, offset int) ([]*todo.Task, error) {
+	start := time.Now()
+	tasks, err := m.next.ListTasks(ctx, filter, limit, offset)
+	m.observe("list_tasks", start, err)
+	return tasks, err
+}
+
+func (m *metricsRepository) CountTasks(ctx context.Context, filter todo.TaskFilter) (int, error) {
+	start := time.Now()
+	count, err := m.next.CountTasks(ctx, filter)
+	m.observe("count_tasks", start, err)
+	return count, err
+}
+
+func (m *metricsRepository) CountOverdueTasks(ctx context.Context, now time.Time) (int, error) {
+	start := time.Now()
+	count, err := m.next.CountOverdueTasks(ctx, now)
+	m.observe("count_overdue_tasks", start, err)
+	return count, err
+}
+
+func (m *metricsRepository) CreateTasks(ctx context.Context, tasks []*todo.Task) error {
+	start := time.Now()
+	err := m.next.CreateTasks(ctx, tasks)
+	m.observe("create_tasks", start, err)
+	return err
+}
+
+func (m *metricsRepository) SaveTasks(ctx context.Context, tasks []*todo.Task) error {
+	start := time.Now()
+	err := m.next.SaveTasks(ctx, tasks)
+	m.observe("save_tasks", start, err)
+	return err
+}
+
+func (m *metricsRepository) StreamTasks(ctx context.Context, filter todo.TaskFilter, fn func(*todo.Task) error) error {
+	start := time.Now()
+	err := m.next.StreamTasks(ctx, filter, fn)
+	m.observe("stream_tasks", start, err)
+	return err
+}
+
+func (m *metricsRepository) TaskStats(ctx context.Context, filter todo.TaskFilter, opts todo.StatsOptions) (*todo.Stats, error) {
+	start := time.Now()
+	stats, err := m.next.TaskStats(ctx, filter, opts)
+	m.observe("task_stats", start, err)
+	return stats, err
+}
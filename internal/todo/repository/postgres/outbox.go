@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/lib/pq"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/events"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type Option func(*postgresRepository)
+
+// WithOutbox records every task change in the task_outbox table, in the same
+// transaction as the change itself.
+func WithOutbox() Option {
+	return func(r *postgresRepository) {
+		r.outbox = true
+	}
+}
+
+// mutate runs fn in a transaction when the outbox is enabled, so that a
+// change is committed together with its outbox row, and directly otherwise.
+func (r *postgresRepository) mutate(ctx context.Context, fn func(db querier) error) error {
+	if !r.outbox {
+		return fn(r.db)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// outboxPayload encodes task without its computed fields, which only hold
+// for the moment and the caller they were computed for.
+func outboxPayload(task *todo.Task) ([]byte, error) {
+	stored := *task
+	stored.ClearComputed()
+	return json.Marshal(&stored)
+}
+
+func (r *postgresRepository) record(ctx context.Context, db querier, typ events.Type, task *todo.Task) (err error) {
+	if !r.outbox {
+		return nil
+	}
+
+	payload, err := outboxPayload(task)
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO task_outbox (event, task) VALUES ($1, $2)"
+	ctx, q := startQuery(ctx, "RecordChange", query)
+	defer func() { q.end(1, err) }()
+
+	_, err = db.ExecContext(ctx, query, string(typ), payload)
+	return err
+}
+
+// recordAll copies one outbox row per task.
+func (r *postgresRepository) recordAll(ctx context.Context, tx *sql.Tx, typ events.Type, tasks []*todo.Task) (err error) {
+	if !r.outbox || len(tasks) == 0 {
+		return nil
+	}
+
+	payloads := make([][]byte, len(tasks))
+	for i, task := range tasks {
+		if payloads[i], err = outboxPayload(task); err != nil {
+			return err
+		}
+	}
+
+	query := pq.CopyIn("task_outbox", "event", "task")
+	ctx, q := startQuery(ctx, "RecordChanges", query)
+	defer func() { q.end(int64(len(tasks)), err) }()
+
+	return copyRows(ctx, tx, query, len(tasks), func(i int) []interface{} {
+		return []interface{}{string(typ), string(payloads[i])}
+	})
+}
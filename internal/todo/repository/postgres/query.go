@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"sberTestTask/internal/logger"
+	"sberTestTask/internal/tracing"
+	"strings"
+	"time"
+)
+
+// queryObserver traces and logs a single SQL statement.
+type queryObserver struct {
+	ctx    context.Context
+	span   trace.Span
+	method string
+	start  time.Time
+}
+
+// startQuery starts a client span for a single SQL statement.
+func startQuery(ctx context.Context, method, query string) (context.Context, *queryObserver) {
+	operation, _, _ := strings.Cut(query, " ")
+	ctx, span := tracing.Tracer().Start(ctx, "postgres."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBQueryText(query),
+			semconv.DBOperationName(operation),
+		),
+	)
+	return ctx, &queryObserver{ctx: ctx, span: span, method: method, start: time.Now()}
+}
+
+// end records either the number of rows returned or affected, or the error.
+func (q *queryObserver) end(rows int64, err error) {
+	duration := time.Since(q.start)
+	log := logger.FromContext(q.ctx)
+	if err != nil {
+		q.span.RecordError(err)
+		q.span.SetStatus(codes.Error, err.Error())
+		log.DebugContext(q.ctx, "query failed", slog.String("query", q.method), slog.Duration("duration", duration), slog.String("error", err.Error()))
+	} else {
+		q.span.SetAttributes(attribute.Int64("db.rows", rows))
+		log.DebugContext(q.ctx, "query", slog.String("query", q.method), slog.Duration("duration", duration), slog.Int64("rows", rows))
+	}
+	q.span.End()
+}
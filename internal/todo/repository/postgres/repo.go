@@ -3,67 +3,189 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"github.com/lib/pq"
 	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/events"
 	"sberTestTask/internal/todo/repository"
 	"strconv"
 	"time"
 )
 
 type postgresRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	outbox bool
 }
 
-func NewPostgresRepository(db *sql.DB) repository.TodoRepository {
-	return &postgresRepository{db: db}
+func NewPostgresRepository(db *sql.DB, opts ...Option) repository.TodoRepository {
+	r := &postgresRepository{db: db}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 func (r *postgresRepository) CreateTask(ctx context.Context, task *todo.Task) error {
-	query := `INSERT INTO tasks (title, description, due_date, completed) VALUES ($1, $2, $3, $4) RETURNING id`
-	err := r.db.QueryRowContext(ctx, query, task.Title, task.Description, task.DueDate, task.Completed).Scan(&task.ID)
-	return err
+	return r.mutate(ctx, func(db querier) (err error) {
+		query := `INSERT INTO tasks (title, description, due_date, completed, all_day) VALUES ($1, $2, $3, $4, $5) RETURNING id, updated_at`
+		ctx, q := startQuery(ctx, "CreateTask", query)
+		defer func() { q.end(1, err) }()
+
+		err = db.QueryRowContext(ctx, query, task.Title, task.Description, task.DueDate, task.Completed, task.AllDay).Scan(&task.ID, &task.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return r.record(ctx, db, events.TaskCreated, task)
+	})
 }
 
-func (r *postgresRepository) GetTask(ctx context.Context, id int) (*todo.Task, error) {
+func (r *postgresRepository) GetTask(ctx context.Context, id int) (_ *todo.Task, err error) {
+	query := "SELECT id, title, description, due_date, completed, all_day, updated_at FROM tasks WHERE id = $1"
+	ctx, q := startQuery(ctx, "GetTask", query)
+	defer func() { q.end(1, err) }()
+
 	task := &todo.Task{}
-	err := r.db.QueryRowContext(ctx, "SELECT id, title, description, due_date, completed FROM tasks WHERE id = $1", id).Scan(&task.ID, &task.Title, &task.Description, &task.DueDate, &task.Completed)
+	err = r.db.QueryRowContext(ctx, query, id).Scan(&task.ID, &task.Title, &task.Description, &task.DueDate, &task.Completed, &task.AllDay, &task.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return task, nil
 }
 
+func (r *postgresRepository) GetTasks(ctx context.Context, ids []int) (_ []*todo.Task, err error) {
+	var tasks []*todo.Task
+	query := "SELECT id, title, description, due_date, completed, all_day, updated_at FROM tasks WHERE id = ANY($1)"
+	ctx, q := startQuery(ctx, "GetTasks", query)
+	defer func() { q.end(int64(len(tasks)), err) }()
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		task := new(todo.Task)
+		if err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.DueDate, &task.Completed, &task.AllDay, &task.UpdatedAt); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
 func (r *postgresRepository) UpdateTask(ctx context.Context, task *todo.Task) error {
-	_, err := r.db.ExecContext(ctx, "UPDATE tasks SET title = $1, description = $2, due_date = $3, completed = $4 WHERE id = $5", task.Title, task.Description, task.DueDate, task.Completed, task.ID)
-	return err
+	return r.mutate(ctx, func(db querier) (err error) {
+		// The previous state tells an update from a completion.
+		query := `WITH old AS (SELECT completed FROM tasks WHERE id = $6 FOR UPDATE)
+UPDATE tasks SET title = $1, description = $2, due_date = $3, completed = $4, all_day = $5 FROM old WHERE tasks.id = $6 RETURNING old.completed, tasks.updated_at`
+		ctx, q := startQuery(ctx, "UpdateTask", query)
+		var affected int64
+		defer func() { q.end(affected, err) }()
+
+		var wasCompleted bool
+		err = db.QueryRowContext(ctx, query, task.Title, task.Description, task.DueDate, task.Completed, task.AllDay, task.ID).Scan(&wasCompleted, &task.UpdatedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		affected = 1
+
+		typ := events.TaskUpdated
+		if task.Completed && !wasCompleted {
+			typ = events.TaskCompleted
+		}
+		return r.record(ctx, db, typ, task)
+	})
 }
 
 func (r *postgresRepository) DeleteTask(ctx context.Context, id int) error {
-	_, err := r.db.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1", id)
-	return err
-}
+	return r.mutate(ctx, func(db querier) (err error) {
+		query := "DELETE FROM tasks WHERE id = $1 RETURNING id, title, description, due_date, completed, all_day, updated_at"
+		ctx, q := startQuery(ctx, "DeleteTask", query)
+		var affected int64
+		defer func() { q.end(affected, err) }()
 
-func (r *postgresRepository) ListTasks(ctx context.Context, completed *bool, dueDate *time.Time, limit, offset int) ([]*todo.Task, error) {
-	var tasks []*todo.Task
-	var rows *sql.Rows
-	var err error
+		task := &todo.Task{}
+		err = db.QueryRowContext(ctx, query, id).Scan(&task.ID, &task.Title, &task.Description, &task.DueDate, &task.Completed, &task.AllDay, &task.UpdatedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		affected = 1
+		return r.record(ctx, db, events.TaskDeleted, task)
+	})
+}
 
-	query := "SELECT id, title, description, due_date, completed FROM tasks WHERE 1=1"
+// taskFilter returns the WHERE clause shared by ListTasks, CountTasks and
+// StreamTasks together with its arguments.
+func taskFilter(filter todo.TaskFilter) (string, []interface{}) {
+	where := " WHERE 1=1"
 	args := []interface{}{}
+	loc := filter.Location
+	if loc == nil {
+		loc = time.UTC
+	}
 
-	if completed != nil {
-		query += " AND completed = $1"
-		args = append(args, *completed)
+	if filter.Completed != nil {
+		args = append(args, *filter.Completed)
+		where += " AND completed = $" + strconv.Itoa(len(args))
 	}
 
-	if dueDate != nil {
-		query += " AND DATE(due_date) = DATE($" + strconv.Itoa(len(args)+1) + ")"
-		args = append(args, *dueDate)
+	if filter.DueDate != nil {
+		// Timed tasks are due on the day if they fall between its
+		// midnights in loc; all-day tasks store the day itself.
+		y, m, d := filter.DueDate.Date()
+		start := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		args = append(args, start.Format(time.DateOnly), start, start.AddDate(0, 0, 1))
+		n := len(args)
+		where += " AND CASE WHEN all_day THEN " + dueDay + " = $" + strconv.Itoa(n-2) + "::date" +
+			" ELSE due_date >= $" + strconv.Itoa(n-1) + " AND due_date < $" + strconv.Itoa(n) + " END"
 	}
 
+	if filter.Overdue != nil {
+		var overdue string
+		overdue, args = overdueClause(filter.Now, loc, args)
+		if *filter.Overdue {
+			where += " AND " + overdue
+		} else {
+			where += " AND NOT " + overdue
+		}
+	}
+	return where, args
+}
+
+// dueDay is the calendar day of an all-day task.
+const dueDay = "(due_date AT TIME ZONE 'UTC')::date"
+
+// overdueClause matches open tasks due before now: timed tasks by instant,
+// all-day tasks once their day has passed in loc.
+func overdueClause(now time.Time, loc *time.Location, args []interface{}) (string, []interface{}) {
+	args = append(args, now.In(loc).Format(time.DateOnly), now)
+	n := len(args)
+	return "(completed = FALSE AND CASE WHEN all_day THEN " + dueDay + " < $" + strconv.Itoa(n-1) + "::date" +
+		" ELSE due_date < $" + strconv.Itoa(n) + " END)", args
+}
+
+func (r *postgresRepository) ListTasks(ctx context.Context, filter todo.TaskFilter, limit, offset int) (_ []*todo.Task, err error) {
+	var tasks []*todo.Task
+	var rows *sql.Rows
+
+	where, args := taskFilter(filter)
+	query := "SELECT id, title, description, due_date, completed, all_day, updated_at FROM tasks" + where
+
 	args = append(args, limit)
 	args = append(args, offset)
 
 	query += " ORDER BY due_date LIMIT $" + strconv.Itoa(len(args)-1) + " OFFSET $" + strconv.Itoa(len(args))
+
+	ctx, q := startQuery(ctx, "ListTasks", query)
+	defer func() { q.end(int64(len(tasks)), err) }()
+
 	rows, err = r.db.QueryContext(ctx, query, args...)
 
 	if err != nil {
@@ -73,7 +195,7 @@ func (r *postgresRepository) ListTasks(ctx context.Context, completed *bool, due
 
 	for rows.Next() {
 		task := new(todo.Task)
-		if err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.DueDate, &task.Completed); err != nil {
+		if err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.DueDate, &task.Completed, &task.AllDay, &task.UpdatedAt); err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, task)
@@ -81,27 +203,225 @@ func (r *postgresRepository) ListTasks(ctx context.Context, completed *bool, due
 
 	return tasks, nil
 }
-func (r *postgresRepository) CountTasks(ctx context.Context, completed *bool, dueDate *time.Time) (int, error) {
-	var err error
+func (r *postgresRepository) CountTasks(ctx context.Context, filter todo.TaskFilter) (_ int, err error) {
+	where, args := taskFilter(filter)
+	query := "SELECT COUNT(id) FROM tasks" + where
 
-	query := "SELECT COUNT(id) FROM tasks WHERE 1=1"
-	args := []interface{}{}
+	ctx, q := startQuery(ctx, "CountTasks", query)
+	defer func() { q.end(1, err) }()
 
-	if completed != nil {
-		query += " AND completed = $1"
-		args = append(args, *completed)
+	var count int
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, err
 	}
 
-	if dueDate != nil {
-		query += " AND DATE(due_date) = DATE($" + strconv.Itoa(len(args)+1) + ")"
-		args = append(args, *dueDate)
-	}
+	return count, nil
+}
+
+func (r *postgresRepository) CountOverdueTasks(ctx context.Context, now time.Time) (_ int, err error) {
+	overdue, args := overdueClause(now, time.UTC, nil)
+	query := "SELECT COUNT(id) FROM tasks WHERE " + overdue
+	ctx, q := startQuery(ctx, "CountOverdueTasks", query)
+	defer func() { q.end(1, err) }()
 
 	var count int
 	err = r.db.QueryRowContext(ctx, query, args...).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
-
 	return count, nil
 }
+
+// CreateTasks reserves IDs from the tasks sequence and loads the tasks with
+// COPY, recording them in the outbox in the same transaction.
+func (r *postgresRepository) CreateTasks(ctx context.Context, tasks []*todo.Task) (err error) {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := reserveIDs(ctx, tx, tasks); err != nil {
+		return err
+	}
+
+	query := pq.CopyIn("tasks", "id", "title", "description", "due_date", "completed", "all_day")
+	copyCtx, q := startQuery(ctx, "CreateTasks", query)
+	err = copyRows(copyCtx, tx, query, len(tasks), func(i int) []interface{} {
+		t := tasks[i]
+		return []interface{}{t.ID, t.Title, t.Description, t.DueDate, t.Completed, t.AllDay}
+	})
+	q.end(int64(len(tasks)), err)
+	if err != nil {
+		return err
+	}
+
+	if err := r.recordAll(ctx, tx, events.TaskCreated, tasks); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SaveTasks copies the tasks into a temporary table and upserts them from
+// there, then moves the ID sequence past the largest saved ID. Like
+// CreateTasks it records the changes in the outbox: a created event for
+// every new ID and an updated event for every replaced task.
+func (r *postgresRepository) SaveTasks(ctx context.Context, tasks []*todo.Task) (err error) {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "CREATE TEMP TABLE tasks_saved (LIKE tasks INCLUDING DEFAULTS) ON COMMIT DROP")
+	if err != nil {
+		return err
+	}
+
+	query := pq.CopyIn("tasks_saved", "id", "title", "description", "due_date", "completed", "all_day")
+	copyCtx, q := startQuery(ctx, "SaveTasks", query)
+	err = copyRows(copyCtx, tx, query, len(tasks), func(i int) []interface{} {
+		t := tasks[i]
+		return []interface{}{t.ID, t.Title, t.Description, t.DueDate, t.Completed, t.AllDay}
+	})
+	q.end(int64(len(tasks)), err)
+	if err != nil {
+		return err
+	}
+
+	created, updated, err := upsertSaved(ctx, tx, tasks)
+	if err != nil {
+		return err
+	}
+	if err := r.recordAll(ctx, tx, events.TaskCreated, created); err != nil {
+		return err
+	}
+	if err := r.recordAll(ctx, tx, events.TaskUpdated, updated); err != nil {
+		return err
+	}
+
+	// The sequence never moves backwards, so IDs handed out before the
+	// save are not handed out again.
+	_, err = tx.ExecContext(ctx, `SELECT setval(pg_get_serial_sequence('tasks', 'id'),
+    GREATEST((SELECT MAX(id) FROM tasks), (SELECT last_value FROM tasks_id_seq)))`)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// upsertSaved moves the tasks from tasks_saved into tasks and splits them
+// into inserted and replaced ones, setting their updated_at.
+func upsertSaved(ctx context.Context, tx *sql.Tx, tasks []*todo.Task) (created, updated []*todo.Task, err error) {
+	query := `INSERT INTO tasks (id, title, description, due_date, completed, all_day)
+SELECT id, title, description, due_date, completed, all_day FROM tasks_saved
+ON CONFLICT (id) DO UPDATE SET title = EXCLUDED.title, description = EXCLUDED.description,
+    due_date = EXCLUDED.due_date, completed = EXCLUDED.completed, all_day = EXCLUDED.all_day
+RETURNING id, updated_at, xmax = 0`
+	ctx, q := startQuery(ctx, "UpsertTasks", query)
+	defer func() { q.end(int64(len(created)+len(updated)), err) }()
+
+	byID := make(map[int]*todo.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id       int
+			at       time.Time
+			inserted bool
+		)
+		if err := rows.Scan(&id, &at, &inserted); err != nil {
+			return nil, nil, err
+		}
+		task, ok := byID[id]
+		if !ok {
+			continue
+		}
+		task.UpdatedAt = &at
+		if inserted {
+			created = append(created, task)
+		} else {
+			updated = append(updated, task)
+		}
+	}
+	return created, updated, rows.Err()
+}
+
+func reserveIDs(ctx context.Context, tx *sql.Tx, tasks []*todo.Task) (err error) {
+	query := "SELECT nextval(pg_get_serial_sequence('tasks', 'id')) FROM generate_series(1, $1)"
+	ctx, q := startQuery(ctx, "ReserveTaskIDs", query)
+	defer func() { q.end(int64(len(tasks)), err) }()
+
+	rows, err := tx.QueryContext(ctx, query, len(tasks))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for i := 0; rows.Next(); i++ {
+		if err := rows.Scan(&tasks[i].ID); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// copyRows streams n rows into a COPY FROM STDIN statement.
+func copyRows(ctx context.Context, tx *sql.Tx, query string, n int, row func(i int) []interface{}) error {
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i := 0; i < n; i++ {
+		if _, err := stmt.ExecContext(ctx, row(i)...); err != nil {
+			return err
+		}
+	}
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
+func (r *postgresRepository) StreamTasks(ctx context.Context, filter todo.TaskFilter, fn func(*todo.Task) error) (err error) {
+	var count int64
+	where, args := taskFilter(filter)
+	query := "SELECT id, title, description, due_date, completed, all_day, updated_at FROM tasks" + where + " ORDER BY due_date, id"
+	ctx, q := startQuery(ctx, "StreamTasks", query)
+	defer func() { q.end(count, err) }()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		task := new(todo.Task)
+		if err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.DueDate, &task.Completed, &task.AllDay, &task.UpdatedAt); err != nil {
+			return err
+		}
+		count++
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
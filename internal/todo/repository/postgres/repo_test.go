@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"sberTestTask/internal/todo"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConn records the statements run through database/sql and answers
+// queries with the rows returned by query. It stands in for Postgres where
+// only the statements a method sends matter.
+type fakeConn struct {
+	mu    sync.Mutex
+	execs []fakeExec
+	query func(query string) (columns []string, rows [][]driver.Value)
+}
+
+type fakeExec struct {
+	query string
+	args  []driver.Value
+}
+
+func (c *fakeConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c *fakeConn) Driver() driver.Driver                        { return nil }
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error)    { return &fakeStmt{c, query}, nil }
+func (c *fakeConn) Close() error                                 { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                    { return c, nil }
+func (c *fakeConn) Commit() error                                { return c.record("COMMIT", nil) }
+func (c *fakeConn) Rollback() error                              { return nil }
+
+func (c *fakeConn) record(query string, args []driver.Value) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.execs = append(c.execs, fakeExec{query: query, args: args})
+	return nil
+}
+
+// statements returns the recorded statements starting with prefix.
+func (c *fakeConn) statements(prefix string) []fakeExec {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var execs []fakeExec
+	for _, e := range c.execs {
+		if strings.HasPrefix(e.query, prefix) {
+			execs = append(execs, e)
+		}
+	}
+	return execs
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.conn.record(s.query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.conn.record(s.query, args)
+	columns, rows := s.conn.query(s.query)
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSaveTasksRecordsChanges(t *testing.T) {
+	updatedAt := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	conn := &fakeConn{query: func(query string) ([]string, [][]driver.Value) {
+		if !strings.HasPrefix(query, "INSERT INTO tasks") {
+			return nil, nil
+		}
+		// Task 1 is new, task 2 replaced an existing one.
+		return []string{"id", "updated_at", "inserted"}, [][]driver.Value{
+			{int64(1), updatedAt, true},
+			{int64(2), updatedAt, false},
+		}
+	}}
+	db := sql.OpenDB(conn)
+	defer db.Close()
+
+	repo := NewPostgresRepository(db, WithOutbox())
+	tasks := []*todo.Task{{ID: 1, Title: "New"}, {ID: 2, Title: "Replaced"}}
+	require.NoError(t, repo.SaveTasks(context.Background(), tasks))
+
+	assert.Equal(t, &updatedAt, tasks[0].UpdatedAt)
+
+	// One COPY row per event plus the final flush of each COPY.
+	var outbox []string
+	for _, e := range conn.statements(`COPY "task_outbox"`) {
+		if len(e.args) == 0 {
+			continue
+		}
+		var task todo.Task
+		require.NoError(t, json.Unmarshal([]byte(e.args[1].(string)), &task))
+		outbox = append(outbox, e.args[0].(string)+":"+task.Title)
+	}
+	assert.Equal(t, []string{"created:New", "updated:Replaced"}, outbox)
+
+	setval := conn.statements("SELECT setval")
+	require.Len(t, setval, 1)
+	assert.Contains(t, setval[0].query, "GREATEST((SELECT MAX(id) FROM tasks), (SELECT last_value FROM tasks_id_seq))")
+	assert.Len(t, conn.statements("COMMIT"), 1)
+}
+
+func TestSaveTasksWithoutOutbox(t *testing.T) {
+	conn := &fakeConn{query: func(string) ([]string, [][]driver.Value) {
+		return []string{"id", "updated_at", "inserted"}, [][]driver.Value{{int64(1), time.Now(), true}}
+	}}
+	db := sql.OpenDB(conn)
+	defer db.Close()
+
+	require.NoError(t, NewPostgresRepository(db).SaveTasks(context.Background(), []*todo.Task{{ID: 1, Title: "New"}}))
+	assert.Empty(t, conn.statements(`COPY "task_outbox"`))
+}
+
+func TestOutboxOmitsComputedFields(t *testing.T) {
+	conn := &fakeConn{query: func(string) ([]string, [][]driver.Value) {
+		return []string{"completed", "updated_at"}, [][]driver.Value{{false, time.Now()}}
+	}}
+	db := sql.OpenDB(conn)
+	defer db.Close()
+
+	// A task read back for an update still carries its computed fields.
+	due := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	overdue, dueIn, daysOverdue := true, int64(-3600), 2
+	task := &todo.Task{ID: 1, Title: "Late", DueDate: &due, Overdue: &overdue, DueIn: &dueIn, DaysOverdue: &daysOverdue}
+	require.NoError(t, NewPostgresRepository(db, WithOutbox()).UpdateTask(context.Background(), task))
+
+	inserts := conn.statements("INSERT INTO task_outbox")
+	require.Len(t, inserts, 1)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(inserts[0].args[1].([]byte), &payload))
+	assert.Equal(t, "Late", payload["title"])
+	for _, field := range []string{"overdue", "due_in", "days_overdue"} {
+		assert.NotContains(t, payload, field)
+	}
+	assert.NotNil(t, task.Overdue, "the caller's task is left alone")
+}
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"sberTestTask/internal/todo"
+	"strconv"
+	"time"
+)
+
+// localDay is the due day of a task in the time zone named by parameter
+// n. All-day tasks store the day itself.
+func localDay(n int) string {
+	return "CASE WHEN all_day THEN " + dueDay + " ELSE (due_date AT TIME ZONE $" + strconv.Itoa(n) + ")::date END"
+}
+
+// TaskStats aggregates the tasks matching filter. The series only hold
+// buckets and days that have tasks.
+func (r *postgresRepository) TaskStats(ctx context.Context, filter todo.TaskFilter, opts todo.StatsOptions) (*todo.Stats, error) {
+	if filter.Location == nil {
+		filter.Location = time.UTC
+	}
+	stats := &todo.Stats{}
+	if err := r.statsSummary(ctx, filter, stats); err != nil {
+		return nil, err
+	}
+	if err := r.statsCompletion(ctx, filter, opts, stats); err != nil {
+		return nil, err
+	}
+	if err := r.statsUpcoming(ctx, filter, opts, stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (r *postgresRepository) statsSummary(ctx context.Context, filter todo.TaskFilter, stats *todo.Stats) (err error) {
+	where, args := taskFilter(filter)
+	overdue, args := overdueClause(filter.Now, filter.Location, args)
+	query := `SELECT COUNT(*), COUNT(*) FILTER (WHERE completed), COUNT(*) FILTER (WHERE ` + overdue + `),
+    AVG(EXTRACT(EPOCH FROM completed_at - created_at)) FILTER (WHERE completed AND completed_at IS NOT NULL)
+FROM tasks` + where
+	ctx, q := startQuery(ctx, "TaskStatsSummary", query)
+	defer func() { q.end(1, err) }()
+
+	var avg sql.NullFloat64
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&stats.Total, &stats.Completed, &stats.Overdue, &avg)
+	if err != nil {
+		return err
+	}
+	stats.Open = stats.Total - stats.Completed
+	if avg.Valid {
+		stats.AvgTimeToComplete = &avg.Float64
+	}
+	return nil
+}
+
+func (r *postgresRepository) statsCompletion(ctx context.Context, filter todo.TaskFilter, opts todo.StatsOptions, stats *todo.Stats) (err error) {
+	where, args := taskFilter(filter)
+	args = append(args, filter.Location.String(), opts.Bucket, opts.From.Format(time.DateOnly), opts.To.Format(time.DateOnly))
+	n := len(args)
+	query := `SELECT date_trunc($` + strconv.Itoa(n-2) + `::text, day::timestamp)::date AS bucket, COUNT(*), COUNT(*) FILTER (WHERE completed)
+FROM (SELECT ` + localDay(n-3) + ` AS day, completed FROM tasks` + where + `) t
+WHERE day BETWEEN $` + strconv.Itoa(n-1) + `::date AND $` + strconv.Itoa(n) + `::date
+GROUP BY bucket ORDER BY bucket`
+	ctx, q := startQuery(ctx, "TaskStatsCompletion", query)
+	defer func() { q.end(int64(len(stats.Completion)), err) }()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var start time.Time
+		var b todo.CompletionBucket
+		if err := rows.Scan(&start, &b.Due, &b.Completed); err != nil {
+			return err
+		}
+		b.Start = start.Format(time.DateOnly)
+		stats.Completion = append(stats.Completion, b)
+	}
+	return rows.Err()
+}
+
+func (r *postgresRepository) statsUpcoming(ctx context.Context, filter todo.TaskFilter, opts todo.StatsOptions, stats *todo.Stats) (err error) {
+	where, args := taskFilter(filter)
+	today := filter.Now.In(filter.Location)
+	args = append(args, filter.Location.String(), today.Format(time.DateOnly), today.AddDate(0, 0, opts.UpcomingDays-1).Format(time.DateOnly))
+	n := len(args)
+	query := `SELECT day, COUNT(*)
+FROM (SELECT ` + localDay(n-2) + ` AS day FROM tasks` + where + ` AND completed = FALSE) t
+WHERE day BETWEEN $` + strconv.Itoa(n-1) + `::date AND $` + strconv.Itoa(n) + `::date
+GROUP BY day ORDER BY day`
+	ctx, q := startQuery(ctx, "TaskStatsUpcoming", query)
+	defer func() { q.end(int64(len(stats.Upcoming)), err) }()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var day time.Time
+		var c todo.DayCount
+		if err := rows.Scan(&day, &c.Count); err != nil {
+			return err
+		}
+		c.Date = day.Format(time.DateOnly)
+		stats.Upcoming = append(stats.Upcoming, c)
+	}
+	return rows.Err()
+}
@@ -10,8 +10,23 @@ import (
 type TodoRepository interface {
 	CreateTask(ctx context.Context, task *todo.Task) error
 	GetTask(ctx context.Context, id int) (*todo.Task, error)
+	GetTasks(ctx context.Context, ids []int) ([]*todo.Task, error)
 	UpdateTask(ctx context.Context, task *todo.Task) error
 	DeleteTask(ctx context.Context, id int) error
-	ListTasks(ctx context.Context, completed *bool, dueDate *time.Time, limit, offset int) ([]*todo.Task, error)
-	CountTasks(ctx context.Context, completed *bool, dueDate *time.Time) (int, error)
+	ListTasks(ctx context.Context, filter todo.TaskFilter, limit, offset int) ([]*todo.Task, error)
+	CountTasks(ctx context.Context, filter todo.TaskFilter) (int, error)
+	CountOverdueTasks(ctx context.Context, now time.Time) (int, error)
+	// CreateTasks inserts all tasks at once and sets their IDs.
+	CreateTasks(ctx context.Context, tasks []*todo.Task) error
+	// SaveTasks inserts tasks with their IDs, replacing existing tasks with
+	// the same ID. IDs handed out later do not collide with saved ones.
+	// Inserted tasks are reported as created and replaced ones as updated,
+	// as CreateTasks reports its tasks as created.
+	SaveTasks(ctx context.Context, tasks []*todo.Task) error
+	// StreamTasks calls fn for every matching task without loading them all
+	// into memory. An error returned by fn stops the iteration.
+	StreamTasks(ctx context.Context, filter todo.TaskFilter, fn func(*todo.Task) error) error
+	// TaskStats aggregates the tasks matching filter. Filter.Now and
+	// Filter.Location must be set.
+	TaskStats(ctx context.Context, filter todo.TaskFilter, opts todo.StatsOptions) (*todo.Stats, error)
 }
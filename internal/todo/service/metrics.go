@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"sberTestTask/internal/todo"
+)
+
+type metricsUsecase struct {
+	next       TodoUsecase
+	operations *prometheus.CounterVec
+	errors     *prometheus.CounterVec
+}
+
+// NewMetricsUsecase wraps uc and counts operations and domain errors.
+func NewMetricsUsecase(uc TodoUsecase, reg prometheus.Registerer) TodoUsecase {
+	m := &metricsUsecase{
+		next: uc,
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "todo_usecase_operations_total",
+			Help: "Number of TodoUsecase calls by operation.",
+		}, []string{"operation"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "todo_usecase_errors_total",
+			Help: "Number of failed TodoUsecase calls by operation and domain error.",
+		}, []string{"operation", "error"}),
+	}
+	reg.MustRegister(m.operations, m.errors)
+	return m
+}
+
+func (m *metricsUsecase) observe(operation string, err error) {
+	m.operations.WithLabelValues(operation).Inc()
+	if err != nil {
+		m.errors.WithLabelValues(operation, errorType(err)).Inc()
+	}
+}
+
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, ErrIdNotFound):
+		return "id_not_found"
+	case errors.Is(err, ErrInvalidData):
+		return "invalid_data"
+	case errors.Is(err, ErrOnServer):
+		return "server"
+	default:
+		return "unknown"
+	}
+}
+
+func (m *metricsUsecase) CreateTask(ctx context.Context, task *todo.Task) error {
+	err := m.next.CreateTask(ctx, task)
+	m.observe("create_task", err)
+	return err
+}
+
+func (m *metricsUsecase) GetTask(ctx context.Context, id int) (*todo.Task, error) {
+	task, err := m.next.GetTask(ctx, id)
+	m.observe("get_task", err)
+	return task, err
+}
+
+func (m *metricsUsecase) GetTasks(ctx context.Context, ids []int) ([]*todo.Task, error) {
+	tasks, err := m.next.GetTasks(ctx, ids)
+	m.observe("get_tasks", err)
+	return tasks, err
+}
+
+func (m *metricsUsecase) UpdateTask(ctx context.Context, task *todo.Task) error {
+	err := m.next.UpdateTask(ctx, task)
+	m.observe("update_task", err)
+	return err
+}
+
+func (m *metricsUsecase) DeleteTask(ctx context.Context, id int) error {
+	err := m.next.DeleteTask(ctx, id)
+	m.observe("delete_task", err)
+	return err
+}
+
+func (m *metricsUsecase) ListTasks(ctx context.Context, filter todo.TaskFilter, limit, page int) (*todo.Pages, error) {
+	pages, err := m.next.ListTasks(ctx, filter, limit, page)
+	m.observe("list_tasks", err)
+	return pages, err
+}
+
+func (m *metricsUsecase) CountTasks(ctx context.Context, filter todo.TaskFilter) (int, error) {
+	count, err := m.next.CountTasks(ctx, filter)
+	m.observe("count_tasks", err)
+	return count, err
+}
+
+func (m *metricsUsecase) ExportTasks(ctx context.Context, filter todo.TaskFilter, fn func(*todo.Task) error) error {
+	err := m.next.ExportTasks(ctx, filter, fn)
+	m.observe("export_tasks", err)
+	return err
+}
+
+func (m *metricsUsecase) ImportTasks(ctx context.Context, tasks []*todo.Task) error {
+	err := m.next.ImportTasks(ctx, tasks)
+	m.observe("import_tasks", err)
+	return err
+}
+
+func (m *metricsUsecase) Stats(ctx context.Context, filter todo.TaskFilter, opts todo.StatsOptions) (*todo.Stats, error) {
+	stats, err := m.next.Stats(ctx, filter, opts)
+	m.observe("stats", err)
+	return stats, err
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/tests/mocks/serviceMock"
+)
+
+func TestMetricsUsecase(t *testing.T) {
+	mockUsecase := new(serviceMock.MockTodoUsecase)
+	reg := prometheus.NewRegistry()
+	uc := NewMetricsUsecase(mockUsecase, reg).(*metricsUsecase)
+
+	mockUsecase.On("GetTask", mock.Anything, 1).Return(&todo.Task{ID: 1}, nil)
+	mockUsecase.On("GetTask", mock.Anything, 2).Return((*todo.Task)(nil), ErrIdNotFound)
+
+	_, err := uc.GetTask(context.Background(), 1)
+	assert.NoError(t, err)
+	_, err = uc.GetTask(context.Background(), 2)
+	assert.Equal(t, ErrIdNotFound, err)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(uc.operations.WithLabelValues("get_task")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(uc.errors.WithLabelValues("get_task", "id_not_found")))
+	mockUsecase.AssertExpectations(t)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"sberTestTask/internal/logger"
 	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/events"
 	"sberTestTask/internal/todo/repository"
 	"time"
 )
@@ -18,60 +20,170 @@ var (
 type TodoUsecase interface {
 	CreateTask(ctx context.Context, task *todo.Task) error
 	GetTask(ctx context.Context, id int) (*todo.Task, error)
+	GetTasks(ctx context.Context, ids []int) ([]*todo.Task, error)
 	UpdateTask(ctx context.Context, task *todo.Task) error
 	DeleteTask(ctx context.Context, id int) error
-	ListTasks(ctx context.Context, completed *bool, dueDate *time.Time, limit, page int) (*todo.Pages, error)
-	CountTasks(ctx context.Context, completed *bool, dueDate *time.Time) (int, error)
+	ListTasks(ctx context.Context, filter todo.TaskFilter, limit, page int) (*todo.Pages, error)
+	CountTasks(ctx context.Context, filter todo.TaskFilter) (int, error)
+	// ExportTasks calls fn for every task matching the ListTasks filters.
+	ExportTasks(ctx context.Context, filter todo.TaskFilter, fn func(*todo.Task) error) error
+	// ImportTasks creates all tasks in one batch.
+	ImportTasks(ctx context.Context, tasks []*todo.Task) error
+	// Stats aggregates the tasks matching the ListTasks filters.
+	Stats(ctx context.Context, filter todo.TaskFilter, opts todo.StatsOptions) (*todo.Stats, error)
 }
 
+// Clock tells the service the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
 type todoService struct {
-	repo repository.TodoRepository
+	repo      repository.TodoRepository
+	publisher events.Publisher
+	clock     Clock
+	location  *time.Location
 }
 
-func NewTodoUsecase(repo repository.TodoRepository) TodoUsecase {
-	return &todoService{repo: repo}
+type Option func(*todoService)
+
+// WithPublisher makes the service publish an event after every successful mutation.
+func WithPublisher(p events.Publisher) Option {
+	return func(s *todoService) {
+		s.publisher = p
+	}
 }
 
-func (u *todoService) CreateTask(ctx context.Context, task *todo.Task) error {
+// WithClock replaces the system clock used for overdue filters and the
+// computed task fields.
+func WithClock(c Clock) Option {
+	return func(s *todoService) {
+		s.clock = c
+	}
+}
+
+// WithLocation sets the default time zone of date filters and the
+// computed fields, used when the context carries none (see
+// todo.WithLocation). UTC is used by default.
+func WithLocation(loc *time.Location) Option {
+	return func(s *todoService) {
+		s.location = loc
+	}
+}
 
+func NewTodoUsecase(repo repository.TodoRepository, opts ...Option) TodoUsecase {
+	s := &todoService{repo: repo, publisher: events.NopPublisher(), clock: systemClock{}, location: time.UTC}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// publish notifies subscribers about a change. Failing to publish does not
+// fail the mutation, which has already been committed.
+func (u *todoService) publish(ctx context.Context, typ events.Type, task *todo.Task) {
+	snapshot := *task
+	snapshot.ClearComputed()
+	if err := u.publisher.Publish(ctx, typ, &snapshot); err != nil {
+		logger.FromContext(ctx).ErrorContext(ctx, "publish task event", slog.Int("task_id", task.ID), slog.String("error", err.Error()))
+	}
+}
+
+// locationOf returns the caller's time zone, falling back to the
+// service default.
+func (u *todoService) locationOf(ctx context.Context) *time.Location {
+	if loc, ok := todo.LocationFromContext(ctx); ok {
+		return loc
+	}
+	return u.location
+}
+
+// compute fills the computed fields of tasks about to be returned.
+func (u *todoService) compute(ctx context.Context, tasks ...*todo.Task) {
+	now, loc := u.clock.Now(), u.locationOf(ctx)
+	for _, task := range tasks {
+		if task != nil {
+			task.SetComputed(now, loc)
+		}
+	}
+}
+
+// withNow sets the reference time of an overdue filter and the time zone
+// of the date and overdue filters.
+func (u *todoService) withNow(ctx context.Context, filter todo.TaskFilter) todo.TaskFilter {
+	if filter.Overdue != nil && filter.Now.IsZero() {
+		filter.Now = u.clock.Now()
+	}
+	if (filter.Overdue != nil || filter.DueDate != nil) && filter.Location == nil {
+		filter.Location = u.locationOf(ctx)
+	}
+	return filter
+}
+
+func (u *todoService) CreateTask(ctx context.Context, task *todo.Task) error {
+	task.Normalize()
+	task.ClearComputed()
 	if err := u.repo.CreateTask(ctx, task); err != nil {
-		slog.Error("create error: ", slog.String("error", err.Error()))
+		logError(ctx, "create task", err, ErrOnServer)
 		return ErrOnServer
 	}
+	u.publish(ctx, events.TaskCreated, task)
+	u.compute(ctx, task)
 	return nil
 }
 
 func (u *todoService) GetTask(ctx context.Context, id int) (*todo.Task, error) {
 	task, err := u.repo.GetTask(ctx, id)
 	if err != nil {
-		slog.Error("Task not found", slog.String("error", err.Error()))
+		logError(ctx, "task not found", err, ErrIdNotFound, slog.Int("task_id", id))
 		return nil, ErrIdNotFound
 	}
+	u.compute(ctx, task)
 	return task, nil
 }
 
+func (u *todoService) GetTasks(ctx context.Context, ids []int) ([]*todo.Task, error) {
+	tasks, err := u.repo.GetTasks(ctx, ids)
+	if err != nil {
+		logError(ctx, "get tasks", err, ErrOnServer)
+		return nil, ErrOnServer
+	}
+	u.compute(ctx, tasks...)
+	return tasks, nil
+}
+
 func (u *todoService) UpdateTask(ctx context.Context, task *todo.Task) error {
+	task.Normalize()
+	task.ClearComputed()
 	if err := u.repo.UpdateTask(ctx, task); err != nil {
-		slog.Error("update error: ", slog.String("error", err.Error()))
+		logError(ctx, "update task", err, ErrOnServer, slog.Int("task_id", task.ID))
 		return ErrOnServer
 	}
+	u.publish(ctx, events.TaskUpdated, task)
+	u.compute(ctx, task)
 	return nil
 }
 
 func (u *todoService) DeleteTask(ctx context.Context, id int) error {
 
 	if err := u.repo.DeleteTask(ctx, id); err != nil {
-		slog.Error("delete error: ", slog.String("error", err.Error()))
+		logError(ctx, "delete task", err, ErrOnServer, slog.Int("task_id", id))
 		return ErrOnServer
 	}
+	u.publish(ctx, events.TaskDeleted, &todo.Task{ID: id})
 	return nil
 }
 
-func (u *todoService) ListTasks(ctx context.Context, completed *bool, dueDate *time.Time, limit, page int) (*todo.Pages, error) {
-
-	totalCount, err := u.CountTasks(ctx, completed, dueDate)
+func (u *todoService) ListTasks(ctx context.Context, filter todo.TaskFilter, limit, page int) (*todo.Pages, error) {
+	// Count and list against the same instant.
+	filter = u.withNow(ctx, filter)
+	totalCount, err := u.CountTasks(ctx, filter)
 	if err != nil {
-		slog.Error("error counting tasks", slog.String("error", err.Error()))
+		logError(ctx, "count tasks", err, ErrOnServer)
 		return nil, ErrOnServer
 	}
 
@@ -88,11 +200,12 @@ func (u *todoService) ListTasks(ctx context.Context, completed *bool, dueDate *t
 		offset = (page - 1) * limit
 	}
 
-	tasks, err := u.repo.ListTasks(ctx, completed, dueDate, limit, offset)
+	tasks, err := u.repo.ListTasks(ctx, filter, limit, offset)
 	if err != nil {
-		slog.Error("error getting list ", slog.String("error", err.Error()))
+		logError(ctx, "list tasks", err, ErrOnServer)
 		return nil, ErrOnServer
 	}
+	u.compute(ctx, tasks...)
 	return &todo.Pages{
 		CountPage: countPage,
 		CurPage:   page,
@@ -101,6 +214,35 @@ func (u *todoService) ListTasks(ctx context.Context, completed *bool, dueDate *t
 
 }
 
-func (u *todoService) CountTasks(ctx context.Context, completed *bool, dueDate *time.Time) (int, error) {
-	return u.repo.CountTasks(ctx, completed, dueDate)
+func (u *todoService) CountTasks(ctx context.Context, filter todo.TaskFilter) (int, error) {
+	return u.repo.CountTasks(ctx, u.withNow(ctx, filter))
+}
+
+func (u *todoService) ExportTasks(ctx context.Context, filter todo.TaskFilter, fn func(*todo.Task) error) error {
+	if err := u.repo.StreamTasks(ctx, u.withNow(ctx, filter), fn); err != nil {
+		logError(ctx, "export tasks", err, ErrOnServer)
+		return ErrOnServer
+	}
+	return nil
+}
+
+func (u *todoService) ImportTasks(ctx context.Context, tasks []*todo.Task) error {
+	for _, task := range tasks {
+		task.Normalize()
+		task.ClearComputed()
+	}
+	if err := u.repo.CreateTasks(ctx, tasks); err != nil {
+		logError(ctx, "import tasks", err, ErrOnServer, slog.Int("tasks", len(tasks)))
+		return ErrOnServer
+	}
+	for _, task := range tasks {
+		u.publish(ctx, events.TaskCreated, task)
+	}
+	return nil
+}
+
+// logError logs the underlying cause of a domain error with the request-scoped logger.
+func logError(ctx context.Context, msg string, cause, domainErr error, attrs ...any) {
+	attrs = append(attrs, slog.String("error", cause.Error()), slog.String("error_class", errorType(domainErr)))
+	logger.FromContext(ctx).ErrorContext(ctx, msg, attrs...)
 }
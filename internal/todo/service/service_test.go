@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/events"
 	"sberTestTask/internal/todo/tests/mocks/repositoryMock"
 )
 
@@ -101,10 +102,10 @@ func TestListTasks(t *testing.T) {
 	t.Run("Successful ListTasks", func(t *testing.T) {
 		mockRepo.Calls = nil
 		mockRepo.ExpectedCalls = nil
-		mockRepo.On("CountTasks", mock.Anything, &completed, &date).Return(2, nil)
-		mockRepo.On("ListTasks", mock.Anything, &completed, &date, 10, 0).Return(tasks, nil)
+		mockRepo.On("CountTasks", mock.Anything, todo.TaskFilter{Completed: &completed, DueDate: &date, Location: time.UTC}).Return(2, nil)
+		mockRepo.On("ListTasks", mock.Anything, todo.TaskFilter{Completed: &completed, DueDate: &date, Location: time.UTC}, 10, 0).Return(tasks, nil)
 
-		result, err := svc.ListTasks(context.Background(), &completed, &date, 10, 1)
+		result, err := svc.ListTasks(context.Background(), todo.TaskFilter{Completed: &completed, DueDate: &date}, 10, 1)
 		expectedPages := &todo.Pages{
 			CountPage: 1,
 			CurPage:   1,
@@ -119,9 +120,9 @@ func TestListTasks(t *testing.T) {
 	t.Run("Error Counting Tasks", func(t *testing.T) {
 		mockRepo.Calls = nil
 		mockRepo.ExpectedCalls = nil
-		mockRepo.On("CountTasks", mock.Anything, &completed, &date).Return(0, errors.New("count error"))
+		mockRepo.On("CountTasks", mock.Anything, todo.TaskFilter{Completed: &completed, DueDate: &date, Location: time.UTC}).Return(0, errors.New("count error"))
 
-		result, err := svc.ListTasks(context.Background(), &completed, &date, 10, 1)
+		result, err := svc.ListTasks(context.Background(), todo.TaskFilter{Completed: &completed, DueDate: &date}, 10, 1)
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -132,10 +133,10 @@ func TestListTasks(t *testing.T) {
 	t.Run("Error Getting ListTasks", func(t *testing.T) {
 		mockRepo.Calls = nil
 		mockRepo.ExpectedCalls = nil
-		mockRepo.On("CountTasks", mock.Anything, &completed, &date).Return(2, nil)
-		mockRepo.On("ListTasks", mock.Anything, &completed, &date, 10, 0).Return(([]*todo.Task)(nil), errors.New("list error"))
+		mockRepo.On("CountTasks", mock.Anything, todo.TaskFilter{Completed: &completed, DueDate: &date, Location: time.UTC}).Return(2, nil)
+		mockRepo.On("ListTasks", mock.Anything, todo.TaskFilter{Completed: &completed, DueDate: &date, Location: time.UTC}, 10, 0).Return(([]*todo.Task)(nil), errors.New("list error"))
 
-		result, err := svc.ListTasks(context.Background(), &completed, &date, 10, 1)
+		result, err := svc.ListTasks(context.Background(), todo.TaskFilter{Completed: &completed, DueDate: &date}, 10, 1)
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -151,10 +152,215 @@ func TestCountTasks(t *testing.T) {
 	date := time.Now()
 	completed := true
 
-	mockRepo.On("CountTasks", mock.Anything, &completed, &date).Return(1, nil)
+	mockRepo.On("CountTasks", mock.Anything, todo.TaskFilter{Completed: &completed, DueDate: &date, Location: time.UTC}).Return(1, nil)
 
-	count, err := svc.CountTasks(context.Background(), &completed, &date)
+	count, err := svc.CountTasks(context.Background(), todo.TaskFilter{Completed: &completed, DueDate: &date})
 	assert.NoError(t, err)
 	assert.Equal(t, 1, count)
 	mockRepo.AssertExpectations(t)
 }
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+func TestComputedFields(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	// 2024-06-10 01:00 in Moscow.
+	now := time.Date(2024, 6, 9, 22, 0, 0, 0, time.UTC)
+
+	dueAt := func(s string) *time.Time {
+		d, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &d
+	}
+	tests := []struct {
+		name        string
+		task        todo.Task
+		overdue     bool
+		dueIn       *int64
+		daysOverdue int
+	}{
+		{"due later", todo.Task{DueDate: dueAt("2024-06-10T22:00:00Z")}, false, ptr(int64(24 * 60 * 60)), 0},
+		{"due earlier the same day", todo.Task{DueDate: dueAt("2024-06-09T21:30:00Z")}, true, ptr(int64(-30 * 60)), 0},
+		// 2024-06-09 23:00 in Moscow, the previous calendar day there.
+		{"due yesterday in the zone", todo.Task{DueDate: dueAt("2024-06-09T20:00:00Z")}, true, ptr(int64(-2 * 60 * 60)), 1},
+		{"due a week ago", todo.Task{DueDate: dueAt("2024-06-02T22:00:00Z")}, true, ptr(int64(-7 * 24 * 60 * 60)), 7},
+		{"completed", todo.Task{DueDate: dueAt("2024-06-02T22:00:00Z"), Completed: true}, false, nil, 0},
+		// All-day tasks are due at the end of their day in the zone.
+		{"all-day due today", todo.Task{DueDate: dueAt("2024-06-10T00:00:00Z"), AllDay: true}, false, ptr(int64(23 * 60 * 60)), 0},
+		{"all-day due yesterday in the zone", todo.Task{DueDate: dueAt("2024-06-09T00:00:00Z"), AllDay: true}, true, ptr(int64(-60 * 60)), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(repositoryMock.MockTodoRepository)
+			svc := NewTodoUsecase(mockRepo, WithClock(fixedClock(now)), WithLocation(moscow))
+			task := tt.task
+			mockRepo.On("GetTask", mock.Anything, 1).Return(&task, nil)
+
+			got, err := svc.GetTask(context.Background(), 1)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.overdue, *got.Overdue)
+			assert.Equal(t, tt.dueIn, got.DueIn)
+			assert.Equal(t, tt.daysOverdue, *got.DaysOverdue)
+		})
+	}
+}
+
+func TestListTasksOverdueUsesClock(t *testing.T) {
+	now := time.Date(2024, 6, 9, 12, 0, 0, 0, time.UTC)
+	mockRepo := new(repositoryMock.MockTodoRepository)
+	svc := NewTodoUsecase(mockRepo, WithClock(fixedClock(now)))
+
+	overdue := true
+	filter := todo.TaskFilter{Overdue: &overdue, Now: now, Location: time.UTC}
+	mockRepo.On("CountTasks", mock.Anything, filter).Return(1, nil)
+	mockRepo.On("ListTasks", mock.Anything, filter, 10, 0).Return([]*todo.Task{}, nil)
+
+	_, err := svc.ListTasks(context.Background(), todo.TaskFilter{Overdue: &overdue}, 10, 1)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListTasksUsesCallerLocation(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	mockRepo := new(repositoryMock.MockTodoRepository)
+	svc := NewTodoUsecase(mockRepo)
+
+	date := time.Date(2024, 6, 7, 0, 0, 0, 0, time.UTC)
+	filter := todo.TaskFilter{DueDate: &date, Location: moscow}
+	mockRepo.On("CountTasks", mock.Anything, filter).Return(1, nil)
+	mockRepo.On("ListTasks", mock.Anything, filter, 10, 0).Return([]*todo.Task{}, nil)
+
+	ctx := todo.WithLocation(context.Background(), moscow)
+	_, err := svc.ListTasks(ctx, todo.TaskFilter{DueDate: &date}, 10, 1)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateAllDayTask(t *testing.T) {
+	mockRepo := new(repositoryMock.MockTodoRepository)
+	svc := NewTodoUsecase(mockRepo)
+
+	// Late on June 7 in Moscow is still June 7 for an all-day task.
+	due := time.Date(2024, 6, 7, 23, 30, 0, 0, time.FixedZone("MSK", 3*60*60))
+	day := time.Date(2024, 6, 7, 0, 0, 0, 0, time.UTC)
+	mockRepo.On("CreateTask", mock.Anything, mock.MatchedBy(func(task *todo.Task) bool {
+		return task.DueDate.Equal(day) && task.DueDate.Location() == time.UTC
+	})).Return(nil)
+
+	err := svc.CreateTask(context.Background(), &todo.Task{Title: "Holiday", DueDate: &due, AllDay: true})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPublishedTaskHasNoComputedFields(t *testing.T) {
+	mockRepo := new(repositoryMock.MockTodoRepository)
+	publisher := &recordingPublisher{}
+	svc := NewTodoUsecase(mockRepo, WithPublisher(publisher))
+
+	overdue := true
+	due := time.Now().Add(-time.Hour)
+	task := &todo.Task{ID: 1, DueDate: &due, Overdue: &overdue}
+	mockRepo.On("UpdateTask", mock.Anything, task).Return(nil)
+
+	assert.NoError(t, svc.UpdateTask(context.Background(), task))
+	assert.Nil(t, publisher.tasks[0].Overdue)
+	assert.True(t, *task.Overdue)
+}
+
+func TestStoredTaskHasNoComputedFields(t *testing.T) {
+	mockRepo := new(repositoryMock.MockTodoRepository)
+	svc := NewTodoUsecase(mockRepo)
+
+	overdue, dueIn := true, int64(-3600)
+	due := time.Now().Add(-time.Hour)
+	task := &todo.Task{ID: 1, DueDate: &due, Overdue: &overdue, DueIn: &dueIn}
+	mockRepo.On("UpdateTask", mock.Anything, task).Run(func(args mock.Arguments) {
+		stored := args.Get(1).(*todo.Task)
+		assert.Nil(t, stored.Overdue)
+		assert.Nil(t, stored.DueIn)
+		assert.Nil(t, stored.DaysOverdue)
+	}).Return(nil)
+
+	assert.NoError(t, svc.UpdateTask(context.Background(), task))
+	mockRepo.AssertExpectations(t)
+}
+
+type recordingPublisher struct {
+	tasks []*todo.Task
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, _ events.Type, task *todo.Task) error {
+	p.tasks = append(p.tasks, task)
+	return nil
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestStats(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	// 2024-06-10 01:00 in Moscow, a Monday.
+	now := time.Date(2024, 6, 9, 22, 0, 0, 0, time.UTC)
+	mockRepo := new(repositoryMock.MockTodoRepository)
+	svc := NewTodoUsecase(mockRepo, WithClock(fixedClock(now)), WithLocation(moscow))
+
+	day := func(d int) time.Time { return time.Date(2024, 6, d, 0, 0, 0, 0, time.UTC) }
+	opts := todo.StatsOptions{Bucket: todo.BucketWeek, From: day(5), To: day(10), UpcomingDays: 3}
+	completed := false
+	mockRepo.On("TaskStats", mock.Anything,
+		todo.TaskFilter{Completed: &completed, Now: now, Location: moscow},
+		todo.StatsOptions{Bucket: todo.BucketWeek, From: day(3), To: day(10), UpcomingDays: 3},
+	).Return(&todo.Stats{
+		Total: 4, Open: 4,
+		Completion: []todo.CompletionBucket{{Start: "2024-06-10", Due: 4, Completed: 1}},
+		Upcoming:   []todo.DayCount{{Date: "2024-06-11", Count: 2}},
+	}, nil).Once()
+
+	stats, err := svc.Stats(context.Background(), todo.TaskFilter{Completed: &completed}, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []todo.CompletionBucket{
+		{Start: "2024-06-03"},
+		{Start: "2024-06-10", Due: 4, Completed: 1, Rate: 0.25},
+	}, stats.Completion)
+	assert.Equal(t, []todo.DayCount{
+		{Date: "2024-06-10"}, {Date: "2024-06-11", Count: 2}, {Date: "2024-06-12"},
+	}, stats.Upcoming)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestStatsRange(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	day := func(y, m, d int) time.Time { return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name string
+		opts todo.StatsOptions
+		err  string
+	}{
+		{"From without to", todo.StatsOptions{From: day(1, 1, 2)}, "invalid data: more than 366 buckets between from and to"},
+		{"From after today", todo.StatsOptions{From: day(2024, 6, 11)}, "invalid data: from is after to"},
+		{"Too many weeks", todo.StatsOptions{Bucket: todo.BucketWeek, From: day(2010, 1, 4), To: day(2024, 1, 1)}, "invalid data: more than 366 buckets between from and to"},
+		{"Longest series", todo.StatsOptions{From: day(2023, 6, 11), To: day(2024, 6, 10)}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(repositoryMock.MockTodoRepository)
+			mockRepo.On("TaskStats", mock.Anything, mock.Anything, mock.Anything).Return(&todo.Stats{}, nil).Maybe()
+			svc := NewTodoUsecase(mockRepo, WithClock(fixedClock(now)), WithLocation(time.UTC))
+
+			stats, err := svc.Stats(context.Background(), todo.TaskFilter{}, tt.opts)
+			if tt.err != "" {
+				assert.ErrorIs(t, err, ErrInvalidData)
+				assert.EqualError(t, err, tt.err)
+				mockRepo.AssertNotCalled(t, "TaskStats", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, stats.Completion, MaxStatsBuckets)
+		})
+	}
+}
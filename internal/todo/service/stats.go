@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sberTestTask/internal/todo"
+	"time"
+)
+
+const (
+	defaultStatsDays    = 30
+	defaultStatsWeeks   = 12
+	defaultUpcomingDays = 7
+
+	// MaxStatsBuckets bounds the completion series of one Stats call.
+	MaxStatsBuckets = 366
+)
+
+func (u *todoService) Stats(ctx context.Context, filter todo.TaskFilter, opts todo.StatsOptions) (*todo.Stats, error) {
+	now, loc := u.clock.Now(), u.locationOf(ctx)
+	y, m, d := now.In(loc).Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	opts = statsDefaults(opts, today)
+	if err := checkStatsRange(opts); err != nil {
+		return nil, err
+	}
+
+	filter.Now, filter.Location = now, loc
+	stats, err := u.repo.TaskStats(ctx, filter, opts)
+	if err != nil {
+		logError(ctx, "task stats", err, ErrOnServer)
+		return nil, ErrOnServer
+	}
+	fillStats(stats, opts, today)
+	return stats, nil
+}
+
+// statsDefaults fills in the last 30 days or 12 weeks up to today and a
+// week of upcoming days. Weekly series start on a Monday.
+func statsDefaults(opts todo.StatsOptions, today time.Time) todo.StatsOptions {
+	if opts.Bucket == "" {
+		opts.Bucket = todo.BucketDay
+	}
+	if opts.To.IsZero() {
+		opts.To = today
+	}
+	if opts.From.IsZero() {
+		if opts.Bucket == todo.BucketWeek {
+			opts.From = opts.To.AddDate(0, 0, -7*(defaultStatsWeeks-1))
+		} else {
+			opts.From = opts.To.AddDate(0, 0, -(defaultStatsDays - 1))
+		}
+	}
+	if opts.Bucket == todo.BucketWeek {
+		opts.From = opts.From.AddDate(0, 0, -(int(opts.From.Weekday())+6)%7)
+	}
+	if opts.UpcomingDays == 0 {
+		opts.UpcomingDays = defaultUpcomingDays
+	}
+	return opts
+}
+
+// checkStatsRange rejects series running backwards or longer than
+// MaxStatsBuckets, once the defaults have filled in a missing end.
+func checkStatsRange(opts todo.StatsOptions) error {
+	if opts.From.After(opts.To) {
+		return fmt.Errorf("%w: from is after to", ErrInvalidData)
+	}
+	step := 1
+	if opts.Bucket == todo.BucketWeek {
+		step = 7
+	}
+	// Sub saturates for ranges of centuries, which are still too long.
+	if buckets := int(opts.To.Sub(opts.From)/(24*time.Hour))/step + 1; buckets > MaxStatsBuckets {
+		return fmt.Errorf("%w: more than %d buckets between from and to", ErrInvalidData, MaxStatsBuckets)
+	}
+	return nil
+}
+
+// fillStats adds the empty buckets and days left out by the repository and
+// computes the completion rates.
+func fillStats(stats *todo.Stats, opts todo.StatsOptions, today time.Time) {
+	step := 1
+	if opts.Bucket == todo.BucketWeek {
+		step = 7
+	}
+	buckets := make(map[string]todo.CompletionBucket, len(stats.Completion))
+	for _, b := range stats.Completion {
+		buckets[b.Start] = b
+	}
+	completion := []todo.CompletionBucket{}
+	for day := opts.From; !day.After(opts.To); day = day.AddDate(0, 0, step) {
+		start := day.Format(time.DateOnly)
+		b := buckets[start]
+		b.Start = start
+		if b.Due > 0 {
+			b.Rate = float64(b.Completed) / float64(b.Due)
+		}
+		completion = append(completion, b)
+	}
+	stats.Completion = completion
+
+	counts := make(map[string]int, len(stats.Upcoming))
+	for _, c := range stats.Upcoming {
+		counts[c.Date] = c.Count
+	}
+	upcoming := make([]todo.DayCount, 0, opts.UpcomingDays)
+	for i := 0; i < opts.UpcomingDays; i++ {
+		date := today.AddDate(0, 0, i).Format(time.DateOnly)
+		upcoming = append(upcoming, todo.DayCount{Date: date, Count: counts[date]})
+	}
+	stats.Upcoming = upcoming
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/tracing"
+	"time"
+)
+
+type tracingUsecase struct {
+	next   TodoUsecase
+	tracer trace.Tracer
+}
+
+// NewTracingUsecase wraps uc and starts a span for every call.
+func NewTracingUsecase(uc TodoUsecase) TodoUsecase {
+	return &tracingUsecase{next: uc, tracer: tracing.Tracer()}
+}
+
+func (t *tracingUsecase) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, "TodoUsecase."+operation, trace.WithAttributes(attrs...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func filterAttrs(filter todo.TaskFilter) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if filter.Completed != nil {
+		attrs = append(attrs, attribute.Bool("todo.filter.completed", *filter.Completed))
+	}
+	if filter.DueDate != nil {
+		attrs = append(attrs, attribute.String("todo.filter.date", filter.DueDate.Format(time.DateOnly)))
+	}
+	if filter.Overdue != nil {
+		attrs = append(attrs, attribute.Bool("todo.filter.overdue", *filter.Overdue))
+	}
+	return attrs
+}
+
+func (t *tracingUsecase) CreateTask(ctx context.Context, task *todo.Task) error {
+	ctx, span := t.start(ctx, "CreateTask")
+	err := t.next.CreateTask(ctx, task)
+	span.SetAttributes(attribute.Int("todo.task_id", task.ID))
+	endSpan(span, err)
+	return err
+}
+
+func (t *tracingUsecase) GetTask(ctx context.Context, id int) (*todo.Task, error) {
+	ctx, span := t.start(ctx, "GetTask", attribute.Int("todo.task_id", id))
+	task, err := t.next.GetTask(ctx, id)
+	endSpan(span, err)
+	return task, err
+}
+
+func (t *tracingUsecase) GetTasks(ctx context.Context, ids []int) ([]*todo.Task, error) {
+	ctx, span := t.start(ctx, "GetTasks", attribute.IntSlice("todo.task_ids", ids))
+	tasks, err := t.next.GetTasks(ctx, ids)
+	endSpan(span, err)
+	return tasks, err
+}
+
+func (t *tracingUsecase) UpdateTask(ctx context.Context, task *todo.Task) error {
+	ctx, span := t.start(ctx, "UpdateTask", attribute.Int("todo.task_id", task.ID))
+	err := t.next.UpdateTask(ctx, task)
+	endSpan(span, err)
+	return err
+}
+
+func (t *tracingUsecase) DeleteTask(ctx context.Context, id int) error {
+	ctx, span := t.start(ctx, "DeleteTask", attribute.Int("todo.task_id", id))
+	err := t.next.DeleteTask(ctx, id)
+	endSpan(span, err)
+	return err
+}
+
+func (t *tracingUsecase) ListTasks(ctx context.Context, filter todo.TaskFilter, limit, page int) (*todo.Pages, error) {
+	attrs := append(filterAttrs(filter), attribute.Int("todo.limit", limit), attribute.Int("todo.page", page))
+	ctx, span := t.start(ctx, "ListTasks", attrs...)
+	pages, err := t.next.ListTasks(ctx, filter, limit, page)
+	if pages != nil {
+		span.SetAttributes(attribute.Int("todo.result_count", len(pages.Tasks)))
+	}
+	endSpan(span, err)
+	return pages, err
+}
+
+func (t *tracingUsecase) CountTasks(ctx context.Context, filter todo.TaskFilter) (int, error) {
+	ctx, span := t.start(ctx, "CountTasks", filterAttrs(filter)...)
+	count, err := t.next.CountTasks(ctx, filter)
+	endSpan(span, err)
+	return count, err
+}
+
+func (t *tracingUsecase) ExportTasks(ctx context.Context, filter todo.TaskFilter, fn func(*todo.Task) error) error {
+	ctx, span := t.start(ctx, "ExportTasks", filterAttrs(filter)...)
+	var count int
+	err := t.next.ExportTasks(ctx, filter, func(task *todo.Task) error {
+		count++
+		return fn(task)
+	})
+	span.SetAttributes(attribute.Int("todo.result_count", count))
+	endSpan(span, err)
+	return err
+}
+
+func (t *tracingUsecase) ImportTasks(ctx context.Context, tasks []*todo.Task) error {
+	ctx, span := t.start(ctx, "ImportTasks", attribute.Int("todo.task_count", len(tasks)))
+	err := t.next.ImportTasks(ctx, tasks)
+	endSpan(span, err)
+	return err
+}
+
+func (t *tracingUsecase) Stats(ctx context.Context, filter todo.TaskFilter, opts todo.StatsOptions) (*todo.Stats, error) {
+	attrs := append(filterAttrs(filter), attribute.String("todo.stats.bucket", opts.Bucket))
+	ctx, span := t.start(ctx, "Stats", attrs...)
+	stats, err := t.next.Stats(ctx, filter, opts)
+	endSpan(span, err)
+	return stats, err
+}
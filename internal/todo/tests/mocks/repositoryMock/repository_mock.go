@@ -21,6 +21,11 @@ func (m *MockTodoRepository) GetTask(ctx context.Context, id int) (*todo.Task, e
 	return args.Get(0).(*todo.Task), args.Error(1)
 }
 
+func (m *MockTodoRepository) GetTasks(ctx context.Context, ids []int) ([]*todo.Task, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]*todo.Task), args.Error(1)
+}
+
 func (m *MockTodoRepository) UpdateTask(ctx context.Context, task *todo.Task) error {
 	args := m.Called(ctx, task)
 	return args.Error(0)
@@ -31,12 +36,37 @@ func (m *MockTodoRepository) DeleteTask(ctx context.Context, id int) error {
 	return args.Error(0)
 }
 
-func (m *MockTodoRepository) ListTasks(ctx context.Context, completed *bool, dueDate *time.Time, limit, offset int) ([]*todo.Task, error) {
-	args := m.Called(ctx, completed, dueDate, limit, offset)
+func (m *MockTodoRepository) ListTasks(ctx context.Context, filter todo.TaskFilter, limit, offset int) ([]*todo.Task, error) {
+	args := m.Called(ctx, filter, limit, offset)
 	return args.Get(0).([]*todo.Task), args.Error(1)
 }
 
-func (m *MockTodoRepository) CountTasks(ctx context.Context, completed *bool, dueDate *time.Time) (int, error) {
-	args := m.Called(ctx, completed, dueDate)
+func (m *MockTodoRepository) CountTasks(ctx context.Context, filter todo.TaskFilter) (int, error) {
+	args := m.Called(ctx, filter)
 	return args.Int(0), args.Error(1)
 }
+
+func (m *MockTodoRepository) CountOverdueTasks(ctx context.Context, now time.Time) (int, error) {
+	args := m.Called(ctx, now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTodoRepository) CreateTasks(ctx context.Context, tasks []*todo.Task) error {
+	args := m.Called(ctx, tasks)
+	return args.Error(0)
+}
+
+func (m *MockTodoRepository) SaveTasks(ctx context.Context, tasks []*todo.Task) error {
+	args := m.Called(ctx, tasks)
+	return args.Error(0)
+}
+
+func (m *MockTodoRepository) StreamTasks(ctx context.Context, filter todo.TaskFilter, fn func(*todo.Task) error) error {
+	args := m.Called(ctx, filter, fn)
+	return args.Error(0)
+}
+
+func (m *MockTodoRepository) TaskStats(ctx context.Context, filter todo.TaskFilter, opts todo.StatsOptions) (*todo.Stats, error) {
+	args := m.Called(ctx, filter, opts)
+	return args.Get(0).(*todo.Stats), args.Error(1)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"github.com/stretchr/testify/mock"
 	"sberTestTask/internal/todo"
-	"time"
 )
 
 // MockTodoUsecase is a mock type for the TodoUsecase interface
@@ -37,6 +36,11 @@ func (m *MockTodoUsecase) GetTask(ctx context.Context, id int) (*todo.Task, erro
 	return args.Get(0).(*todo.Task), args.Error(1)
 }
 
+func (m *MockTodoUsecase) GetTasks(ctx context.Context, ids []int) ([]*todo.Task, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]*todo.Task), args.Error(1)
+}
+
 func (m *MockTodoUsecase) UpdateTask(ctx context.Context, task *todo.Task) error {
 	args := m.Called(ctx, task)
 	return args.Error(0)
@@ -47,12 +51,27 @@ func (m *MockTodoUsecase) DeleteTask(ctx context.Context, id int) error {
 	return args.Error(0)
 }
 
-func (m *MockTodoUsecase) ListTasks(ctx context.Context, completed *bool, dueDate *time.Time, limit, page int) (*todo.Pages, error) {
-	args := m.Called(ctx, completed, dueDate, limit, page)
+func (m *MockTodoUsecase) ListTasks(ctx context.Context, filter todo.TaskFilter, limit, page int) (*todo.Pages, error) {
+	args := m.Called(ctx, filter, limit, page)
 	return args.Get(0).(*todo.Pages), args.Error(1)
 }
 
-func (m *MockTodoUsecase) CountTasks(ctx context.Context, completed *bool, dueDate *time.Time) (int, error) {
-	args := m.Called(ctx, completed, dueDate)
+func (m *MockTodoUsecase) CountTasks(ctx context.Context, filter todo.TaskFilter) (int, error) {
+	args := m.Called(ctx, filter)
 	return args.Int(0), args.Error(1)
 }
+
+func (m *MockTodoUsecase) ExportTasks(ctx context.Context, filter todo.TaskFilter, fn func(*todo.Task) error) error {
+	args := m.Called(ctx, filter, fn)
+	return args.Error(0)
+}
+
+func (m *MockTodoUsecase) ImportTasks(ctx context.Context, tasks []*todo.Task) error {
+	args := m.Called(ctx, tasks)
+	return args.Error(0)
+}
+
+func (m *MockTodoUsecase) Stats(ctx context.Context, filter todo.TaskFilter, opts todo.StatsOptions) (*todo.Stats, error) {
+	args := m.Called(ctx, filter, opts)
+	return args.Get(0).(*todo.Stats), args.Error(1)
+}
@@ -0,0 +1,35 @@
+package todo
+
+import (
+	"sberTestTask/internal/validation"
+	"time"
+)
+
+const (
+	// TitleMaxLength matches the VARCHAR(255) tasks.title column.
+	TitleMaxLength = 255
+	// DescriptionMaxLength bounds the TEXT tasks.description column.
+	DescriptionMaxLength = 10000
+)
+
+// Due dates must fall from MinDueDate up to, but not including,
+// MaxDueDate.
+var (
+	MinDueDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	MaxDueDate = time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+var taskValidator = validation.New(
+	validation.Field("title", func(t *Task) string { return t.Title },
+		validation.NotBlank(), validation.MaxLength(TitleMaxLength)),
+	validation.Field("description", func(t *Task) string { return t.Description },
+		validation.MaxLength(DescriptionMaxLength)),
+	validation.Field("due_date", func(t *Task) *time.Time { return t.DueDate },
+		validation.Required[time.Time](), validation.TimeRange(MinDueDate, MaxDueDate)),
+)
+
+// Validate checks a task before it is stored, returning every violation
+// as validation.Errors. Field names are the JSON names.
+func (t *Task) Validate() error {
+	return taskValidator.Validate(t)
+}
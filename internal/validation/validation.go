@@ -0,0 +1,123 @@
+// Package validation declares per-field rules for a type and collects every
+// violation instead of stopping at the first one.
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError is one violated rule. Message does not repeat the field name,
+// so that callers can name the field as their input does.
+type FieldError struct {
+	Field   string `json:"field" example:"title"`
+	Message string `json:"message" example:"cannot be empty"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// Errors are all violations found, in the order the fields are declared.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Add records a violation of field.
+func (e *Errors) Add(field, message string) {
+	*e = append(*e, FieldError{Field: field, Message: message})
+}
+
+// Err returns e, or nil when there are no violations.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Rule checks a value and describes the violation, or returns "".
+type Rule[V any] func(V) string
+
+// FieldRules are the rules of one field of T.
+type FieldRules[T any] func(*T, *Errors)
+
+// Field declares the rules of the field name, read from T by get. Rules run
+// in order and only the first violation of a field is reported.
+func Field[T, V any](name string, get func(*T) V, rules ...Rule[V]) FieldRules[T] {
+	return func(t *T, errs *Errors) {
+		v := get(t)
+		for _, rule := range rules {
+			if msg := rule(v); msg != "" {
+				errs.Add(name, msg)
+				return
+			}
+		}
+	}
+}
+
+type Validator[T any] struct {
+	fields []FieldRules[T]
+}
+
+func New[T any](fields ...FieldRules[T]) *Validator[T] {
+	return &Validator[T]{fields: fields}
+}
+
+// Validate returns the violations in t as Errors, or nil.
+func (v *Validator[T]) Validate(t *T) error {
+	var errs Errors
+	for _, field := range v.fields {
+		field(t, &errs)
+	}
+	return errs.Err()
+}
+
+// NotBlank rejects strings that are empty or only white space.
+func NotBlank() Rule[string] {
+	return func(s string) string {
+		if strings.TrimSpace(s) == "" {
+			return "cannot be empty"
+		}
+		return ""
+	}
+}
+
+// MaxLength limits a string to n characters, as VARCHAR(n) does.
+func MaxLength(n int) Rule[string] {
+	return func(s string) string {
+		if utf8.RuneCountInString(s) > n {
+			return fmt.Sprintf("must be at most %d characters", n)
+		}
+		return ""
+	}
+}
+
+// Required rejects nil pointers.
+func Required[V any]() Rule[*V] {
+	return func(v *V) string {
+		if v == nil {
+			return "is required"
+		}
+		return ""
+	}
+}
+
+// TimeRange accepts times from min up to, but not including, max. Nil
+// passes; combine with Required.
+func TimeRange(min, max time.Time) Rule[*time.Time] {
+	return func(t *time.Time) string {
+		if t != nil && (t.Before(min) || !t.Before(max)) {
+			return fmt.Sprintf("must be from %s to before %s", min.Format(time.DateOnly), max.Format(time.DateOnly))
+		}
+		return ""
+	}
+}
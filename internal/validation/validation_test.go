@@ -0,0 +1,60 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type item struct {
+	Name string
+	At   *time.Time
+}
+
+var itemValidator = New(
+	Field("name", func(i *item) string { return i.Name }, NotBlank(), MaxLength(3)),
+	Field("at", func(i *item) *time.Time { return i.At }, Required[time.Time](), TimeRange(
+		time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC))),
+)
+
+func TestValidator(t *testing.T) {
+	ok := time.Date(2024, 6, 7, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		item item
+		want Errors
+	}{
+		{"valid", item{Name: "äöü", At: &ok}, nil},
+		{"all fields invalid", item{Name: " "}, Errors{
+			{Field: "name", Message: "cannot be empty"},
+			{Field: "at", Message: "is required"},
+		}},
+		{"first rule of a field only", item{Name: strings.Repeat("a", 4), At: &late}, Errors{
+			{Field: "name", Message: "must be at most 3 characters"},
+			{Field: "at", Message: "must be from 2000-01-01 to before 2100-01-01"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := itemValidator.Validate(&tt.item)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var errs Errors
+			assert.True(t, errors.As(err, &errs))
+			assert.Equal(t, tt.want, errs)
+		})
+	}
+}
+
+func TestErrorsError(t *testing.T) {
+	errs := Errors{{Field: "title", Message: "cannot be empty"}, {Field: "due_date", Message: "is required"}}
+	assert.Equal(t, "title cannot be empty; due_date is required", errs.Error())
+	assert.NoError(t, Errors(nil).Err())
+}
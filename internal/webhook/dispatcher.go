@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sberTestTask/internal/egress"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const batchSize = 50
+
+// RetryPolicy controls how failed deliveries are retried. The delay starts
+// at InitialBackoff and doubles after every failed attempt up to MaxBackoff.
+// A delivery that failed MaxAttempts times is moved to StatusDead.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func (p RetryPolicy) backoff(attempts int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempts && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxBackoff)
+}
+
+// queue is the part of the Store used by the Dispatcher.
+type queue interface {
+	fanOut(ctx context.Context, limit int) (int, error)
+	claim(ctx context.Context, limit int, lease time.Duration) ([]*attempt, error)
+	finish(ctx context.Context, a *attempt, res result) error
+}
+
+// attempt is a leased delivery together with its subscription's target.
+type attempt struct {
+	id       int64
+	event    string
+	payload  []byte
+	attempts int
+	url      string
+	secret   string
+}
+
+type result struct {
+	status     Status
+	next       time.Time
+	statusCode int
+	err        error
+}
+
+// Dispatcher turns outbox entries into deliveries and sends due deliveries.
+type Dispatcher struct {
+	queue        queue
+	client       *http.Client
+	policy       RetryPolicy
+	pollInterval time.Duration
+	now          func() time.Time
+}
+
+// NewDispatcher sends deliveries through a client that only connects to
+// public addresses and does not follow redirects, since subscription URLs
+// come from API callers.
+func NewDispatcher(store *Store, policy RetryPolicy, timeout, pollInterval time.Duration) *Dispatcher {
+	return &Dispatcher{
+		queue:        store,
+		client:       egress.PublicClient(timeout),
+		policy:       policy,
+		pollInterval: pollInterval,
+		now:          time.Now,
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.poll(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("webhook dispatch", slog.String("error", err.Error()))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll drains the outbox and sends all deliveries that are due.
+func (d *Dispatcher) poll(ctx context.Context) error {
+	for {
+		n, err := d.queue.fanOut(ctx, batchSize)
+		if err != nil {
+			return err
+		}
+		if n < batchSize {
+			break
+		}
+	}
+
+	for {
+		// The lease outlives the request timeout so that a slow receiver
+		// is not sent the same delivery twice.
+		attempts, err := d.queue.claim(ctx, batchSize, 2*d.client.Timeout)
+		if err != nil {
+			return err
+		}
+		d.sendAll(ctx, attempts)
+		if len(attempts) < batchSize {
+			return nil
+		}
+	}
+}
+
+func (d *Dispatcher) sendAll(ctx context.Context, attempts []*attempt) {
+	var wg sync.WaitGroup
+	for _, a := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := d.send(ctx, a)
+			// Record the outcome even if shutdown began during the request.
+			if err := d.queue.finish(context.WithoutCancel(ctx), a, res); err != nil {
+				slog.Error("record webhook delivery", slog.Int64("delivery_id", a.id), slog.String("error", err.Error()))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) send(ctx context.Context, a *attempt) result {
+	statusCode, err := d.post(ctx, a)
+	now := d.now()
+	if err == nil {
+		return result{status: StatusDelivered, next: now, statusCode: statusCode}
+	}
+
+	log := slog.With(slog.Int64("delivery_id", a.id), slog.String("url", a.url), slog.Int("attempt", a.attempts+1), slog.String("error", err.Error()))
+	if a.attempts+1 >= d.policy.MaxAttempts {
+		log.Error("webhook delivery dead")
+		return result{status: StatusDead, next: now, statusCode: statusCode, err: err}
+	}
+	retryIn := d.policy.backoff(a.attempts + 1)
+	log.Warn("webhook delivery failed", slog.Duration("retry_in", retryIn))
+	return result{status: StatusPending, next: now.Add(retryIn), statusCode: statusCode, err: err}
+}
+
+// post sends the payload and treats any 2xx response as success.
+func (d *Dispatcher) post(ctx context.Context, a *attempt) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(a.payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todo-webhooks/1.0")
+	req.Header.Set(EventHeader, a.event)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(a.id, 10))
+	req.Header.Set(SignatureHeader, Sign(a.secret, a.payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
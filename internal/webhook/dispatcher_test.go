@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeQueue keeps deliveries in memory and hands out those that are due.
+type fakeQueue struct {
+	mu       sync.Mutex
+	now      time.Time
+	pending  []*attempt
+	due      map[int64]time.Time
+	finished map[int64]result
+}
+
+func newFakeQueue(now time.Time, attempts ...*attempt) *fakeQueue {
+	q := &fakeQueue{now: now, pending: attempts, due: map[int64]time.Time{}, finished: map[int64]result{}}
+	for _, a := range attempts {
+		q.due[a.id] = now
+	}
+	return q
+}
+
+func (q *fakeQueue) fanOut(context.Context, int) (int, error) { return 0, nil }
+
+func (q *fakeQueue) claim(_ context.Context, limit int, lease time.Duration) ([]*attempt, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var claimed []*attempt
+	for _, a := range q.pending {
+		if len(claimed) == limit {
+			break
+		}
+		if !q.due[a.id].After(q.now) {
+			q.due[a.id] = q.now.Add(lease)
+			claimed = append(claimed, a)
+		}
+	}
+	return claimed, nil
+}
+
+func (q *fakeQueue) finish(_ context.Context, a *attempt, res result) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.finished[a.id] = res
+	a.attempts++
+	if res.status == StatusPending {
+		q.due[a.id] = res.next
+		return nil
+	}
+	for i, p := range q.pending {
+		if p == a {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (q *fakeQueue) advance(d time.Duration) {
+	q.mu.Lock()
+	q.now = q.now.Add(d)
+	q.mu.Unlock()
+}
+
+func newTestDispatcher(q *fakeQueue, policy RetryPolicy) *Dispatcher {
+	return &Dispatcher{
+		queue:  q,
+		client: &http.Client{Timeout: time.Second},
+		policy: policy,
+		now:    func() time.Time { return q.now },
+	}
+}
+
+func TestDispatcherSignsPayload(t *testing.T) {
+	payload := []byte(`{"event":"task.created","task":{"id":1,"title":"Test"}}`)
+	received := make(chan *http.Request, 1)
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+	}))
+	defer receiver.Close()
+
+	q := newFakeQueue(time.Now(), &attempt{id: 7, event: "task.created", payload: payload, url: receiver.URL, secret: "s3cret"})
+	d := newTestDispatcher(q, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Minute})
+
+	assert.NoError(t, d.poll(context.Background()))
+
+	r := <-received
+	assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	assert.Equal(t, "task.created", r.Header.Get(EventHeader))
+	assert.Equal(t, "7", r.Header.Get(DeliveryHeader))
+	assert.True(t, Verify("s3cret", body, r.Header.Get(SignatureHeader)))
+	assert.False(t, Verify("other", body, r.Header.Get(SignatureHeader)))
+	assert.Equal(t, payload, body)
+
+	res := q.finished[7]
+	assert.Equal(t, StatusDelivered, res.status)
+	assert.Equal(t, http.StatusOK, res.statusCode)
+}
+
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	var calls int
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	start := time.Now()
+	a := &attempt{id: 1, event: "task.updated", payload: []byte(`{}`), url: receiver.URL, secret: "s"}
+	q := newFakeQueue(start, a)
+	d := newTestDispatcher(q, RetryPolicy{MaxAttempts: 5, InitialBackoff: 10 * time.Second, MaxBackoff: time.Minute})
+	ctx := context.Background()
+
+	assert.NoError(t, d.poll(ctx))
+	res := q.finished[1]
+	assert.Equal(t, StatusPending, res.status)
+	assert.Equal(t, http.StatusServiceUnavailable, res.statusCode)
+	assert.Equal(t, start.Add(10*time.Second), res.next)
+
+	// Not due yet.
+	assert.NoError(t, d.poll(ctx))
+	assert.Equal(t, 1, calls)
+
+	q.advance(10 * time.Second)
+	assert.NoError(t, d.poll(ctx))
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, q.now.Add(20*time.Second), q.finished[1].next)
+
+	q.advance(20 * time.Second)
+	assert.NoError(t, d.poll(ctx))
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, StatusDelivered, q.finished[1].status)
+	assert.Equal(t, 3, a.attempts)
+}
+
+func TestDispatcherDeadLetter(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	q := newFakeQueue(time.Now(), &attempt{id: 1, payload: []byte(`{}`), url: receiver.URL, secret: "s"})
+	d := newTestDispatcher(q, RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Second, MaxBackoff: time.Second})
+	ctx := context.Background()
+
+	assert.NoError(t, d.poll(ctx))
+	assert.Equal(t, StatusPending, q.finished[1].status)
+
+	q.advance(time.Second)
+	assert.NoError(t, d.poll(ctx))
+	res := q.finished[1]
+	assert.Equal(t, StatusDead, res.status)
+	assert.EqualError(t, res.err, "unexpected status 500 Internal Server Error")
+	assert.Empty(t, q.pending)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+	assert.Equal(t, time.Second, p.backoff(1))
+	assert.Equal(t, 2*time.Second, p.backoff(2))
+	assert.Equal(t, 8*time.Second, p.backoff(4))
+	assert.Equal(t, 10*time.Second, p.backoff(5))
+	assert.Equal(t, 10*time.Second, p.backoff(50))
+}
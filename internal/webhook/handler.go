@@ -0,0 +1,272 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"sberTestTask/internal/egress"
+	"sberTestTask/internal/httpapi"
+	"sberTestTask/internal/logger"
+	"sberTestTask/internal/validation"
+	"slices"
+	"strconv"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+// subscriptionStore is the part of Store the handler uses.
+type subscriptionStore interface {
+	CreateSubscription(ctx context.Context, sub *Subscription) error
+	GetSubscription(ctx context.Context, id int) (*Subscription, error)
+	ListSubscriptions(ctx context.Context) ([]*Subscription, error)
+	DeleteSubscription(ctx context.Context, id int) error
+	ListDeliveries(ctx context.Context, subscriptionID int, status Status, limit int) ([]*Delivery, error)
+	RetryDelivery(ctx context.Context, subscriptionID int, deliveryID int64) error
+}
+
+type Handler struct {
+	store subscriptionStore
+	// allowedHosts limits subscription URLs when not empty.
+	allowedHosts []string
+}
+
+// NewHandler returns the /webhooks routes. Subscription URLs must name a
+// host matching allowedHosts, as in reminder.TargetPolicy, unless it is
+// empty; addresses inside the deployment are refused either way.
+func NewHandler(store *Store, allowedHosts []string) http.Handler {
+	return newHandler(store, allowedHosts)
+}
+
+func newHandler(store subscriptionStore, allowedHosts []string) http.Handler {
+	h := &Handler{store: store, allowedHosts: allowedHosts}
+	r := chi.NewRouter()
+	r.Post("/", h.CreateSubscription)
+	r.Get("/", h.ListSubscriptions)
+	r.Get("/{id}", h.GetSubscription)
+	r.Delete("/{id}", h.DeleteSubscription)
+	r.Get("/{id}/deliveries", h.ListDeliveries)
+	r.Post("/{id}/deliveries/{deliveryID}/retry", h.RetryDelivery)
+	return r
+}
+
+// @Summary Register a webhook
+// @Description Subscribe a URL to task events. Every delivery is a JSON POST signed with HMAC-SHA256 of the body using the secret, sent in the X-Webhook-Signature header as "sha256=<hex>".
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param subscription body webhook.Subscription true "URL, secret and events: task.created, task.updated, task.completed, task.deleted"
+// @Success 201 {object} webhook.Subscription "Subscription created"
+// @Failure 400 {object} httpapi.Problem "Malformed JSON"
+// @Failure 413 {object} httpapi.Problem "Request body too large"
+// @Failure 422 {object} httpapi.Problem "Invalid subscription, with the invalid fields in errors"
+// @Failure 500 {object} httpapi.Problem "Internal Server Error"
+// @Router /webhooks [post]
+func (h *Handler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var sub Subscription
+	errs, ok := httpapi.Decode(w, r, &sub, httpapi.DefaultMaxBodySize, "id", "created_at")
+	if !ok {
+		return
+	}
+	if err := h.validate(&sub, errs); err != nil {
+		httpapi.WriteInvalid(w, err)
+		return
+	}
+	if err := h.store.CreateSubscription(r.Context(), &sub); err != nil {
+		h.serverError(w, r, "create webhook", err)
+		return
+	}
+	logger.AddAttrs(r.Context(), slog.Int("webhook_id", sub.ID))
+
+	sub.Secret = ""
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub)
+}
+
+// @Summary List webhooks
+// @Tags webhooks
+// @Produce  json
+// @Success 200 {array} webhook.Subscription "Subscriptions"
+// @Failure 500 {object} httpapi.Problem "Internal Server Error"
+// @Router /webhooks [get]
+func (h *Handler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.store.ListSubscriptions(r.Context())
+	if err != nil {
+		h.serverError(w, r, "list webhooks", err)
+		return
+	}
+	json.NewEncoder(w).Encode(subs)
+}
+
+// @Summary Get a webhook
+// @Tags webhooks
+// @Produce  json
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} webhook.Subscription "Subscription"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 404 {object} httpapi.Problem "Not Found"
+// @Router /webhooks/{id} [get]
+func (h *Handler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	id, ok := subscriptionID(w, r)
+	if !ok {
+		return
+	}
+	sub, err := h.store.GetSubscription(r.Context(), id)
+	if err != nil {
+		h.storeError(w, r, "get webhook", err)
+		return
+	}
+	json.NewEncoder(w).Encode(sub)
+}
+
+// @Summary Delete a webhook
+// @Description Delete a subscription together with its delivery log
+// @Tags webhooks
+// @Param id path int true "Subscription ID"
+// @Success 204 "Subscription deleted"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 404 {object} httpapi.Problem "Not Found"
+// @Router /webhooks/{id} [delete]
+func (h *Handler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	id, ok := subscriptionID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.store.DeleteSubscription(r.Context(), id); err != nil {
+		h.storeError(w, r, "delete webhook", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Webhook delivery log
+// @Description Most recent deliveries of a subscription, newest first
+// @Tags webhooks
+// @Produce  json
+// @Param id path int true "Subscription ID"
+// @Param status query string false "pending, delivered or dead"
+// @Param limit query int false "Number of deliveries, 50 by default"
+// @Success 200 {array} webhook.Delivery "Deliveries"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 404 {object} httpapi.Problem "Not Found"
+// @Router /webhooks/{id}/deliveries [get]
+func (h *Handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := subscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	status := Status(r.URL.Query().Get("status"))
+	if status != "" && status != StatusPending && status != StatusDelivered && status != StatusDead {
+		httpapi.WriteProblem(w, http.StatusBadRequest, "invalid status", nil)
+		return
+	}
+	limit := defaultDeliveryLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxDeliveryLimit {
+			httpapi.WriteProblem(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxDeliveryLimit), nil)
+			return
+		}
+		limit = n
+	}
+
+	if _, err := h.store.GetSubscription(r.Context(), id); err != nil {
+		h.storeError(w, r, "get webhook", err)
+		return
+	}
+	deliveries, err := h.store.ListDeliveries(r.Context(), id, status, limit)
+	if err != nil {
+		h.serverError(w, r, "list webhook deliveries", err)
+		return
+	}
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// @Summary Retry a dead delivery
+// @Description Move a delivery in the dead state back to the queue
+// @Tags webhooks
+// @Param id path int true "Subscription ID"
+// @Param deliveryID path int true "Delivery ID"
+// @Success 202 "Delivery queued"
+// @Failure 400 {object} httpapi.Problem "Bad Request"
+// @Failure 404 {object} httpapi.Problem "Not Found"
+// @Router /webhooks/{id}/deliveries/{deliveryID}/retry [post]
+func (h *Handler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	id, ok := subscriptionID(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseInt(chi.URLParam(r, "deliveryID"), 10, 64)
+	if err != nil {
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if err := h.store.RetryDelivery(r.Context(), id, deliveryID); err != nil {
+		h.storeError(w, r, "retry webhook delivery", err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func subscriptionID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpapi.WriteProblem(w, http.StatusBadRequest, err.Error(), nil)
+		return 0, false
+	}
+	logger.AddAttrs(r.Context(), slog.Int("webhook_id", id))
+	return id, true
+}
+
+func (h *Handler) storeError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	if errors.Is(err, ErrNotFound) {
+		httpapi.WriteProblem(w, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+	h.serverError(w, r, msg, err)
+}
+
+func (h *Handler) serverError(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	logger.FromContext(r.Context()).ErrorContext(r.Context(), msg, slog.String("error", err.Error()))
+	httpapi.WriteProblem(w, http.StatusInternalServerError, "error on server", nil)
+}
+
+// validate adds the rule violations of sub to errs, the fields that could
+// not be decoded, and returns them as a validation.Errors.
+func (h *Handler) validate(sub *Subscription, errs validation.Errors) error {
+	add := func(field, message string) {
+		if !slices.ContainsFunc(errs, func(e validation.FieldError) bool { return e.Field == field }) {
+			errs.Add(field, message)
+		}
+	}
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		add("url", "must be an absolute http or https URL")
+	} else if addr, err := netip.ParseAddr(u.Hostname()); err == nil && !egress.IsPublic(addr) {
+		// Host names are checked again when the dispatcher connects.
+		add("url", fmt.Sprintf("host %s is not a public address", u.Hostname()))
+	} else if len(h.allowedHosts) > 0 && !egress.HostAllowed(u.Hostname(), h.allowedHosts) {
+		add("url", fmt.Sprintf("host %s is not allowed", u.Hostname()))
+	}
+	if sub.Secret == "" {
+		add("secret", "is required")
+	}
+	if len(sub.Events) == 0 {
+		add("events", "must list at least one event")
+	}
+	for _, event := range sub.Events {
+		if !slices.Contains(Events, event) {
+			add("events", fmt.Sprintf("has unknown event %q", event))
+		}
+	}
+	return errs.Err()
+}
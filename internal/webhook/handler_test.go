@@ -0,0 +1,303 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sberTestTask/internal/httpapi"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockStore struct {
+	mock.Mock
+}
+
+func (m *mockStore) CreateSubscription(ctx context.Context, sub *Subscription) error {
+	return m.Called(ctx, sub).Error(0)
+}
+
+func (m *mockStore) GetSubscription(ctx context.Context, id int) (*Subscription, error) {
+	args := m.Called(ctx, id)
+	sub, _ := args.Get(0).(*Subscription)
+	return sub, args.Error(1)
+}
+
+func (m *mockStore) ListSubscriptions(ctx context.Context) ([]*Subscription, error) {
+	args := m.Called(ctx)
+	subs, _ := args.Get(0).([]*Subscription)
+	return subs, args.Error(1)
+}
+
+func (m *mockStore) DeleteSubscription(ctx context.Context, id int) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *mockStore) ListDeliveries(ctx context.Context, subscriptionID int, status Status, limit int) ([]*Delivery, error) {
+	args := m.Called(ctx, subscriptionID, status, limit)
+	deliveries, _ := args.Get(0).([]*Delivery)
+	return deliveries, args.Error(1)
+}
+
+func (m *mockStore) RetryDelivery(ctx context.Context, subscriptionID int, deliveryID int64) error {
+	return m.Called(ctx, subscriptionID, deliveryID).Error(0)
+}
+
+// problemDetail checks that rr is a problem+json answer and returns its
+// detail.
+func problemDetail(t *testing.T, rr *httptest.ResponseRecorder) string {
+	t.Helper()
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+	var problem httpapi.Problem
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+	assert.Equal(t, rr.Code, problem.Status)
+	return problem.Detail
+}
+
+func TestCreateSubscription(t *testing.T) {
+	created := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		body           string
+		mockReturn     error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Created",
+			body:           `{"url":"https://example.com/hook","secret":"s3cret","events":["task.created","task.deleted"]}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id":1,"url":"https://example.com/hook","events":["task.created","task.deleted"],"created_at":"2026-10-19T12:00:00Z"}`,
+		},
+		{
+			name:           "Invalid JSON",
+			body:           `{"url":`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "unexpected end of JSON input",
+		},
+		{
+			name:           "Server and unknown fields",
+			body:           `{"id":7,"url":"https://example.com/hook","secret":"s3cret","events":"task.created","active":true}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "active is not a known field; events must be an array; id is set by the server",
+		},
+		{
+			name:           "Relative URL",
+			body:           `{"url":"/hook","secret":"s3cret","events":["task.created"]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "url must be an absolute http or https URL",
+		},
+		{
+			name:           "Unsupported scheme",
+			body:           `{"url":"ftp://example.com/hook","secret":"s3cret","events":["task.created"]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "url must be an absolute http or https URL",
+		},
+		{
+			name:           "Metadata address",
+			body:           `{"url":"http://169.254.169.254/latest/meta-data","secret":"s3cret","events":["task.created"]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "url host 169.254.169.254 is not a public address",
+		},
+		{
+			name:           "Loopback address",
+			body:           `{"url":"http://[::1]:5432/","secret":"s3cret","events":["task.created"]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "url host ::1 is not a public address",
+		},
+		{
+			name:           "Private address",
+			body:           `{"url":"https://10.0.0.7/hook","secret":"s3cret","events":["task.created"]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "url host 10.0.0.7 is not a public address",
+		},
+		{
+			name:           "Missing secret",
+			body:           `{"url":"https://example.com/hook","events":["task.created"]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "secret is required",
+		},
+		{
+			name:           "No events",
+			body:           `{"url":"https://example.com/hook","secret":"s3cret","events":[]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "events must list at least one event",
+		},
+		{
+			name:           "Unknown event",
+			body:           `{"url":"https://example.com/hook","secret":"s3cret","events":["task.moved"]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `events has unknown event "task.moved"`,
+		},
+		{
+			name:           "Store error",
+			body:           `{"url":"https://example.com/hook","secret":"s3cret","events":["task.created"]}`,
+			mockReturn:     errors.New("connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "error on server",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mockStore)
+			store.On("CreateSubscription", mock.Anything, mock.MatchedBy(func(sub *Subscription) bool {
+				return sub.Secret == "s3cret"
+			})).Run(func(args mock.Arguments) {
+				sub := args.Get(1).(*Subscription)
+				sub.ID = 1
+				sub.CreatedAt = created
+			}).Return(tt.mockReturn).Maybe()
+
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			newHandler(store, nil).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			} else {
+				assert.Equal(t, tt.expectedBody, problemDetail(t, rr))
+			}
+			if tt.expectedStatus/100 == 4 {
+				store.AssertNotCalled(t, "CreateSubscription", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestCreateSubscriptionAllowedHosts(t *testing.T) {
+	store := new(mockStore)
+	store.On("CreateSubscription", mock.Anything, mock.Anything).Return(nil)
+	handler := newHandler(store, []string{"hooks.example.com", "*.example.org"})
+
+	for url, status := range map[string]int{
+		"https://hooks.example.com/a": http.StatusCreated,
+		"https://ci.example.org/a":    http.StatusCreated,
+		"https://example.net/a":       http.StatusUnprocessableEntity,
+	} {
+		body := `{"url":"` + url + `","secret":"s3cret","events":["task.created"]}`
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("POST", "/", strings.NewReader(body)))
+		assert.Equal(t, status, rr.Code, url)
+	}
+	store.AssertNumberOfCalls(t, "CreateSubscription", 2)
+}
+
+func TestListSubscriptions(t *testing.T) {
+	created := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		subs           []*Subscription
+		mockError      error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Subscriptions",
+			subs: []*Subscription{
+				{ID: 1, URL: "https://example.com/a", Events: []string{"task.created"}, CreatedAt: created},
+				{ID: 2, URL: "https://example.com/b", Events: []string{"task.deleted"}, CreatedAt: created},
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `[{"id":1,"url":"https://example.com/a","events":["task.created"],"created_at":"2026-10-19T12:00:00Z"},
+				{"id":2,"url":"https://example.com/b","events":["task.deleted"],"created_at":"2026-10-19T12:00:00Z"}]`,
+		},
+		{
+			name:           "Empty",
+			subs:           []*Subscription{},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[]`,
+		},
+		{
+			name:           "Store error",
+			mockError:      errors.New("connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "error on server",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mockStore)
+			store.On("ListSubscriptions", mock.Anything).Return(tt.subs, tt.mockError)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			rr := httptest.NewRecorder()
+			newHandler(store, nil).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.mockError == nil {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+				var subs []map[string]any
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &subs))
+				for _, sub := range subs {
+					assert.NotContains(t, sub, "secret")
+				}
+			} else {
+				assert.Equal(t, tt.expectedBody, problemDetail(t, rr))
+			}
+		})
+	}
+}
+
+func TestDeleteSubscription(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		mockError      error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Deleted",
+			id:             "1",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Not found",
+			id:             "2",
+			mockError:      ErrNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "not found",
+		},
+		{
+			name:           "Invalid id",
+			id:             "abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid syntax",
+		},
+		{
+			name:           "Store error",
+			id:             "3",
+			mockError:      errors.New("connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "error on server",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mockStore)
+			store.On("DeleteSubscription", mock.Anything, mock.Anything).Return(tt.mockError).Maybe()
+
+			req := httptest.NewRequest("DELETE", "/"+tt.id, nil)
+			rr := httptest.NewRecorder()
+			newHandler(store, nil).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus != http.StatusNoContent {
+				assert.Contains(t, problemDetail(t, rr), tt.expectedBody)
+			}
+			if tt.expectedStatus == http.StatusBadRequest {
+				store.AssertNotCalled(t, "DeleteSubscription", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
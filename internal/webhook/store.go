@@ -0,0 +1,250 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"time"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Store keeps subscriptions and deliveries in Postgres. Deliveries are
+// created from the task_outbox table, which the task repository writes in
+// the same transaction as the task change.
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateSubscription(ctx context.Context, sub *Subscription) error {
+	return s.db.QueryRowContext(ctx,
+		"INSERT INTO webhook_subscriptions (url, secret, events) VALUES ($1, $2, $3) RETURNING id, created_at",
+		sub.URL, sub.Secret, pq.Array(sub.Events),
+	).Scan(&sub.ID, &sub.CreatedAt)
+}
+
+func (s *Store) GetSubscription(ctx context.Context, id int) (*Subscription, error) {
+	sub := &Subscription{}
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, url, events, created_at FROM webhook_subscriptions WHERE id = $1", id,
+	).Scan(&sub.ID, &sub.URL, pq.Array(&sub.Events), &sub.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (s *Store) ListSubscriptions(ctx context.Context) ([]*Subscription, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, url, events, created_at FROM webhook_subscriptions ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []*Subscription{}
+	for rows.Next() {
+		sub := &Subscription{}
+		if err := rows.Scan(&sub.ID, &sub.URL, pq.Array(&sub.Events), &sub.CreatedAt); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// StreamSubscriptions calls fn for every subscription, secret included, in
+// ID order.
+func (s *Store) StreamSubscriptions(ctx context.Context, fn func(*Subscription) error) error {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, url, secret, events, created_at FROM webhook_subscriptions ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		sub := &Subscription{}
+		if err := rows.Scan(&sub.ID, &sub.URL, &sub.Secret, pq.Array(&sub.Events), &sub.CreatedAt); err != nil {
+			return err
+		}
+		if err := fn(sub); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// RestoreSubscription inserts a subscription from a backup under its ID, or
+// under a new one if the ID is 0, and moves the ID sequence past it. An
+// existing subscription with the ID is replaced if overwrite is set and kept
+// otherwise.
+func (s *Store) RestoreSubscription(ctx context.Context, sub *Subscription, overwrite bool) (bool, error) {
+	if sub.ID == 0 {
+		err := s.db.QueryRowContext(ctx,
+			"INSERT INTO webhook_subscriptions (url, secret, events, created_at) VALUES ($1, $2, $3, $4) RETURNING id",
+			sub.URL, sub.Secret, pq.Array(sub.Events), sub.CreatedAt,
+		).Scan(&sub.ID)
+		return err == nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	onConflict := "DO NOTHING"
+	if overwrite {
+		onConflict = "DO UPDATE SET url = EXCLUDED.url, secret = EXCLUDED.secret, events = EXCLUDED.events, created_at = EXCLUDED.created_at"
+	}
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO webhook_subscriptions (id, url, secret, events, created_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (id) "+onConflict,
+		sub.ID, sub.URL, sub.Secret, pq.Array(sub.Events), sub.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.ExecContext(ctx, `SELECT setval('webhook_subscriptions_id_seq',
+    GREATEST((SELECT MAX(id) FROM webhook_subscriptions), (SELECT last_value FROM webhook_subscriptions_id_seq)))`)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, tx.Commit()
+}
+
+// DeleteSubscription removes a subscription together with its delivery log.
+func (s *Store) DeleteSubscription(ctx context.Context, id int) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListDeliveries returns the most recent deliveries of a subscription,
+// optionally only those with the given status.
+func (s *Store) ListDeliveries(ctx context.Context, subscriptionID int, status Status, limit int) ([]*Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, subscription_id, event, payload, status, attempts, next_attempt_at,
+       last_status_code, last_error, created_at, delivered_at
+FROM webhook_deliveries
+WHERE subscription_id = $1 AND ($2 = '' OR status = $2)
+ORDER BY id DESC LIMIT $3`, subscriptionID, string(status), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*Delivery{}
+	for rows.Next() {
+		d := &Delivery{}
+		var next time.Time
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &next,
+			&d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt)
+		if err != nil {
+			return nil, err
+		}
+		if d.Status == StatusPending {
+			d.NextAttemptAt = &next
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// RetryDelivery moves a dead delivery back to the queue with a fresh set of attempts.
+func (s *Store) RetryDelivery(ctx context.Context, subscriptionID int, id int64) error {
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE webhook_deliveries SET status = $1, attempts = 0, next_attempt_at = now() WHERE id = $2 AND subscription_id = $3 AND status = $4",
+		StatusPending, id, subscriptionID, StatusDead)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// fanOut moves up to limit changes from the outbox into one delivery per
+// matching subscription and returns the number of changes taken. SKIP
+// LOCKED lets several replicas drain the outbox at the same time.
+func (s *Store) fanOut(ctx context.Context, limit int) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `WITH batch AS (
+    DELETE FROM task_outbox
+    WHERE id IN (SELECT id FROM task_outbox ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED)
+    RETURNING id, 'task.' || event AS event, task, created_at
+), created AS (
+    INSERT INTO webhook_deliveries (subscription_id, event, payload)
+    SELECT s.id, b.event, jsonb_build_object('event_id', b.id, 'event', b.event, 'occurred_at', b.created_at, 'task', b.task)
+    FROM batch b JOIN webhook_subscriptions s ON b.event = ANY (s.events)
+    ORDER BY b.id
+)
+SELECT count(*) FROM batch`, limit).Scan(&n)
+	return n, err
+}
+
+// claim leases up to limit due deliveries. A leased delivery is not handed
+// out again until the lease expires, so a crashed dispatcher only delays it.
+func (s *Store) claim(ctx context.Context, limit int, lease time.Duration) ([]*attempt, error) {
+	rows, err := s.db.QueryContext(ctx, `UPDATE webhook_deliveries d
+SET next_attempt_at = now() + make_interval(secs => $2)
+FROM webhook_subscriptions s
+WHERE s.id = d.subscription_id AND d.id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= now()
+    ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED
+)
+RETURNING d.id, d.event, d.payload, d.attempts, s.url, s.secret`, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*attempt
+	for rows.Next() {
+		a := &attempt{}
+		if err := rows.Scan(&a.id, &a.event, &a.payload, &a.attempts, &a.url, &a.secret); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+// finish stores the outcome of an attempt.
+func (s *Store) finish(ctx context.Context, a *attempt, res result) error {
+	var statusCode *int
+	if res.statusCode != 0 {
+		statusCode = &res.statusCode
+	}
+	var lastError *string
+	if res.err != nil {
+		msg := res.err.Error()
+		lastError = &msg
+	}
+
+	var deliveredAt *time.Time
+	if res.status == StatusDelivered {
+		now := time.Now()
+		deliveredAt = &now
+	}
+
+	_, err := s.db.ExecContext(ctx, `UPDATE webhook_deliveries
+SET status = $1, attempts = $2, next_attempt_at = $3, last_status_code = $4, last_error = $5, delivered_at = $6
+WHERE id = $7`, res.status, a.attempts+1, res.next, statusCode, lastError, deliveredAt, a.id)
+	return err
+}
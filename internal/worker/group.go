@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Group runs named background workers and waits for them on shutdown.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	failed map[string]error
+}
+
+func NewGroup() *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{ctx: ctx, cancel: cancel, failed: make(map[string]error)}
+}
+
+// Go starts fn in its own goroutine. The context passed to fn is cancelled by Stop.
+func (g *Group) Go(name string, fn func(ctx context.Context) error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		slog.Info("worker started", slog.String("worker", name))
+		if err := fn(g.ctx); err != nil && g.ctx.Err() == nil {
+			slog.Error("worker stopped", slog.String("worker", name), slog.String("error", err.Error()))
+			g.mu.Lock()
+			g.failed[name] = err
+			g.mu.Unlock()
+			return
+		}
+		slog.Info("worker stopped", slog.String("worker", name))
+	}()
+}
+
+// Stop cancels all workers and waits until they return or ctx is done.
+func (g *Group) Stop(ctx context.Context) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (g *Group) Name() string { return "workers" }
+
+// Check reports workers that exited with an error before shutdown.
+func (g *Group) Check(context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.failed) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(g.failed))
+	for name, err := range g.failed {
+		names = append(names, fmt.Sprintf("%s: %v", name, err))
+	}
+	sort.Strings(names)
+	return fmt.Errorf("failed workers: %s", strings.Join(names, "; "))
+}
@@ -0,0 +1,264 @@
+// Package client is a Go client for the task REST API. Failed responses are
+// returned as *Error and match ErrNotFound and the other sentinel errors;
+// 429 and 5xx responses are retried with backoff (see RetryPolicy), and
+// Tasks iterates over all pages of a listing.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// Task mirrors the API task. DueDate is required when creating a task.
+type Task struct {
+	ID          int        `json:"id,omitempty"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	DueDate     *time.Time `json:"due_date"`
+	Completed   bool       `json:"completed"`
+	// AllDay tasks are due on the calendar day of DueDate.
+	AllDay bool `json:"all_day,omitempty"`
+
+	// Computed by the server when the task is read.
+	Overdue     bool   `json:"overdue,omitempty"`
+	DueIn       *int64 `json:"due_in,omitempty"`
+	DaysOverdue int    `json:"days_overdue,omitempty"`
+}
+
+// TaskPage is one page of ListTasks. CurPage is clamped to CountPage by
+// the server.
+type TaskPage struct {
+	CountPage int     `json:"count_page"`
+	CurPage   int     `json:"cur_page"`
+	Tasks     []*Task `json:"tasks"`
+}
+
+// ListOptions are the filters and pagination of ListTasks. Zero values are
+// left to the server defaults.
+type ListOptions struct {
+	Completed *bool
+	// Date matches tasks due on that calendar day, in the client's time
+	// zone (see WithTimeZone).
+	Date *time.Time
+	// Overdue selects open tasks past their due date, or all others.
+	Overdue *bool
+	Limit   int
+	Page    int
+}
+
+// Bool returns a pointer to v, for ListOptions.Completed and TaskUpdate.
+func Bool(v bool) *bool { return &v }
+
+// String returns a pointer to v, for TaskUpdate.
+func String(v string) *string { return &v }
+
+// TaskUpdate changes only the fields that are set.
+type TaskUpdate struct {
+	Title       *string
+	Description *string
+	DueDate     *time.Time
+	Completed   *bool
+	AllDay      *bool
+}
+
+func (u TaskUpdate) body() map[string]any {
+	body := make(map[string]any)
+	if u.Title != nil {
+		body["title"] = *u.Title
+	}
+	if u.Description != nil {
+		body["description"] = *u.Description
+	}
+	if u.DueDate != nil {
+		body["due_date"] = u.DueDate.Format(time.RFC3339)
+	}
+	if u.Completed != nil {
+		body["completed"] = *u.Completed
+	}
+	if u.AllDay != nil {
+		body["all_day"] = *u.AllDay
+	}
+	return body
+}
+
+// apiVersion is the version of the REST API the client speaks.
+const apiVersion = "v1"
+
+// Client is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	apiKey     string
+	httpClient *http.Client
+	retry      RetryPolicy
+	timeZone   string
+}
+
+type Option func(*Client)
+
+// WithAPIKey sends key in the X-API-Key header of every request.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTimeZone sends the IANA time zone name in the tz parameter of every
+// request, so that date filters and the computed fields use it instead of
+// the user's or server's default.
+func WithTimeZone(name string) Option {
+	return func(c *Client) {
+		c.timeZone = name
+	}
+}
+
+// New returns a client for the API served at baseURL, e.g. http://localhost:8080.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid server URL %q", baseURL)
+	}
+
+	c := &Client{baseURL: u, httpClient: &http.Client{Timeout: 30 * time.Second}, retry: DefaultRetryPolicy}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+func (c *Client) CreateTask(ctx context.Context, task *Task) (*Task, error) {
+	var created Task
+	if err := c.do(ctx, http.MethodPost, "/tasks", nil, task, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) GetTask(ctx context.Context, id int) (*Task, error) {
+	var task Task
+	if err := c.do(ctx, http.MethodGet, "/tasks/"+strconv.Itoa(id), nil, nil, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) ListTasks(ctx context.Context, opts ListOptions) (*TaskPage, error) {
+	query := url.Values{}
+	if opts.Completed != nil {
+		query.Set("completed", strconv.FormatBool(*opts.Completed))
+	}
+	if opts.Date != nil {
+		query.Set("date", opts.Date.Format(time.DateOnly))
+	}
+	if opts.Overdue != nil {
+		query.Set("overdue", strconv.FormatBool(*opts.Overdue))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Page > 0 {
+		query.Set("page", strconv.Itoa(opts.Page))
+	}
+
+	var page TaskPage
+	if err := c.do(ctx, http.MethodGet, "/tasks", query, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) UpdateTask(ctx context.Context, id int, update TaskUpdate) (*Task, error) {
+	var task Task
+	if err := c.do(ctx, http.MethodPut, "/tasks/"+strconv.Itoa(id), nil, update.body(), &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) DeleteTask(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, "/tasks/"+strconv.Itoa(id), nil, nil, nil)
+}
+
+// do sends the request, retrying it according to the retry policy, and
+// decodes a 2xx JSON response into out.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	u := c.baseURL.JoinPath(apiVersion, path)
+	if c.timeZone != "" {
+		if query == nil {
+			query = url.Values{}
+		}
+		query.Set("tz", c.timeZone)
+	}
+	u.RawQuery = query.Encode()
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.send(ctx, method, u.String(), payload, out)
+		if err == nil || attempt >= c.retry.MaxAttempts || !retryable(method, err) {
+			return err
+		}
+		if err := c.retry.wait(ctx, attempt, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, url string, payload []byte, out any) error {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set(apiKeyHeader, c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return decodeError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
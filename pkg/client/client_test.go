@@ -0,0 +1,270 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sberTestTask/internal/auth"
+	"sberTestTask/internal/ratelimit"
+	"sberTestTask/internal/todo"
+	"sberTestTask/internal/todo/delivery/api"
+	"sberTestTask/internal/todo/service"
+	"sberTestTask/internal/todo/tests/mocks/serviceMock"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var noBackoff = RetryPolicy{MaxAttempts: 3}
+
+func setupServer(t *testing.T) (*Client, *serviceMock.MockTodoUsecase) {
+	uc := new(serviceMock.MockTodoUsecase)
+	router := chi.NewRouter()
+	api.RegisterRoutes(router, api.NewHandler(uc), api.RouteOptions{
+		Auth: auth.NewAuthenticator(map[string]string{"secret": "alice"}, true),
+	})
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	c, err := New(srv.URL, WithAPIKey("secret"), WithRetryPolicy(noBackoff))
+	require.NoError(t, err)
+	return c, uc
+}
+
+// flakyServer answers with the given statuses before passing requests to
+// next, and counts the requests.
+func flakyServer(t *testing.T, next http.Handler, statuses ...int) (*Client, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n <= len(statuses) {
+			http.Error(w, "try again", statuses[n-1])
+			return
+		}
+		next.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := New(srv.URL, WithRetryPolicy(noBackoff))
+	require.NoError(t, err)
+	return c, &calls
+}
+
+func TestCreateTask(t *testing.T) {
+	c, uc := setupServer(t)
+	due := time.Date(2024, 6, 7, 0, 0, 0, 0, time.UTC)
+	uc.On("CreateTask", mock.Anything, mock.MatchedBy(func(task *todo.Task) bool {
+		return task.Title == "Write report" && task.DueDate.Equal(due)
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*todo.Task).ID = 12
+	}).Return(nil)
+
+	task, err := c.CreateTask(context.Background(), &Task{Title: "Write report", DueDate: &due})
+	require.NoError(t, err)
+	assert.Equal(t, 12, task.ID)
+	assert.Equal(t, "Write report", task.Title)
+}
+
+func TestListTasks(t *testing.T) {
+	c, uc := setupServer(t)
+	completed := false
+	date := time.Date(2024, 6, 7, 0, 0, 0, 0, time.UTC)
+	uc.On("ListTasks", mock.Anything, mock.MatchedBy(func(f todo.TaskFilter) bool {
+		return *f.Completed == completed && f.DueDate != nil && f.DueDate.Equal(date)
+	}), 5, 2).Return(&todo.Pages{CountPage: 3, CurPage: 2, Tasks: []*todo.Task{{ID: 1, Title: "a"}}}, nil)
+
+	page, err := c.ListTasks(context.Background(), ListOptions{Completed: &completed, Date: &date, Limit: 5, Page: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, page.CountPage)
+	require.Len(t, page.Tasks, 1)
+	assert.Equal(t, "a", page.Tasks[0].Title)
+}
+
+func TestWithTimeZone(t *testing.T) {
+	c, uc := setupServer(t)
+	tz, err := New(c.baseURL.String(), WithAPIKey("secret"), WithTimeZone("Europe/Moscow"))
+	require.NoError(t, err)
+	uc.On("GetTask", mock.MatchedBy(func(ctx context.Context) bool {
+		loc, ok := todo.LocationFromContext(ctx)
+		return ok && loc.String() == "Europe/Moscow"
+	}), 12).Return(&todo.Task{ID: 12, Title: "a", AllDay: true}, nil)
+
+	task, err := tz.GetTask(context.Background(), 12)
+	require.NoError(t, err)
+	assert.True(t, task.AllDay)
+}
+
+func TestUpdateTask(t *testing.T) {
+	c, uc := setupServer(t)
+	due := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+	uc.On("GetTask", mock.Anything, 12).Return(&todo.Task{ID: 12, Title: "old", Description: "keep", DueDate: &due}, nil)
+	uc.On("UpdateTask", mock.Anything, &todo.Task{ID: 12, Title: "old", Description: "keep", DueDate: &due, Completed: true}).Return(nil)
+
+	completed := true
+	task, err := c.UpdateTask(context.Background(), 12, TaskUpdate{Completed: &completed})
+	require.NoError(t, err)
+	assert.True(t, task.Completed)
+	assert.Equal(t, "keep", task.Description)
+}
+
+func TestDeleteTask(t *testing.T) {
+	c, uc := setupServer(t)
+	uc.On("GetTask", mock.Anything, 12).Return(&todo.Task{ID: 12}, nil)
+	uc.On("DeleteTask", mock.Anything, 12).Return(nil)
+
+	require.NoError(t, c.DeleteTask(context.Background(), 12))
+	uc.AssertExpectations(t)
+}
+
+func TestErrors(t *testing.T) {
+	c, uc := setupServer(t)
+	uc.On("GetTask", mock.Anything, 404).Return((*todo.Task)(nil), service.ErrIdNotFound)
+
+	_, err := c.GetTask(context.Background(), 404)
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, service.ErrIdNotFound.Error(), apiErr.Message)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NotErrorIs(t, err, ErrServer)
+
+	_, err = c.CreateTask(context.Background(), &Task{Title: " "})
+	require.True(t, errors.As(err, &apiErr))
+	assert.ErrorIs(t, err, ErrInvalid)
+	assert.Equal(t, []FieldError{{Field: "title", Message: "cannot be empty"}, {Field: "due_date", Message: "is required"}}, apiErr.Fields)
+
+	c.apiKey = "wrong"
+	_, err = c.GetTask(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestProblemError(t *testing.T) {
+	key, err := ratelimit.NewKeyFunc(ratelimit.KeyByIP)
+	require.NoError(t, err)
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Limit{Rate: 0.001, Burst: 1}, nil, key)
+	uc := new(serviceMock.MockTodoUsecase)
+	uc.On("GetTask", mock.Anything, 1).Return(&todo.Task{ID: 1}, nil)
+	router := chi.NewRouter()
+	api.RegisterRoutes(router, api.NewHandler(uc), api.RouteOptions{RateLimit: limiter})
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	c, err := New(srv.URL, WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	require.NoError(t, err)
+	_, err = c.GetTask(context.Background(), 1)
+	require.NoError(t, err)
+
+	_, err = c.GetTask(context.Background(), 1)
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Contains(t, apiErr.Message, "rate limit exceeded")
+	assert.Positive(t, apiErr.RetryAfter)
+}
+
+func TestRetry(t *testing.T) {
+	uc := new(serviceMock.MockTodoUsecase)
+	uc.On("GetTask", mock.Anything, 1).Return(&todo.Task{ID: 1, Title: "a"}, nil)
+	handler := chi.NewRouter()
+	api.RegisterRoutes(handler, api.NewHandler(uc), api.RouteOptions{})
+
+	t.Run("server errors", func(t *testing.T) {
+		c, calls := flakyServer(t, handler, http.StatusBadGateway, http.StatusServiceUnavailable)
+		task, err := c.GetTask(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, "a", task.Title)
+		assert.EqualValues(t, 3, calls.Load())
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		c, calls := flakyServer(t, handler, 500, 500, 500, 500)
+		_, err := c.GetTask(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrServer)
+		assert.EqualValues(t, 3, calls.Load())
+	})
+
+	t.Run("create is not retried on 500", func(t *testing.T) {
+		c, calls := flakyServer(t, handler, http.StatusInternalServerError)
+		_, err := c.CreateTask(context.Background(), &Task{Title: "a"})
+		assert.ErrorIs(t, err, ErrServer)
+		assert.EqualValues(t, 1, calls.Load())
+	})
+
+	t.Run("create is retried on 429", func(t *testing.T) {
+		uc.On("CreateTask", mock.Anything, mock.Anything).Return(nil)
+		c, calls := flakyServer(t, handler, http.StatusTooManyRequests)
+		due := time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC)
+		_, err := c.CreateTask(context.Background(), &Task{Title: "a", DueDate: &due})
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, calls.Load())
+	})
+
+	t.Run("client errors", func(t *testing.T) {
+		c, calls := flakyServer(t, handler, http.StatusBadRequest)
+		_, err := c.GetTask(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrBadRequest)
+		assert.EqualValues(t, 1, calls.Load())
+	})
+
+	t.Run("context", func(t *testing.T) {
+		c, err := New("http://example.com", WithRetryPolicy(RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour, MaxBackoff: time.Hour}))
+		require.NoError(t, err)
+		c.baseURL.Host = "127.0.0.1:1"
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = c.GetTask(ctx, 1)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestTasksIterator(t *testing.T) {
+	c, uc := setupServer(t)
+	pages := [][]*todo.Task{
+		{{ID: 1}, {ID: 2}},
+		{{ID: 3}, {ID: 4}},
+		{{ID: 5}},
+	}
+	for i, tasks := range pages {
+		uc.On("ListTasks", mock.Anything, todo.TaskFilter{}, 2, i+1).
+			Return(&todo.Pages{CountPage: len(pages), CurPage: i + 1, Tasks: tasks}, nil).Once()
+	}
+
+	tasks, err := c.AllTasks(context.Background(), ListOptions{Limit: 2})
+	require.NoError(t, err)
+	var ids []int
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids)
+	uc.AssertExpectations(t)
+}
+
+func TestTasksIteratorEmpty(t *testing.T) {
+	c, uc := setupServer(t)
+	uc.On("ListTasks", mock.Anything, todo.TaskFilter{Completed: Bool(true)}, 10, 1).
+		Return(&todo.Pages{CountPage: 0, CurPage: 0}, nil).Once()
+
+	it := c.Tasks(context.Background(), ListOptions{Completed: Bool(true), Limit: 10})
+	assert.False(t, it.Next())
+	assert.NoError(t, it.Err())
+}
+
+func TestTasksIteratorError(t *testing.T) {
+	c, uc := setupServer(t)
+	uc.On("ListTasks", mock.Anything, todo.TaskFilter{}, 1, 1).
+		Return(&todo.Pages{CountPage: 2, CurPage: 1, Tasks: []*todo.Task{{ID: 1}}}, nil).Once()
+	uc.On("ListTasks", mock.Anything, todo.TaskFilter{}, 1, 2).
+		Return((*todo.Pages)(nil), service.ErrOnServer)
+
+	it := c.Tasks(context.Background(), ListOptions{Limit: 1})
+	require.True(t, it.Next())
+	assert.Equal(t, 1, it.Task().ID)
+	assert.False(t, it.Next())
+	assert.ErrorIs(t, it.Err(), ErrServer)
+}
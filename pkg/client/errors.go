@@ -0,0 +1,113 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors matched by *Error with errors.Is, depending on the status code.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrInvalid      = errors.New("invalid data")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServer       = errors.New("server error")
+)
+
+// Error is returned for responses with a non-2xx status.
+type Error struct {
+	StatusCode int
+	// Message is the plain-text body or the detail of a problem+json body.
+	Message string
+	// Fields are the invalid fields of a request rejected with 422.
+	Fields []FieldError
+	// RetryAfter is the Retry-After header of 429 and 503 responses.
+	RetryAfter time.Duration
+}
+
+// FieldError is one invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func (e *Error) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		return target == ErrBadRequest
+	case http.StatusUnauthorized:
+		return target == ErrUnauthorized
+	case http.StatusForbidden:
+		return target == ErrForbidden
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return target == ErrConflict
+	case http.StatusUnprocessableEntity:
+		return target == ErrInvalid
+	case http.StatusTooManyRequests:
+		return target == ErrRateLimited
+	}
+	return e.StatusCode >= 500 && target == ErrServer
+}
+
+// problem is the RFC 9457 body the rate limiter and other middlewares use.
+type problem struct {
+	Title   string       `json:"title"`
+	Detail  string       `json:"detail"`
+	Message string       `json:"message"`
+	Error   string       `json:"error"`
+	Errors  []FieldError `json:"errors"`
+}
+
+func decodeError(resp *http.Response) *Error {
+	e := &Error{StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp.Header.Get("Retry-After"))}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	e.Message = strings.TrimSpace(string(body))
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "application/json" || mediaType == "application/problem+json" {
+		var p problem
+		if json.Unmarshal(body, &p) == nil {
+			e.Fields = p.Errors
+			for _, msg := range []string{p.Detail, p.Message, p.Error, p.Title} {
+				if msg != "" {
+					e.Message = msg
+					break
+				}
+			}
+		}
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
+
+// retryAfter parses delay-seconds or an HTTP date.
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
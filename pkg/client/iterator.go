@@ -0,0 +1,90 @@
+package client
+
+import "context"
+
+// TaskIterator walks all tasks matching ListOptions page by page:
+//
+//	it := c.Tasks(ctx, client.ListOptions{Completed: &open})
+//	for it.Next() {
+//		task := it.Task()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// Tasks created or deleted during the walk can shift page boundaries, so a
+// task may be skipped or seen twice.
+type TaskIterator struct {
+	ctx    context.Context
+	client *Client
+	opts   ListOptions
+	page   *TaskPage
+	index  int
+	done   bool
+	err    error
+}
+
+// Tasks returns an iterator starting at opts.Page, or the first page.
+func (c *Client) Tasks(ctx context.Context, opts ListOptions) *TaskIterator {
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	return &TaskIterator{ctx: ctx, client: c, opts: opts}
+}
+
+// Next advances to the next task, fetching the next page when needed. It
+// returns false after the last task or on error.
+func (it *TaskIterator) Next() bool {
+	for {
+		if it.err != nil {
+			return false
+		}
+		if it.page != nil && it.index < len(it.page.Tasks)-1 {
+			it.index++
+			return true
+		}
+		if it.done {
+			return false
+		}
+
+		page, err := it.client.ListTasks(it.ctx, it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		// The server returns the last page again for pages past the end.
+		if page.CurPage < it.opts.Page {
+			it.done = true
+			it.page = nil
+			return false
+		}
+		it.page, it.index = page, -1
+		it.done = page.CurPage >= page.CountPage || len(page.Tasks) == 0
+		it.opts.Page++
+	}
+}
+
+// Task returns the current task.
+func (it *TaskIterator) Task() *Task {
+	return it.page.Tasks[it.index]
+}
+
+// Page returns the page the current task belongs to.
+func (it *TaskIterator) Page() *TaskPage {
+	return it.page
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *TaskIterator) Err() error {
+	return it.err
+}
+
+// AllTasks collects every task matching opts.
+func (c *Client) AllTasks(ctx context.Context, opts ListOptions) ([]*Task, error) {
+	var tasks []*Task
+	it := c.Tasks(ctx, opts)
+	for it.Next() {
+		tasks = append(tasks, it.Task())
+	}
+	return tasks, it.Err()
+}